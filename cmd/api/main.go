@@ -1,17 +1,30 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"os"
+	"strconv"
+	"time"
 	"todo-go/internal/handler"
 	"todo-go/internal/model"
 	"todo-go/internal/repository"
+	"todo-go/internal/search"
 	"todo-go/internal/service"
+	"todo-go/internal/storefront"
+	"todo-go/pkg/cache"
+	"todo-go/pkg/domain"
 	"todo-go/pkg/jwt"
+	"todo-go/pkg/mailer"
 	"todo-go/pkg/middleware"
+	"todo-go/pkg/notify"
+	"todo-go/pkg/payment"
 	"todo-go/pkg/qr"
+	"todo-go/pkg/shipping"
+	"todo-go/pkg/storage"
 
 	"github.com/gorilla/handlers"
 	"gorm.io/driver/mysql"
@@ -40,54 +53,229 @@ func main() {
 
 	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{
 		Logger: logger.Default.LogMode(logger.Silent),
+		// Report unique index violations as gorm.ErrDuplicatedKey
+		TranslateError: true,
 	})
 	if err != nil {
 		log.Fatalf("failed to open database connection: %s", err.Error())
 	}
 
+	// Domains must be unique before their index can be created, and follow
+	// the rules new ones do
+	if err := repository.PrepareWebsiteDomains(db, service.DomainAllowed); err != nil {
+		log.Fatalf("failed to prepare website domains: %s", err.Error())
+	}
+
+	// Likewise for verified custom domains
+	if err := repository.PrepareCustomDomains(db); err != nil {
+		log.Fatalf("failed to prepare custom domains: %s", err.Error())
+	}
+
+	// and for SKUs within a store
+	if err := repository.PrepareProductSKUs(db); err != nil {
+		log.Fatalf("failed to prepare product SKUs: %s", err.Error())
+	}
+
+	// and for SKUs of variants within a store
+	if err := repository.PrepareVariantSKUs(db); err != nil {
+		log.Fatalf("failed to prepare variant SKUs: %s", err.Error())
+	}
+
+	// and for the pending payment of an order
+	if err := repository.PreparePendingPayments(db); err != nil {
+		log.Fatalf("failed to prepare pending payments: %s", err.Error())
+	}
+
+	// Products that never counted stock must stay orderable
+	if err := repository.PrepareStockTracking(db); err != nil {
+		log.Fatalf("failed to prepare stock tracking: %s", err.Error())
+	}
+
 	// Run auto migration for all models
 	err = db.AutoMigrate(
 		&model.User{},
 		&model.Todo{},
 		&model.Store{},
 		&model.Product{},
+		&model.ProductImage{},
+		&model.ProductVariant{},
+		&model.Category{},
 		&model.Website{},
+		&model.WebsiteRevision{},
 		&model.Order{},
+		&model.StockMovement{},
+		&model.StockAlert{},
+		&model.Sale{},
+		&model.Voucher{},
+		&model.VoucherRedemption{},
+		&model.ShippingMethod{},
+		&model.Payment{},
+		&model.PaymentEvent{},
+		&model.QRCode{},
+		&model.QRScan{},
 	)
 	if err != nil {
 		log.Fatalf("failed to run database migration: %s", err.Error())
 	}
 
+	// Products from before categories only had a free-text name
+	if err := repository.MigrateLegacyCategories(db); err != nil {
+		log.Fatalf("failed to migrate legacy categories: %s", err.Error())
+	}
+
 	// Initialize core services
 	jwtSvc := jwt.NewService("secretttt")
 	qrSvc := qr.NewService()
+	mailSvc := mailer.NewLogMailer()
+	notifier := notify.NewMailNotifier(mailSvc)
+	carrierRater := shipping.NewStubRater(10000)
+	domainVerifier := domain.NewVerifier(net.DefaultResolver, "_umkm-verify")
+	themes, err := storefront.NewRegistry(getEnv("TEMPLATE_DIR", ""))
+	if err != nil {
+		log.Fatalf("failed to load storefront templates: %s", err.Error())
+	}
+	renderer := storefront.NewRenderer(themes)
+
+	// Initialize the payment gateway; only the local mock is built in so far.
+	// Provider and secret have no defaults so a deploy never runs on the mock
+	// by accident
+	webhookSecret := os.Getenv("PAYMENT_WEBHOOK_SECRET")
+	if webhookSecret == "" {
+		log.Fatal("PAYMENT_WEBHOOK_SECRET is required")
+	}
+	var paymentProvider payment.Provider
+	var mockPayments *payment.MockProvider
+	switch provider := os.Getenv("PAYMENT_PROVIDER"); provider {
+	case "":
+		log.Fatal("PAYMENT_PROVIDER is required")
+	case "mock":
+		mockPayments = payment.NewMockProvider(webhookSecret)
+		paymentProvider = mockPayments
+	default:
+		log.Fatalf("unknown PAYMENT_PROVIDER: %s", provider)
+	}
+
+	// The mock's simulate route lets anyone mark a payment paid, so it is
+	// only mounted when asked for explicitly
+	mockEnabled, err := strconv.ParseBool(getEnv("PAYMENT_MOCK_ENABLED", "false"))
+	if err != nil {
+		log.Fatalf("invalid PAYMENT_MOCK_ENABLED: %s", err.Error())
+	}
+	if mockEnabled && mockPayments == nil {
+		log.Fatal("PAYMENT_MOCK_ENABLED requires PAYMENT_PROVIDER=mock")
+	}
+	if mockEnabled {
+		log.Println("WARNING: PAYMENT_MOCK_ENABLED is on, anyone can mark orders paid through POST /api/v1/payments/mock/{ref}. Never enable it in production.")
+	}
+
+	// Initialize blob storage for uploaded images
+	uploadDir := getEnv("UPLOAD_DIR", "./uploads")
+	var blobStore storage.BlobStore
+	switch getEnv("BLOB_BACKEND", "local") {
+	case "s3":
+		if os.Getenv("S3_PUBLIC_URL") == "" {
+			log.Fatal("S3_PUBLIC_URL is required when BLOB_BACKEND is s3")
+		}
+		blobStore = storage.NewS3Store(storage.S3Config{
+			Endpoint:  os.Getenv("S3_ENDPOINT"),
+			Region:    os.Getenv("S3_REGION"),
+			Bucket:    os.Getenv("S3_BUCKET"),
+			AccessKey: os.Getenv("S3_ACCESS_KEY"),
+			SecretKey: os.Getenv("S3_SECRET_KEY"),
+			PublicURL: os.Getenv("S3_PUBLIC_URL"),
+		})
+	default:
+		blobStore = storage.NewLocalStore(uploadDir, getEnv("PUBLIC_BASE_URL", "http://localhost:8080")+"/uploads")
+	}
+
+	// Stores are served at {domain}.{platformDomain} and at verified custom domains
+	platformDomain := getEnv("PLATFORM_DOMAIN", "ourplatform.id")
 
 	// Initialize repositories
 	userRepo := repository.NewUserRepository(db)
 	storeRepo := repository.NewStoreRepository(db)
 	productRepo := repository.NewProductRepository(db)
+	productImageRepo := repository.NewProductImageRepository(db)
+	productVariantRepo := repository.NewProductVariantRepository(db)
+	categoryRepo := repository.NewCategoryRepository(db)
 	websiteRepo := repository.NewWebsiteRepository(db)
 	orderRepo := repository.NewOrderRepository(db)
 	todoRepo := repository.NewTodoRepository(db)
+	stockRepo := repository.NewStockRepository(db)
+	saleRepo := repository.NewSaleRepository(db)
+	voucherRepo := repository.NewVoucherRepository(db)
+	shippingRepo := repository.NewShippingMethodRepository(db)
+	paymentRepo := repository.NewPaymentRepository(db)
+	qrCodeRepo := repository.NewQRCodeRepository(db)
+
+	// Initialize product search, built per store on first query
+	productSearcher := search.NewIndex(productRepo.GetByStoreID)
+
+	// Public catalog pages are cached in memory; CATALOG_CACHE_TTL=0 turns
+	// caching off
+	catalogCacheTTL, err := time.ParseDuration(getEnv("CATALOG_CACHE_TTL", "60s"))
+	if err != nil {
+		log.Fatalf("invalid CATALOG_CACHE_TTL: %s", err.Error())
+	}
+	var catalogCache *service.CatalogCache
+	if catalogCacheTTL > 0 {
+		catalogCache = service.NewCatalogCache(cache.NewMemory(10000), catalogCacheTTL)
+	}
 
 	// Initialize middleware service
 	middSvc := middleware.NewService(jwtSvc, userRepo)
 
 	// Initialize business logic services
 	authSvc := service.NewAuthService(userRepo, jwtSvc)
-	storeSvc := service.NewStoreService(storeRepo)
-	productSvc := service.NewProductService(productRepo, storeRepo)
-	websiteSvc := service.NewWebsiteService(websiteRepo, storeRepo, productRepo)
-	orderSvc := service.NewOrderService(orderRepo, storeRepo, productRepo)
+	userSvc := service.NewUserService(userRepo, jwtSvc, mailSvc, model.DefaultRetentionPolicy)
+	storeSvc := service.NewStoreService(storeRepo, catalogCache)
+	pricer := service.NewPricer(saleRepo, categoryRepo)
+	productSvc := service.NewProductService(productRepo, productVariantRepo, categoryRepo, storeRepo, productSearcher, catalogCache)
+	websiteSvc := service.NewWebsiteService(websiteRepo, storeRepo, productRepo, categoryRepo, productSearcher, pricer, domainVerifier, themes, catalogCache, platformDomain)
+	shippingSvc := service.NewShippingService(shippingRepo, storeRepo, websiteRepo, productRepo, carrierRater)
+	orderSvc := service.NewOrderService(orderRepo, storeRepo, productRepo, voucherRepo, categoryRepo, qrCodeRepo, pricer, shippingSvc)
+	uploadSvc := service.NewUploadService(blobStore, storeRepo, catalogCache)
+	productImageSvc := service.NewProductImageService(productImageRepo, productRepo, storeRepo, uploadSvc, catalogCache)
 	todoSvc := service.NewTodoService(todoRepo)
+	categorySvc := service.NewCategoryService(categoryRepo, storeRepo, productSearcher, catalogCache)
+	saleSvc := service.NewSaleService(saleRepo, productRepo, categoryRepo, storeRepo, catalogCache)
+	voucherSvc := service.NewVoucherService(voucherRepo, productRepo, categoryRepo, storeRepo)
+	stockSvc := service.NewStockService(stockRepo, productRepo, storeRepo, userRepo, notifier, catalogCache)
+	paymentSvc := service.NewPaymentService(paymentRepo, orderRepo, storeRepo, paymentProvider)
+	qrCodeSvc := service.NewQRCodeService(qrCodeRepo, websiteRepo, storeRepo, productRepo, uploadSvc, qrSvc)
+
+	// Start the low-stock alert job
+	lowStockInterval, err := time.ParseDuration(getEnv("LOW_STOCK_CHECK_INTERVAL", "5m"))
+	if err != nil {
+		log.Fatalf("invalid LOW_STOCK_CHECK_INTERVAL: %s", err.Error())
+	}
+	go stockSvc.RunLowStockAlerts(context.Background(), lowStockInterval)
+
+	// Start the job that settles payments whose webhook never arrived
+	reconcileInterval, err := time.ParseDuration(getEnv("PAYMENT_RECONCILE_INTERVAL", "1m"))
+	if err != nil {
+		log.Fatalf("invalid PAYMENT_RECONCILE_INTERVAL: %s", err.Error())
+	}
+	go paymentSvc.RunReconciliation(context.Background(), reconcileInterval)
 
 	// Initialize HTTP handlers
 	authHandler := handler.NewAuthHandler(authSvc)
+	userHandler := handler.NewUserHandler(userSvc)
 	storeHandler := handler.NewStoreHandler(storeSvc)
 	productHandler := handler.NewProductHandler(productSvc)
-	websiteHandler := handler.NewWebsiteHandler(websiteSvc, qrSvc)
+	websiteHandler := handler.NewWebsiteHandler(websiteSvc, shippingSvc, renderer)
+	themeHandler := handler.NewThemeHandler(themes)
 	orderHandler := handler.NewOrderHandler(orderSvc)
+	uploadHandler := handler.NewUploadHandler(uploadSvc)
+	productImageHandler := handler.NewProductImageHandler(productImageSvc)
 	todoHandler := handler.NewTodoHandler(todoSvc)
+	categoryHandler := handler.NewCategoryHandler(categorySvc)
+	stockHandler := handler.NewStockHandler(stockSvc)
+	saleHandler := handler.NewSaleHandler(saleSvc)
+	voucherHandler := handler.NewVoucherHandler(voucherSvc)
+	shippingHandler := handler.NewShippingHandler(shippingSvc)
+	paymentHandler := handler.NewPaymentHandler(paymentSvc, qrSvc, mockPayments)
+	qrCodeHandler := handler.NewQRCodeHandler(qrCodeSvc)
 
 	// Setup HTTP router and routes
 	r := http.NewServeMux()
@@ -95,31 +283,120 @@ func main() {
 	// Authentication routes
 	r.Handle("POST /api/v1/auth/signup", http.HandlerFunc(authHandler.SignUp))
 	r.Handle("POST /api/v1/auth/signin", http.HandlerFunc(authHandler.SignIn))
+	r.Handle("POST /api/v1/auth/verify-email", http.HandlerFunc(userHandler.VerifyEmail))
+
+	// Account management routes (protected)
+	r.Handle("GET /api/v1/me", middSvc.JWT(http.HandlerFunc(userHandler.Get)))
+	r.Handle("PUT /api/v1/me", middSvc.JWT(http.HandlerFunc(userHandler.Update)))
+	r.Handle("PUT /api/v1/me/password", middSvc.JWT(http.HandlerFunc(userHandler.ChangePassword)))
+	r.Handle("PUT /api/v1/me/email", middSvc.JWT(http.HandlerFunc(userHandler.ChangeEmail)))
+	r.Handle("DELETE /api/v1/me", middSvc.JWT(http.HandlerFunc(userHandler.Delete)))
 
 	// Store management routes (protected)
 	r.Handle("POST /api/v1/store", middSvc.JWT(http.HandlerFunc(storeHandler.Create)))
 	r.Handle("GET /api/v1/store", middSvc.JWT(http.HandlerFunc(storeHandler.Get)))
 	r.Handle("PUT /api/v1/store", middSvc.JWT(http.HandlerFunc(storeHandler.Update)))
+	r.Handle("POST /api/v1/store/logo", middSvc.JWT(http.HandlerFunc(uploadHandler.StoreLogo)))
 
 	// Product management routes (protected)
 	r.Handle("POST /api/v1/products", middSvc.JWT(http.HandlerFunc(productHandler.Create)))
 	r.Handle("GET /api/v1/products", middSvc.JWT(http.HandlerFunc(productHandler.GetAll)))
+	r.Handle("GET /api/v1/products/archive", middSvc.JWT(http.HandlerFunc(productHandler.GetArchived)))
+	r.Handle("POST /api/v1/products/import", middSvc.JWT(http.HandlerFunc(productHandler.Import)))
+	r.Handle("GET /api/v1/products/export", middSvc.JWT(http.HandlerFunc(productHandler.Export)))
 	r.Handle("GET /api/v1/products/{id}", middSvc.JWT(http.HandlerFunc(productHandler.GetByID)))
 	r.Handle("PUT /api/v1/products/{id}", middSvc.JWT(http.HandlerFunc(productHandler.Update)))
 	r.Handle("DELETE /api/v1/products/{id}", middSvc.JWT(http.HandlerFunc(productHandler.Delete)))
+	r.Handle("POST /api/v1/products/{id}/restore", middSvc.JWT(http.HandlerFunc(productHandler.Restore)))
+	r.Handle("POST /api/v1/products/{id}/variants", middSvc.JWT(http.HandlerFunc(productHandler.CreateVariant)))
+	r.Handle("PUT /api/v1/products/{id}/variants/{variantId}", middSvc.JWT(http.HandlerFunc(productHandler.UpdateVariant)))
+	r.Handle("DELETE /api/v1/products/{id}/variants/{variantId}", middSvc.JWT(http.HandlerFunc(productHandler.DeleteVariant)))
+	r.Handle("POST /api/v1/products/{id}/images", middSvc.JWT(http.HandlerFunc(productImageHandler.Create)))
+	r.Handle("PUT /api/v1/products/{id}/images/order", middSvc.JWT(http.HandlerFunc(productImageHandler.Reorder)))
+	r.Handle("PUT /api/v1/products/{id}/images/{imageId}", middSvc.JWT(http.HandlerFunc(productImageHandler.Update)))
+	r.Handle("DELETE /api/v1/products/{id}/images/{imageId}", middSvc.JWT(http.HandlerFunc(productImageHandler.Delete)))
+
+	// Category management routes (protected)
+	r.Handle("POST /api/v1/categories", middSvc.JWT(http.HandlerFunc(categoryHandler.Create)))
+	r.Handle("GET /api/v1/categories", middSvc.JWT(http.HandlerFunc(categoryHandler.GetAll)))
+	r.Handle("PUT /api/v1/categories/{id}", middSvc.JWT(http.HandlerFunc(categoryHandler.Update)))
+	r.Handle("DELETE /api/v1/categories/{id}", middSvc.JWT(http.HandlerFunc(categoryHandler.Delete)))
+
+	// Sale routes (protected)
+	r.Handle("POST /api/v1/sales", middSvc.JWT(http.HandlerFunc(saleHandler.Create)))
+	r.Handle("GET /api/v1/sales", middSvc.JWT(http.HandlerFunc(saleHandler.GetAll)))
+	r.Handle("PUT /api/v1/sales/{id}", middSvc.JWT(http.HandlerFunc(saleHandler.Update)))
+	r.Handle("DELETE /api/v1/sales/{id}", middSvc.JWT(http.HandlerFunc(saleHandler.Delete)))
+
+	// Voucher routes (protected)
+	r.Handle("POST /api/v1/vouchers", middSvc.JWT(http.HandlerFunc(voucherHandler.Create)))
+	r.Handle("GET /api/v1/vouchers", middSvc.JWT(http.HandlerFunc(voucherHandler.GetAll)))
+	r.Handle("PUT /api/v1/vouchers/{id}", middSvc.JWT(http.HandlerFunc(voucherHandler.Update)))
+	r.Handle("DELETE /api/v1/vouchers/{id}", middSvc.JWT(http.HandlerFunc(voucherHandler.Delete)))
+
+	// Shipping method routes (protected)
+	r.Handle("POST /api/v1/shipping-methods", middSvc.JWT(http.HandlerFunc(shippingHandler.Create)))
+	r.Handle("GET /api/v1/shipping-methods", middSvc.JWT(http.HandlerFunc(shippingHandler.GetAll)))
+	r.Handle("PUT /api/v1/shipping-methods/{id}", middSvc.JWT(http.HandlerFunc(shippingHandler.Update)))
+	r.Handle("DELETE /api/v1/shipping-methods/{id}", middSvc.JWT(http.HandlerFunc(shippingHandler.Delete)))
+
+	// Website templates (public, the builder lists them before login too)
+	r.Handle("GET /api/v1/templates", http.HandlerFunc(themeHandler.GetAll))
+	r.Handle("GET /api/v1/templates/{name}/preview", http.HandlerFunc(themeHandler.Preview))
 
 	// Website builder routes (protected)
 	r.Handle("POST /api/v1/website", middSvc.JWT(http.HandlerFunc(websiteHandler.Create)))
 	r.Handle("GET /api/v1/website", middSvc.JWT(http.HandlerFunc(websiteHandler.Get)))
 	r.Handle("PUT /api/v1/website", middSvc.JWT(http.HandlerFunc(websiteHandler.Update)))
-	r.Handle("GET /api/v1/website/qr", middSvc.JWT(http.HandlerFunc(websiteHandler.GenerateQR)))
+	r.Handle("POST /api/v1/website/publish", middSvc.JWT(http.HandlerFunc(websiteHandler.Publish)))
+	r.Handle("POST /api/v1/website/unpublish", middSvc.JWT(http.HandlerFunc(websiteHandler.Unpublish)))
+	r.Handle("POST /api/v1/website/preview", middSvc.JWT(http.HandlerFunc(websiteHandler.CreatePreview)))
+	r.Handle("GET /api/v1/website/revisions", middSvc.JWT(http.HandlerFunc(websiteHandler.ListRevisions)))
+	r.Handle("GET /api/v1/website/revisions/diff", middSvc.JWT(http.HandlerFunc(websiteHandler.DiffRevisions)))
+	r.Handle("POST /api/v1/website/revisions/{version}/rollback", middSvc.JWT(http.HandlerFunc(websiteHandler.Rollback)))
+	r.Handle("GET /api/v1/website/qr", middSvc.JWT(http.HandlerFunc(qrCodeHandler.Catalog)))
+	r.Handle("GET /api/v1/website/domain-availability", middSvc.JWT(http.HandlerFunc(websiteHandler.CheckDomain)))
+	r.Handle("PUT /api/v1/website/custom-domain", middSvc.JWT(http.HandlerFunc(websiteHandler.SetCustomDomain)))
+	r.Handle("POST /api/v1/website/custom-domain/verify", middSvc.JWT(http.HandlerFunc(websiteHandler.VerifyCustomDomain)))
+
+	// QR code short links for tables, products and flyers (protected)
+	r.Handle("POST /api/v1/qr-codes", middSvc.JWT(http.HandlerFunc(qrCodeHandler.Create)))
+	r.Handle("GET /api/v1/qr-codes", middSvc.JWT(http.HandlerFunc(qrCodeHandler.GetAll)))
+	r.Handle("DELETE /api/v1/qr-codes/{id}", middSvc.JWT(http.HandlerFunc(qrCodeHandler.Delete)))
+	r.Handle("GET /api/v1/qr-codes/{id}/image", middSvc.JWT(http.HandlerFunc(qrCodeHandler.Image)))
+	r.Handle("GET /api/v1/qr-codes/{id}/stats", middSvc.JWT(http.HandlerFunc(qrCodeHandler.Stats)))
 
 	// Public catalog route (no authentication needed)
 	r.Handle("GET /catalog/{domain}", http.HandlerFunc(websiteHandler.GetCatalog))
+	r.Handle("GET /catalog/{domain}/categories/{slug}", http.HandlerFunc(websiteHandler.GetCategoryCatalog))
+	r.Handle("GET /catalog/{domain}/products/{id}", http.HandlerFunc(websiteHandler.GetCatalogProduct))
+	r.Handle("GET /catalog/{domain}/search", http.HandlerFunc(websiteHandler.SearchCatalog))
+	r.Handle("GET /catalog/{domain}/shipping", http.HandlerFunc(shippingHandler.GetPublic))
+	r.Handle("POST /catalog/{domain}/shipping/quote", http.HandlerFunc(shippingHandler.Quote))
+	r.Handle("GET /preview/{token}", http.HandlerFunc(websiteHandler.Preview))
+	r.Handle("GET /q/{code}", http.HandlerFunc(qrCodeHandler.Scan))
+
+	// Uploaded files served from the local blob store
+	r.Handle("GET /uploads/", http.StripPrefix("/uploads/", storage.FileServer(uploadDir)))
 
 	// Order management routes
 	r.Handle("POST /api/v1/orders/{storeId}", http.HandlerFunc(orderHandler.Create))   // Public - for customers
 	r.Handle("GET /api/v1/orders", middSvc.JWT(http.HandlerFunc(orderHandler.GetAll))) // Protected - for store owners
+	r.Handle("PUT /api/v1/orders/{id}/status", middSvc.JWT(http.HandlerFunc(orderHandler.UpdateStatus)))
+	r.Handle("POST /api/v1/orders/{storeId}/{id}/payment", http.HandlerFunc(paymentHandler.Create)) // Public - for customers
+	r.Handle("POST /api/v1/orders/{storeId}/{id}/qris", http.HandlerFunc(paymentHandler.QRIS))      // Public - for customers
+
+	// Payment provider callbacks
+	r.Handle("POST /api/v1/payments/webhook/{provider}", http.HandlerFunc(paymentHandler.Webhook))
+	if mockEnabled {
+		r.Handle("POST /api/v1/payments/mock/{ref}", http.HandlerFunc(paymentHandler.Simulate))
+	}
+
+	// Inventory ledger routes (protected)
+	r.Handle("POST /api/v1/products/{id}/stock", middSvc.JWT(http.HandlerFunc(stockHandler.Create)))
+	r.Handle("GET /api/v1/products/{id}/stock", middSvc.JWT(http.HandlerFunc(stockHandler.History)))
+	r.Handle("POST /api/v1/stock/reconcile", middSvc.JWT(http.HandlerFunc(stockHandler.Reconcile)))
+	r.Handle("GET /api/v1/stock/low", middSvc.JWT(http.HandlerFunc(stockHandler.LowStock)))
 
 	// Todo routes (existing functionality)
 	r.Handle("POST /api/v1/todos", middSvc.JWT(http.HandlerFunc(todoHandler.Create)))
@@ -128,13 +405,16 @@ func main() {
 	r.Handle("PUT /api/v1/todos/{id}", middSvc.JWT(http.HandlerFunc(todoHandler.Update)))
 	r.Handle("DELETE /api/v1/todos/{id}", middSvc.JWT(http.HandlerFunc(todoHandler.Delete)))
 
+	// Serve store catalogs at their own hostnames
+	hostRouter := handler.NewHostRouter(websiteSvc, r)
+
 	// Apply CORS middleware for web access
 	corsHandler := handlers.CORS(
 		handlers.AllowedOrigins([]string{"*"}), // Allow all origins in development
 		handlers.AllowedMethods([]string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}),
 		handlers.AllowedHeaders([]string{"*"}),
 		handlers.AllowCredentials(),
-	)(r)
+	)(hostRouter)
 
 	// Apply logging middleware
 	loggedHandler := handlers.LoggingHandler(os.Stdout, corsHandler)
@@ -147,31 +427,115 @@ func main() {
 	log.Println("  Auth:")
 	log.Println("    POST /api/v1/auth/signup     - Register new user")
 	log.Println("    POST /api/v1/auth/signin     - Login user")
+	log.Println("    POST /api/v1/auth/verify-email - Confirm email change")
+	log.Println("")
+	log.Println("  Account:")
+	log.Println("    GET    /api/v1/me            - Get profile")
+	log.Println("    PUT    /api/v1/me            - Update profile")
+	log.Println("    PUT    /api/v1/me/password   - Change password")
+	log.Println("    PUT    /api/v1/me/email      - Change email")
+	log.Println("    DELETE /api/v1/me            - Delete account")
 	log.Println("")
 	log.Println("  Store Management:")
 	log.Println("    POST /api/v1/store           - Create store profile")
 	log.Println("    GET  /api/v1/store           - Get store profile")
 	log.Println("    PUT  /api/v1/store           - Update store profile")
+	log.Println("    POST /api/v1/store/logo      - Upload store logo")
 	log.Println("")
 	log.Println("  Product Management:")
 	log.Println("    POST   /api/v1/products      - Add new product")
 	log.Println("    GET    /api/v1/products      - List all products")
+	log.Println("    GET    /api/v1/products/archive - List archived products")
+	log.Println("    POST   /api/v1/products/import - Import products from CSV/XLSX")
+	log.Println("    GET    /api/v1/products/export - Export products as CSV/XLSX")
 	log.Println("    GET    /api/v1/products/{id} - Get product details")
 	log.Println("    PUT    /api/v1/products/{id} - Update product")
-	log.Println("    DELETE /api/v1/products/{id} - Delete product")
+	log.Println("    DELETE /api/v1/products/{id} - Archive product")
+	log.Println("    POST   /api/v1/products/{id}/restore - Restore archived product")
+	log.Println("    POST   /api/v1/products/{id}/variants - Add variant")
+	log.Println("    PUT    /api/v1/products/{id}/variants/{variantId} - Update variant")
+	log.Println("    DELETE /api/v1/products/{id}/variants/{variantId} - Delete variant")
+	log.Println("    POST   /api/v1/products/{id}/images - Upload gallery image")
+	log.Println("    PUT    /api/v1/products/{id}/images/order - Reorder gallery")
+	log.Println("    PUT    /api/v1/products/{id}/images/{imageId} - Update gallery image")
+	log.Println("    DELETE /api/v1/products/{id}/images/{imageId} - Delete gallery image")
+	log.Println("")
+	log.Println("  Inventory:")
+	log.Println("    POST   /api/v1/products/{id}/stock - Record restock, return or adjustment")
+	log.Println("    GET    /api/v1/products/{id}/stock - Stock movement history")
+	log.Println("    POST   /api/v1/stock/reconcile - Reconcile stock with the ledger")
+	log.Println("    GET    /api/v1/stock/low    - Low-stock report")
+	log.Println("")
+	log.Println("  Categories:")
+	log.Println("    POST   /api/v1/categories    - Create category")
+	log.Println("    GET    /api/v1/categories    - List category tree")
+	log.Println("    PUT    /api/v1/categories/{id} - Update category")
+	log.Println("    DELETE /api/v1/categories/{id} - Delete category")
+	log.Println("")
+	log.Println("  Sales:")
+	log.Println("    POST   /api/v1/sales         - Schedule sale price")
+	log.Println("    GET    /api/v1/sales         - List sales")
+	log.Println("    PUT    /api/v1/sales/{id}    - Update sale")
+	log.Println("    DELETE /api/v1/sales/{id}    - Delete sale")
+	log.Println("")
+	log.Println("  Vouchers:")
+	log.Println("    POST   /api/v1/vouchers      - Create voucher code")
+	log.Println("    GET    /api/v1/vouchers      - List vouchers")
+	log.Println("    PUT    /api/v1/vouchers/{id} - Update voucher")
+	log.Println("    DELETE /api/v1/vouchers/{id} - Delete voucher")
+	log.Println("")
+	log.Println("  Shipping:")
+	log.Println("    POST   /api/v1/shipping-methods - Add delivery method")
+	log.Println("    GET    /api/v1/shipping-methods - List delivery methods")
+	log.Println("    PUT    /api/v1/shipping-methods/{id} - Update delivery method")
+	log.Println("    DELETE /api/v1/shipping-methods/{id} - Delete delivery method")
 	log.Println("")
 	log.Println("  Website Builder:")
+	log.Println("    GET  /api/v1/templates       - List website templates")
+	log.Println("    GET  /api/v1/templates/{name}/preview - Template preview image")
 	log.Println("    POST /api/v1/website         - Create website")
 	log.Println("    GET  /api/v1/website         - Get website")
-	log.Println("    PUT  /api/v1/website         - Update website")
-	log.Println("    GET  /api/v1/website/qr      - Generate QR code")
+	log.Println("    PUT  /api/v1/website         - Save website draft")
+	log.Println("    POST /api/v1/website/publish - Publish draft and make website public")
+	log.Println("    POST /api/v1/website/unpublish - Hide website")
+	log.Println("    POST /api/v1/website/preview - Create preview link")
+	log.Println("    GET  /api/v1/website/revisions - Revision history")
+	log.Println("    GET  /api/v1/website/revisions/diff?from=&to= - Compare revisions")
+	log.Println("    POST /api/v1/website/revisions/{version}/rollback - Roll back to a revision")
+	log.Println("    GET  /api/v1/website/qr      - Generate QR code (?size=&level=&fg=&bg=&logo=&format=png|svg|pdf&text=)")
+	log.Println("    GET  /api/v1/website/domain-availability?domain= - Check domain")
+	log.Println("    PUT  /api/v1/website/custom-domain - Set custom domain")
+	log.Println("    POST /api/v1/website/custom-domain/verify - Verify custom domain via DNS TXT")
+	log.Println("")
+	log.Println("  QR Codes:")
+	log.Println("    POST   /api/v1/qr-codes      - Create table, product or flyer QR code")
+	log.Println("    GET    /api/v1/qr-codes      - List QR codes with scans and orders")
+	log.Println("    DELETE /api/v1/qr-codes/{id} - Delete QR code")
+	log.Println("    GET    /api/v1/qr-codes/{id}/image - QR code image (same options as /website/qr)")
+	log.Println("    GET    /api/v1/qr-codes/{id}/stats?days= - Scans by day, source and device")
 	log.Println("")
 	log.Println("  Public Access:")
-	log.Println("    GET  /catalog/{domain}       - View public catalog")
+	log.Println("    GET  /catalog/{domain}       - View public catalog (HTML website in browsers)")
+	log.Println("    GET  /catalog/{domain}/categories/{slug} - Browse catalog by category")
+	log.Println("    GET  /catalog/{domain}/products/{id} - View one product")
+	log.Println("    GET  /catalog/{domain}/search?q= - Search catalog")
+	log.Println("    GET  /catalog/{domain}/shipping - List delivery options")
+	log.Println("    POST /catalog/{domain}/shipping/quote - Quote delivery fees")
+	log.Println("    GET  /preview/{token}        - Preview website draft")
+	log.Println("    GET  /q/{code}               - QR code short link, counts the scan")
 	log.Println("")
 	log.Println("  Order Management:")
 	log.Println("    POST /api/v1/orders/{storeId} - Create order (public)")
 	log.Println("    GET  /api/v1/orders          - View store orders")
+	log.Println("    PUT  /api/v1/orders/{id}/status - Confirm, complete or cancel order")
+	log.Println("    POST /api/v1/orders/{storeId}/{id}/payment - Pay order via QRIS/VA (public)")
+	log.Println("    POST /api/v1/orders/{storeId}/{id}/qris - QRIS code for the store's own QRIS (public)")
+	log.Println("")
+	log.Println("  Payments:")
+	log.Println("    POST /api/v1/payments/webhook/{provider} - Payment provider callback")
+	if mockEnabled {
+		log.Println("    POST /api/v1/payments/mock/{ref} - Simulate payment on the mock gateway (development only)")
+	}
 	log.Println("")
 	log.Println("  Todo (Legacy):")
 	log.Println("    POST   /api/v1/todos         - Create todo")
@@ -182,6 +546,7 @@ func main() {
 	log.Println("")
 	log.Println("🔑 Protected endpoints require 'Authorization: Bearer <token>' header")
 	log.Println("📱 QR codes link to: http://localhost:8080/catalog/{domain}")
+	log.Printf("🌐 Store sites: https://{domain}.%s and verified custom domains", platformDomain)
 	log.Println("💬 Orders automatically generate WhatsApp URLs")
 	log.Println("")
 
@@ -190,3 +555,10 @@ func main() {
 		log.Fatalf("❌ Failed to start server: %s", err.Error())
 	}
 }
+
+func getEnv(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
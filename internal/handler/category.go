@@ -0,0 +1,156 @@
+// internal/handler/category.go
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"todo-go/internal/model"
+	"todo-go/internal/service"
+	"todo-go/pkg/resp"
+
+	"github.com/go-playground/validator/v10"
+)
+
+type CategoryHandler struct {
+	categorySvc *service.CategoryService
+}
+
+func NewCategoryHandler(categorySvc *service.CategoryService) *CategoryHandler {
+	return &CategoryHandler{categorySvc: categorySvc}
+}
+
+func (h *CategoryHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req model.CreateCategoryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	err := validator.New(validator.WithRequiredStructEnabled()).Struct(&req)
+	if err != nil {
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx := r.Context()
+	user := ctx.Value("user").(*model.User)
+
+	category, err := h.categorySvc.Create(ctx, user, &req)
+	if err != nil {
+		writeCategoryError(w, "failed to create category", err)
+		return
+	}
+
+	resp.WriteJSON(w, http.StatusOK, map[string]any{
+		"message": "category successfully created",
+		"data":    category,
+	})
+}
+
+func (h *CategoryHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user := ctx.Value("user").(*model.User)
+
+	categories, err := h.categorySvc.GetTree(ctx, user)
+	if err != nil {
+		log.Printf("failed to get categories: %s", err.Error())
+		resp.WriteJSON(w, http.StatusInternalServerError, map[string]any{
+			"error": "internal server error",
+		})
+		return
+	}
+
+	resp.WriteJSON(w, http.StatusOK, map[string]any{
+		"data": categories,
+	})
+}
+
+func (h *CategoryHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": "invalid category id",
+		})
+		return
+	}
+
+	var req model.UpdateCategoryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	err = validator.New(validator.WithRequiredStructEnabled()).Struct(&req)
+	if err != nil {
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx := r.Context()
+	user := ctx.Value("user").(*model.User)
+	req.ID = int64(id)
+
+	category, err := h.categorySvc.Update(ctx, user, &req)
+	if err != nil {
+		writeCategoryError(w, "failed to update category", err)
+		return
+	}
+
+	resp.WriteJSON(w, http.StatusOK, map[string]any{
+		"message": "category successfully updated",
+		"data":    category,
+	})
+}
+
+func (h *CategoryHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": "invalid category id",
+		})
+		return
+	}
+
+	ctx := r.Context()
+	user := ctx.Value("user").(*model.User)
+
+	if err := h.categorySvc.Delete(ctx, user, int64(id)); err != nil {
+		writeCategoryError(w, "failed to delete category", err)
+		return
+	}
+
+	resp.WriteJSON(w, http.StatusOK, map[string]any{
+		"message": "category successfully deleted",
+	})
+}
+
+func writeCategoryError(w http.ResponseWriter, action string, err error) {
+	switch {
+	case errors.Is(err, service.ErrCategoryNotFound):
+		resp.WriteJSON(w, http.StatusNotFound, map[string]any{
+			"error": err.Error(),
+		})
+	case errors.Is(err, service.ErrInvalidSlug),
+		errors.Is(err, service.ErrSlugTaken),
+		errors.Is(err, service.ErrInvalidParent):
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": err.Error(),
+		})
+	default:
+		log.Printf("%s: %s", action, err.Error())
+		resp.WriteJSON(w, http.StatusInternalServerError, map[string]any{
+			"error": "internal server error",
+		})
+	}
+}
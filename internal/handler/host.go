@@ -0,0 +1,48 @@
+// internal/handler/host.go
+package handler
+
+import (
+	"log"
+	"net/http"
+	"strings"
+	"todo-go/internal/service"
+)
+
+// HostRouter serves a store's catalog at the root of its own hostname,
+// {domain}.{baseDomain} or a verified custom domain, by rewriting the
+// request to /catalog/{domain}. The API, uploads, /catalog, /preview and
+// /q paths pass through unchanged on every host, so the order form and QR
+// code short links keep working.
+type HostRouter struct {
+	websiteSvc *service.WebsiteService
+	next       http.Handler
+}
+
+func NewHostRouter(websiteSvc *service.WebsiteService, next http.Handler) *HostRouter {
+	return &HostRouter{websiteSvc: websiteSvc, next: next}
+}
+
+func (h *HostRouter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	for _, prefix := range []string{"/api/", "/uploads/", "/catalog/", "/preview/", "/q/"} {
+		if strings.HasPrefix(r.URL.Path, prefix) {
+			h.next.ServeHTTP(w, r)
+			return
+		}
+	}
+
+	site, err := h.websiteSvc.SiteForHost(r.Context(), r.Host)
+	if err != nil {
+		log.Printf("failed to resolve host %s: %s", r.Host, err.Error())
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	if site == "" {
+		h.next.ServeHTTP(w, r)
+		return
+	}
+
+	rewritten := r.Clone(r.Context())
+	rewritten.URL.Path = "/catalog/" + site + strings.TrimSuffix(r.URL.Path, "/")
+	rewritten.URL.RawPath = ""
+	h.next.ServeHTTP(w, rewritten)
+}
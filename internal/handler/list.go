@@ -0,0 +1,98 @@
+// internal/handler/list.go
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"todo-go/internal/model"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// parseListOptions reads the shared list query parameters:
+// page, cursor, limit, sort, category_id (repeatable or comma separated)
+// and active.
+func parseListOptions(r *http.Request) (*model.ListOptions, error) {
+	query := r.URL.Query()
+	opts := &model.ListOptions{
+		Cursor: query.Get("cursor"),
+		Sort:   query.Get("sort"),
+	}
+
+	var err error
+	if v := query.Get("page"); v != "" {
+		if opts.Page, err = strconv.Atoi(v); err != nil {
+			return nil, fmt.Errorf("invalid page")
+		}
+	}
+	if v := query.Get("limit"); v != "" {
+		if opts.Limit, err = strconv.Atoi(v); err != nil {
+			return nil, fmt.Errorf("invalid limit")
+		}
+	}
+
+	for _, v := range query["category_id"] {
+		for _, part := range strings.Split(v, ",") {
+			id, err := strconv.ParseInt(strings.TrimSpace(part), 10, 64)
+			if err != nil || id <= 0 {
+				return nil, fmt.Errorf("invalid category_id")
+			}
+			opts.CategoryIDs = append(opts.CategoryIDs, id)
+		}
+	}
+
+	if v := query.Get("active"); v != "" {
+		active, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid active")
+		}
+		opts.Active = &active
+	}
+
+	if err := validator.New(validator.WithRequiredStructEnabled()).Struct(opts); err != nil {
+		return nil, err
+	}
+
+	return opts, nil
+}
+
+// setLinkHeader advertises neighbouring pages in an RFC 8288 Link header.
+// Page mode gets first/prev/next/last, cursor mode only next.
+func setLinkHeader(w http.ResponseWriter, r *http.Request, info *model.PageInfo) {
+	link := func(rel string, set func(q url.Values)) string {
+		u := *r.URL
+		q := u.Query()
+		q.Del("page")
+		q.Del("cursor")
+		set(q)
+		u.RawQuery = q.Encode()
+		return fmt.Sprintf("<%s>; rel=%q", u.RequestURI(), rel)
+	}
+	page := func(n int) func(q url.Values) {
+		return func(q url.Values) { q.Set("page", strconv.Itoa(n)) }
+	}
+
+	var links []string
+	if info.Page == 0 {
+		if info.HasMore {
+			links = append(links, link("next", func(q url.Values) { q.Set("cursor", info.NextCursor) }))
+		}
+	} else {
+		last := max(info.TotalPages, 1)
+		links = append(links, link("first", page(1)))
+		if info.Page > 1 {
+			links = append(links, link("prev", page(min(info.Page-1, last))))
+		}
+		if info.HasMore {
+			links = append(links, link("next", page(info.Page+1)))
+		}
+		links = append(links, link("last", page(last)))
+	}
+
+	if len(links) > 0 {
+		w.Header().Set("Link", strings.Join(links, ", "))
+	}
+}
@@ -2,10 +2,16 @@
 package handler
 
 import (
+	"cmp"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
+	"mime"
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
 	"todo-go/internal/model"
 	"todo-go/internal/service"
 	"todo-go/pkg/resp"
@@ -30,8 +36,15 @@ func (h *OrderHandler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// The storefront's order form posts here too
 	var req model.CreateOrderRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	form := isFormPost(r)
+	if form {
+		err = decodeOrderForm(r, &req)
+	} else {
+		err = json.NewDecoder(r.Body).Decode(&req)
+	}
+	if err != nil {
 		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
 			"error": err.Error(),
 		})
@@ -49,10 +62,29 @@ func (h *OrderHandler) Create(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	order, whatsappURL, err := h.orderSvc.Create(ctx, int64(storeID), &req)
 	if err != nil {
-		log.Printf("failed to create order: %s", err.Error())
-		resp.WriteJSON(w, http.StatusInternalServerError, map[string]any{
-			"error": "internal server error",
-		})
+		switch {
+		case errors.Is(err, service.ErrStoreNotFound):
+			resp.WriteJSON(w, http.StatusNotFound, map[string]any{
+				"error": err.Error(),
+			})
+			return
+		case errors.Is(err, service.ErrInvalidOrderItem), errors.Is(err, service.ErrVoucherNotApplicable),
+			errors.Is(err, service.ErrInvalidShipping):
+			resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+				"error": err.Error(),
+			})
+			return
+		default:
+			log.Printf("failed to create order: %s", err.Error())
+			resp.WriteJSON(w, http.StatusInternalServerError, map[string]any{
+				"error": "internal server error",
+			})
+			return
+		}
+	}
+
+	if form {
+		http.Redirect(w, r, whatsappURL, http.StatusSeeOther)
 		return
 	}
 
@@ -68,17 +100,160 @@ func (h *OrderHandler) GetAll(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	user := ctx.Value("user").(*model.User)
 
-	orders, err := h.orderSvc.GetByStore(ctx, user)
+	opts, err := parseListOptions(r)
+	if err != nil {
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	orders, pageInfo, err := h.orderSvc.GetByStore(ctx, user, opts)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidListOptions):
+			resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+				"error": err.Error(),
+			})
+			return
+		default:
+			log.Printf("failed to get orders: %s", err.Error())
+			resp.WriteJSON(w, http.StatusInternalServerError, map[string]any{
+				"error": "internal server error",
+			})
+			return
+		}
+	}
+
+	setLinkHeader(w, r, pageInfo)
+	resp.WriteJSON(w, http.StatusOK, map[string]any{
+		"data":       orders,
+		"count":      len(orders),
+		"pagination": pageInfo,
+	})
+}
+
+func (h *OrderHandler) UpdateStatus(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": "invalid order id",
+		})
+		return
+	}
+
+	var req model.UpdateOrderStatusRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": err.Error(),
+		})
+		return
+	}
+	req.ID = int64(id)
+
+	err = validator.New(validator.WithRequiredStructEnabled()).Struct(&req)
 	if err != nil {
-		log.Printf("failed to get orders: %s", err.Error())
-		resp.WriteJSON(w, http.StatusInternalServerError, map[string]any{
-			"error": "internal server error",
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": err.Error(),
 		})
 		return
 	}
 
+	ctx := r.Context()
+	user := ctx.Value("user").(*model.User)
+
+	order, err := h.orderSvc.UpdateStatus(ctx, user, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrOrderNotFound):
+			resp.WriteJSON(w, http.StatusNotFound, map[string]any{
+				"error": err.Error(),
+			})
+			return
+		case errors.Is(err, service.ErrInvalidOrderStatus):
+			resp.WriteJSON(w, http.StatusConflict, map[string]any{
+				"error": err.Error(),
+			})
+			return
+		default:
+			log.Printf("failed to update order status: %s", err.Error())
+			resp.WriteJSON(w, http.StatusInternalServerError, map[string]any{
+				"error": "internal server error",
+			})
+			return
+		}
+	}
+
 	resp.WriteJSON(w, http.StatusOK, map[string]any{
-		"data":  orders,
-		"count": len(orders),
+		"message": "order status successfully updated",
+		"data":    order,
 	})
 }
+
+func isFormPost(r *http.Request) bool {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return mediaType == "application/x-www-form-urlencoded"
+}
+
+// decodeOrderForm reads an order from the storefront form. Quantities come
+// in fields named qty.{productId} or qty.{productId}.{variantId}; products
+// left at zero are not ordered.
+func decodeOrderForm(r *http.Request, req *model.CreateOrderRequest) error {
+	if err := r.ParseForm(); err != nil {
+		return err
+	}
+
+	req.CustomerName = r.PostForm.Get("customer_name")
+	req.CustomerPhone = r.PostForm.Get("customer_phone")
+	req.Notes = r.PostForm.Get("notes")
+	req.VoucherCode = r.PostForm.Get("voucher_code")
+	req.TableNumber = r.PostForm.Get("table_number")
+	req.QRCode = r.PostForm.Get("qr_code")
+
+	if id := r.PostForm.Get("shipping_method_id"); id != "" {
+		methodID, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			return errors.New("invalid shipping_method_id")
+		}
+		req.ShippingMethodID = methodID
+	}
+	if address := r.PostForm.Get("address"); address != "" {
+		req.ShippingAddress = &model.ShippingAddress{
+			Address:    address,
+			PostalCode: r.PostForm.Get("postal_code"),
+		}
+	}
+
+	for key, values := range r.PostForm {
+		ids, ok := strings.CutPrefix(key, "qty.")
+		if !ok || len(values) == 0 || values[0] == "" {
+			continue
+		}
+		quantity, err := strconv.Atoi(values[0])
+		if err != nil || quantity < 0 {
+			return fmt.Errorf("invalid quantity for %s", key)
+		}
+		if quantity == 0 {
+			continue
+		}
+
+		var item model.OrderItem
+		productID, variantID, hasVariant := strings.Cut(ids, ".")
+		if item.ProductID, err = strconv.ParseInt(productID, 10, 64); err != nil {
+			return fmt.Errorf("invalid product in %s", key)
+		}
+		if hasVariant {
+			if item.VariantID, err = strconv.ParseInt(variantID, 10, 64); err != nil {
+				return fmt.Errorf("invalid variant in %s", key)
+			}
+		}
+		item.Quantity = quantity
+		req.Items = append(req.Items, item)
+	}
+	// Map order is random, keep the order stable for the message
+	slices.SortFunc(req.Items, func(a, b model.OrderItem) int {
+		return cmp.Or(cmp.Compare(a.ProductID, b.ProductID), cmp.Compare(a.VariantID, b.VariantID))
+	})
+
+	return nil
+}
@@ -0,0 +1,233 @@
+// internal/handler/payment.go
+package handler
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"todo-go/internal/model"
+	"todo-go/internal/service"
+	"todo-go/pkg/payment"
+	"todo-go/pkg/qr"
+	"todo-go/pkg/resp"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// maxWebhookBody caps the size of a provider callback
+const maxWebhookBody = 64 << 10
+
+type PaymentHandler struct {
+	paymentSvc *service.PaymentService
+	qrSvc      *qr.Service
+	mock       *payment.MockProvider // nil unless the mock gateway is in use
+}
+
+func NewPaymentHandler(paymentSvc *service.PaymentService, qrSvc *qr.Service, mock *payment.MockProvider) *PaymentHandler {
+	return &PaymentHandler{paymentSvc: paymentSvc, qrSvc: qrSvc, mock: mock}
+}
+
+func (h *PaymentHandler) Create(w http.ResponseWriter, r *http.Request) {
+	storeID, err := strconv.Atoi(r.PathValue("storeId"))
+	if err != nil {
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": "invalid store id",
+		})
+		return
+	}
+
+	orderID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": "invalid order id",
+		})
+		return
+	}
+
+	var req model.CreatePaymentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	err = validator.New(validator.WithRequiredStructEnabled()).Struct(&req)
+	if err != nil {
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx := r.Context()
+	p, err := h.paymentSvc.CreateIntent(ctx, int64(storeID), int64(orderID), &req)
+	if err != nil {
+		writePaymentError(w, "failed to create payment", err)
+		return
+	}
+
+	resp.WriteJSON(w, http.StatusOK, map[string]any{
+		"message": "payment successfully created",
+		"data":    p,
+	})
+}
+
+// QRIS returns a QRIS code for the order total, as JSON with both the QRIS
+// string and the PNG, or with ?format=png as the bare image.
+func (h *PaymentHandler) QRIS(w http.ResponseWriter, r *http.Request) {
+	storeID, err := strconv.Atoi(r.PathValue("storeId"))
+	if err != nil {
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": "invalid store id",
+		})
+		return
+	}
+
+	orderID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": "invalid order id",
+		})
+		return
+	}
+
+	var req model.OrderQRISRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	err = validator.New(validator.WithRequiredStructEnabled()).Struct(&req)
+	if err != nil {
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx := r.Context()
+	code, err := h.paymentSvc.OrderQRIS(ctx, int64(storeID), int64(orderID), &req)
+	if err != nil {
+		writePaymentError(w, "failed to create order QRIS", err)
+		return
+	}
+
+	png, err := h.qrSvc.GenerateQR(code.QRString)
+	if err != nil {
+		log.Printf("failed to generate QR code: %s", err.Error())
+		resp.WriteJSON(w, http.StatusInternalServerError, map[string]any{
+			"error": "failed to generate QR code",
+		})
+		return
+	}
+
+	if r.URL.Query().Get("format") == "png" {
+		w.Header().Set("Content-Type", "image/png")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=\"qris-order-%d.png\"", code.OrderID))
+		w.WriteHeader(http.StatusOK)
+		w.Write(png)
+		return
+	}
+
+	code.QRImage = "data:image/png;base64," + base64.StdEncoding.EncodeToString(png)
+	resp.WriteJSON(w, http.StatusOK, map[string]any{
+		"data": code,
+	})
+}
+
+// Webhook receives status callbacks from the payment provider.
+func (h *PaymentHandler) Webhook(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBody))
+	if err != nil {
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	p, err := h.paymentSvc.HandleWebhook(r.Context(), r.PathValue("provider"), r.Header, body)
+	if err != nil {
+		writePaymentError(w, "failed to handle payment webhook", err)
+		return
+	}
+
+	resp.WriteJSON(w, http.StatusOK, map[string]any{
+		"message": "webhook received",
+		"data":    p,
+	})
+}
+
+// Simulate settles a payment on the mock gateway, which then calls the
+// webhook handling as a real gateway would. Only routed when
+// PAYMENT_MOCK_ENABLED is set.
+func (h *PaymentHandler) Simulate(w http.ResponseWriter, r *http.Request) {
+	var req model.SimulatePaymentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	err := validator.New(validator.WithRequiredStructEnabled()).Struct(&req)
+	if err != nil {
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	header, body, err := h.mock.Simulate(r.PathValue("ref"), payment.Status(req.Status))
+	if err != nil {
+		resp.WriteJSON(w, http.StatusNotFound, map[string]any{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	p, err := h.paymentSvc.HandleWebhook(r.Context(), h.mock.Name(), header, body)
+	if err != nil {
+		writePaymentError(w, "failed to handle payment webhook", err)
+		return
+	}
+
+	resp.WriteJSON(w, http.StatusOK, map[string]any{
+		"message": "payment simulated",
+		"data":    p,
+	})
+}
+
+func writePaymentError(w http.ResponseWriter, action string, err error) {
+	switch {
+	case errors.Is(err, service.ErrOrderNotFound), errors.Is(err, service.ErrPaymentNotFound):
+		resp.WriteJSON(w, http.StatusNotFound, map[string]any{
+			"error": err.Error(),
+		})
+	case errors.Is(err, service.ErrOrderAlreadyPaid), errors.Is(err, service.ErrOrderNotPayable),
+		errors.Is(err, service.ErrQRISNotAccepted), errors.Is(err, service.ErrNothingToPay):
+		resp.WriteJSON(w, http.StatusConflict, map[string]any{
+			"error": err.Error(),
+		})
+	case errors.Is(err, payment.ErrInvalidSignature):
+		resp.WriteJSON(w, http.StatusUnauthorized, map[string]any{
+			"error": err.Error(),
+		})
+	case errors.Is(err, service.ErrInvalidPaymentEvent):
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": err.Error(),
+		})
+	default:
+		log.Printf("%s: %s", action, err.Error())
+		resp.WriteJSON(w, http.StatusInternalServerError, map[string]any{
+			"error": "internal server error",
+		})
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"todo-go/internal/model"
 	"todo-go/internal/service"
 	"todo-go/pkg/resp"
+	"todo-go/pkg/spreadsheet"
 
 	"github.com/go-playground/validator/v10"
 )
@@ -44,11 +45,21 @@ func (h *ProductHandler) Create(w http.ResponseWriter, r *http.Request) {
 
 	product, err := h.productSvc.Create(ctx, user, &req)
 	if err != nil {
-		log.Printf("failed to create product: %s", err.Error())
-		resp.WriteJSON(w, http.StatusInternalServerError, map[string]any{
-			"error": "internal server error",
-		})
-		return
+		switch {
+		case errors.Is(err, service.ErrInvalidOptions),
+			errors.Is(err, service.ErrCategoryNotFound),
+			errors.Is(err, service.ErrDuplicateSKU):
+			resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+				"error": err.Error(),
+			})
+			return
+		default:
+			log.Printf("failed to create product: %s", err.Error())
+			resp.WriteJSON(w, http.StatusInternalServerError, map[string]any{
+				"error": "internal server error",
+			})
+			return
+		}
 	}
 
 	resp.WriteJSON(w, http.StatusOK, map[string]any{
@@ -61,18 +72,36 @@ func (h *ProductHandler) GetAll(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	user := ctx.Value("user").(*model.User)
 
-	products, err := h.productSvc.GetByStore(ctx, user)
+	opts, err := parseListOptions(r)
 	if err != nil {
-		log.Printf("failed to get products: %s", err.Error())
-		resp.WriteJSON(w, http.StatusInternalServerError, map[string]any{
-			"error": "internal server error",
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": err.Error(),
 		})
 		return
 	}
 
+	products, pageInfo, err := h.productSvc.GetByStore(ctx, user, opts)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidListOptions):
+			resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+				"error": err.Error(),
+			})
+			return
+		default:
+			log.Printf("failed to get products: %s", err.Error())
+			resp.WriteJSON(w, http.StatusInternalServerError, map[string]any{
+				"error": "internal server error",
+			})
+			return
+		}
+	}
+
+	setLinkHeader(w, r, pageInfo)
 	resp.WriteJSON(w, http.StatusOK, map[string]any{
-		"data":  products,
-		"count": len(products),
+		"data":       products,
+		"count":      len(products),
+		"pagination": pageInfo,
 	})
 }
 
@@ -147,6 +176,15 @@ func (h *ProductHandler) Update(w http.ResponseWriter, r *http.Request) {
 				"error": err.Error(),
 			})
 			return
+		case errors.Is(err, service.ErrInvalidOptions),
+			errors.Is(err, service.ErrOptionsInUse),
+			errors.Is(err, service.ErrCategoryNotFound),
+			errors.Is(err, service.ErrDuplicateSKU),
+			errors.Is(err, service.ErrInsufficientStock):
+			resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+				"error": err.Error(),
+			})
+			return
 		default:
 			log.Printf("failed to update product: %s", err.Error())
 			resp.WriteJSON(w, http.StatusInternalServerError, map[string]any{
@@ -192,6 +230,321 @@ func (h *ProductHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	}
 
 	resp.WriteJSON(w, http.StatusOK, map[string]any{
-		"message": "product successfully deleted",
+		"message": "product successfully archived",
+	})
+}
+
+func (h *ProductHandler) GetArchived(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user := ctx.Value("user").(*model.User)
+
+	opts, err := parseListOptions(r)
+	if err != nil {
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	products, pageInfo, err := h.productSvc.GetArchived(ctx, user, opts)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidListOptions):
+			resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+				"error": err.Error(),
+			})
+			return
+		default:
+			log.Printf("failed to get archived products: %s", err.Error())
+			resp.WriteJSON(w, http.StatusInternalServerError, map[string]any{
+				"error": "internal server error",
+			})
+			return
+		}
+	}
+
+	setLinkHeader(w, r, pageInfo)
+	resp.WriteJSON(w, http.StatusOK, map[string]any{
+		"data":       products,
+		"count":      len(products),
+		"pagination": pageInfo,
+	})
+}
+
+func (h *ProductHandler) Restore(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": "invalid product id",
+		})
+		return
+	}
+
+	ctx := r.Context()
+	user := ctx.Value("user").(*model.User)
+
+	product, err := h.productSvc.Restore(ctx, user, int64(id))
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrProductNotFound):
+			resp.WriteJSON(w, http.StatusNotFound, map[string]any{
+				"error": err.Error(),
+			})
+			return
+		case errors.Is(err, service.ErrDuplicateSKU):
+			resp.WriteJSON(w, http.StatusConflict, map[string]any{
+				"error": err.Error(),
+			})
+			return
+		default:
+			log.Printf("failed to restore product: %s", err.Error())
+			resp.WriteJSON(w, http.StatusInternalServerError, map[string]any{
+				"error": "internal server error",
+			})
+			return
+		}
+	}
+
+	resp.WriteJSON(w, http.StatusOK, map[string]any{
+		"message": "product successfully restored",
+		"data":    product,
 	})
 }
+
+func (h *ProductHandler) Import(w http.ResponseWriter, r *http.Request) {
+	dryRun := false
+	if v := r.URL.Query().Get("dry_run"); v != "" {
+		var err error
+		if dryRun, err = strconv.ParseBool(v); err != nil {
+			resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+				"error": "invalid dry_run",
+			})
+			return
+		}
+	}
+
+	data, ok := readUpload(w, r, "file")
+	if !ok {
+		return
+	}
+
+	ctx := r.Context()
+	user := ctx.Value("user").(*model.User)
+
+	result, err := h.productSvc.Import(ctx, user, data, dryRun)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidImportFile):
+			resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+				"error": err.Error(),
+			})
+			return
+		case errors.Is(err, service.ErrDuplicateSKU):
+			resp.WriteJSON(w, http.StatusConflict, map[string]any{
+				"error": err.Error(),
+			})
+			return
+		default:
+			log.Printf("failed to import products: %s", err.Error())
+			resp.WriteJSON(w, http.StatusInternalServerError, map[string]any{
+				"error": "internal server error",
+			})
+			return
+		}
+	}
+
+	switch {
+	case len(result.Errors) > 0:
+		resp.WriteJSON(w, http.StatusUnprocessableEntity, map[string]any{
+			"error": "import has invalid rows, nothing was saved",
+			"data":  result,
+		})
+	case dryRun:
+		resp.WriteJSON(w, http.StatusOK, map[string]any{
+			"message": "dry run passed, nothing was saved",
+			"data":    result,
+		})
+	default:
+		resp.WriteJSON(w, http.StatusOK, map[string]any{
+			"message": "products successfully imported",
+			"data":    result,
+		})
+	}
+}
+
+func (h *ProductHandler) Export(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user := ctx.Value("user").(*model.User)
+
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "csv" && format != "xlsx" {
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": "format must be csv or xlsx",
+		})
+		return
+	}
+
+	started := false
+	err := h.productSvc.Export(ctx, user, func() (spreadsheet.Writer, error) {
+		started = true
+		if format == "xlsx" {
+			w.Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+			w.Header().Set("Content-Disposition", `attachment; filename="products.xlsx"`)
+			return spreadsheet.NewXLSXWriter(w, "Products")
+		}
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="products.csv"`)
+		return spreadsheet.NewCSVWriter(w), nil
+	})
+	if err != nil {
+		// The body is streamed, so once rows are out an error can only be logged
+		log.Printf("failed to export products: %s", err.Error())
+		if !started {
+			resp.WriteJSON(w, http.StatusInternalServerError, map[string]any{
+				"error": "internal server error",
+			})
+		}
+	}
+}
+
+func (h *ProductHandler) CreateVariant(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": "invalid product id",
+		})
+		return
+	}
+
+	var req model.CreateProductVariantRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	err = validator.New(validator.WithRequiredStructEnabled()).Struct(&req)
+	if err != nil {
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx := r.Context()
+	user := ctx.Value("user").(*model.User)
+	req.ProductID = int64(id)
+
+	variant, err := h.productSvc.CreateVariant(ctx, user, &req)
+	if err != nil {
+		writeVariantError(w, "failed to create variant", err)
+		return
+	}
+
+	resp.WriteJSON(w, http.StatusOK, map[string]any{
+		"message": "variant successfully created",
+		"data":    variant,
+	})
+}
+
+func (h *ProductHandler) UpdateVariant(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": "invalid product id",
+		})
+		return
+	}
+
+	variantID, err := strconv.Atoi(r.PathValue("variantId"))
+	if err != nil {
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": "invalid variant id",
+		})
+		return
+	}
+
+	var req model.UpdateProductVariantRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	err = validator.New(validator.WithRequiredStructEnabled()).Struct(&req)
+	if err != nil {
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx := r.Context()
+	user := ctx.Value("user").(*model.User)
+	req.ProductID = int64(id)
+	req.ID = int64(variantID)
+
+	variant, err := h.productSvc.UpdateVariant(ctx, user, &req)
+	if err != nil {
+		writeVariantError(w, "failed to update variant", err)
+		return
+	}
+
+	resp.WriteJSON(w, http.StatusOK, map[string]any{
+		"message": "variant successfully updated",
+		"data":    variant,
+	})
+}
+
+func (h *ProductHandler) DeleteVariant(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": "invalid product id",
+		})
+		return
+	}
+
+	variantID, err := strconv.Atoi(r.PathValue("variantId"))
+	if err != nil {
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": "invalid variant id",
+		})
+		return
+	}
+
+	ctx := r.Context()
+	user := ctx.Value("user").(*model.User)
+
+	if err := h.productSvc.DeleteVariant(ctx, user, int64(id), int64(variantID)); err != nil {
+		writeVariantError(w, "failed to delete variant", err)
+		return
+	}
+
+	resp.WriteJSON(w, http.StatusOK, map[string]any{
+		"message": "variant successfully deleted",
+	})
+}
+
+func writeVariantError(w http.ResponseWriter, action string, err error) {
+	switch {
+	case errors.Is(err, service.ErrProductNotFound), errors.Is(err, service.ErrVariantNotFound):
+		resp.WriteJSON(w, http.StatusNotFound, map[string]any{
+			"error": err.Error(),
+		})
+	case errors.Is(err, service.ErrInvalidVariantOption),
+		errors.Is(err, service.ErrDuplicateVariant),
+		errors.Is(err, service.ErrDuplicateSKU),
+		errors.Is(err, service.ErrInsufficientStock):
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": err.Error(),
+		})
+	default:
+		log.Printf("%s: %s", action, err.Error())
+		resp.WriteJSON(w, http.StatusInternalServerError, map[string]any{
+			"error": "internal server error",
+		})
+	}
+}
@@ -0,0 +1,205 @@
+// internal/handler/product_image.go
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"todo-go/internal/model"
+	"todo-go/internal/service"
+	"todo-go/pkg/resp"
+
+	"github.com/go-playground/validator/v10"
+)
+
+type ProductImageHandler struct {
+	imageSvc *service.ProductImageService
+}
+
+func NewProductImageHandler(imageSvc *service.ProductImageService) *ProductImageHandler {
+	return &ProductImageHandler{imageSvc: imageSvc}
+}
+
+func (h *ProductImageHandler) Create(w http.ResponseWriter, r *http.Request) {
+	productID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": "invalid product id",
+		})
+		return
+	}
+
+	data, ok := readUpload(w, r, "image")
+	if !ok {
+		return
+	}
+
+	altText := r.FormValue("alt_text")
+	if len(altText) > 255 {
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": "alt_text must be at most 255 characters",
+		})
+		return
+	}
+	primary, _ := strconv.ParseBool(r.FormValue("is_primary"))
+
+	ctx := r.Context()
+	user := ctx.Value("user").(*model.User)
+
+	product, err := h.imageSvc.Add(ctx, user, int64(productID), data, altText, primary)
+	if err != nil {
+		h.writeError(w, "failed to add product image", err)
+		return
+	}
+
+	resp.WriteJSON(w, http.StatusOK, map[string]any{
+		"message": "product image successfully uploaded",
+		"data":    product,
+	})
+}
+
+func (h *ProductImageHandler) Update(w http.ResponseWriter, r *http.Request) {
+	productID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": "invalid product id",
+		})
+		return
+	}
+
+	imageID, err := strconv.Atoi(r.PathValue("imageId"))
+	if err != nil {
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": "invalid image id",
+		})
+		return
+	}
+
+	var req model.UpdateProductImageRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	err = validator.New(validator.WithRequiredStructEnabled()).Struct(&req)
+	if err != nil {
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx := r.Context()
+	user := ctx.Value("user").(*model.User)
+	req.ProductID = int64(productID)
+	req.ID = int64(imageID)
+
+	product, err := h.imageSvc.Update(ctx, user, &req)
+	if err != nil {
+		h.writeError(w, "failed to update product image", err)
+		return
+	}
+
+	resp.WriteJSON(w, http.StatusOK, map[string]any{
+		"message": "product image successfully updated",
+		"data":    product,
+	})
+}
+
+func (h *ProductImageHandler) Reorder(w http.ResponseWriter, r *http.Request) {
+	productID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": "invalid product id",
+		})
+		return
+	}
+
+	var req model.ReorderProductImagesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	err = validator.New(validator.WithRequiredStructEnabled()).Struct(&req)
+	if err != nil {
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx := r.Context()
+	user := ctx.Value("user").(*model.User)
+	req.ProductID = int64(productID)
+
+	product, err := h.imageSvc.Reorder(ctx, user, &req)
+	if err != nil {
+		h.writeError(w, "failed to reorder product images", err)
+		return
+	}
+
+	resp.WriteJSON(w, http.StatusOK, map[string]any{
+		"message": "product images successfully reordered",
+		"data":    product,
+	})
+}
+
+func (h *ProductImageHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	productID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": "invalid product id",
+		})
+		return
+	}
+
+	imageID, err := strconv.Atoi(r.PathValue("imageId"))
+	if err != nil {
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": "invalid image id",
+		})
+		return
+	}
+
+	ctx := r.Context()
+	user := ctx.Value("user").(*model.User)
+
+	product, err := h.imageSvc.Delete(ctx, user, int64(productID), int64(imageID))
+	if err != nil {
+		h.writeError(w, "failed to delete product image", err)
+		return
+	}
+
+	resp.WriteJSON(w, http.StatusOK, map[string]any{
+		"message": "product image successfully deleted",
+		"data":    product,
+	})
+}
+
+func (h *ProductImageHandler) writeError(w http.ResponseWriter, action string, err error) {
+	switch {
+	case errors.Is(err, service.ErrProductNotFound), errors.Is(err, service.ErrProductImageNotFound):
+		resp.WriteJSON(w, http.StatusNotFound, map[string]any{
+			"error": err.Error(),
+		})
+	case errors.Is(err, service.ErrUnsupportedImage),
+		errors.Is(err, service.ErrImageTooLarge),
+		errors.Is(err, service.ErrTooManyProductImages),
+		errors.Is(err, service.ErrInvalidImageOrder):
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": err.Error(),
+		})
+	default:
+		log.Printf("%s: %s", action, err.Error())
+		resp.WriteJSON(w, http.StatusInternalServerError, map[string]any{
+			"error": "internal server error",
+		})
+	}
+}
@@ -0,0 +1,303 @@
+// internal/handler/qrcode.go
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"todo-go/internal/model"
+	"todo-go/internal/service"
+	"todo-go/pkg/resp"
+
+	"github.com/go-playground/validator/v10"
+)
+
+type QRCodeHandler struct {
+	qrCodeSvc *service.QRCodeService
+}
+
+func NewQRCodeHandler(qrCodeSvc *service.QRCodeService) *QRCodeHandler {
+	return &QRCodeHandler{qrCodeSvc: qrCodeSvc}
+}
+
+// Catalog renders a QR code linking to the store's catalog.
+func (h *QRCodeHandler) Catalog(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user := ctx.Value("user").(*model.User)
+
+	opts, err := parseQRCodeOptions(r)
+	if err != nil {
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	file, err := h.qrCodeSvc.Catalog(ctx, user, baseURL(r), opts)
+	if err != nil {
+		writeQRCodeError(w, "failed to generate QR code", err)
+		return
+	}
+
+	writeQRCode(w, file)
+}
+
+func (h *QRCodeHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req model.CreateQRCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	err := validator.New(validator.WithRequiredStructEnabled()).Struct(&req)
+	if err != nil {
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx := r.Context()
+	user := ctx.Value("user").(*model.User)
+
+	qrCode, err := h.qrCodeSvc.Create(ctx, user, &req)
+	if err != nil {
+		writeQRCodeError(w, "failed to create QR code", err)
+		return
+	}
+	qrCode.URL = shortURL(r, qrCode)
+
+	resp.WriteJSON(w, http.StatusOK, map[string]any{
+		"message": "QR code successfully created",
+		"data":    qrCode,
+	})
+}
+
+func (h *QRCodeHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	opts, err := parseListOptions(r)
+	if err != nil {
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx := r.Context()
+	user := ctx.Value("user").(*model.User)
+
+	qrCodes, pageInfo, err := h.qrCodeSvc.List(ctx, user, opts)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidListOptions) {
+			resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+				"error": err.Error(),
+			})
+			return
+		}
+		writeQRCodeError(w, "failed to get QR codes", err)
+		return
+	}
+	for _, qrCode := range qrCodes {
+		qrCode.URL = shortURL(r, qrCode)
+	}
+
+	setLinkHeader(w, r, pageInfo)
+	resp.WriteJSON(w, http.StatusOK, map[string]any{
+		"data":       qrCodes,
+		"count":      len(qrCodes),
+		"pagination": pageInfo,
+	})
+}
+
+func (h *QRCodeHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": "invalid QR code id",
+		})
+		return
+	}
+
+	ctx := r.Context()
+	user := ctx.Value("user").(*model.User)
+
+	if err := h.qrCodeSvc.Delete(ctx, user, id); err != nil {
+		writeQRCodeError(w, "failed to delete QR code", err)
+		return
+	}
+
+	resp.WriteJSON(w, http.StatusOK, map[string]any{
+		"message": "QR code successfully deleted",
+	})
+}
+
+// Image renders a QR code for the short link, with the same options as the
+// catalog QR code.
+func (h *QRCodeHandler) Image(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": "invalid QR code id",
+		})
+		return
+	}
+
+	opts, err := parseQRCodeOptions(r)
+	if err != nil {
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx := r.Context()
+	user := ctx.Value("user").(*model.User)
+
+	file, err := h.qrCodeSvc.Image(ctx, user, id, baseURL(r), opts)
+	if err != nil {
+		writeQRCodeError(w, "failed to generate QR code", err)
+		return
+	}
+
+	writeQRCode(w, file)
+}
+
+// Stats reports scans and orders of a QR code over the last ?days=
+// (1 to 365, default 30) days.
+func (h *QRCodeHandler) Stats(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": "invalid QR code id",
+		})
+		return
+	}
+
+	days := service.DefaultQRStatsDays
+	if v := r.URL.Query().Get("days"); v != "" {
+		if days, err = strconv.Atoi(v); err != nil || days < 1 || days > 365 {
+			resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+				"error": "days must be between 1 and 365",
+			})
+			return
+		}
+	}
+
+	ctx := r.Context()
+	user := ctx.Value("user").(*model.User)
+
+	stats, err := h.qrCodeSvc.Stats(ctx, user, id, days)
+	if err != nil {
+		writeQRCodeError(w, "failed to get QR code stats", err)
+		return
+	}
+	stats.QRCode.URL = shortURL(r, stats.QRCode)
+
+	resp.WriteJSON(w, http.StatusOK, map[string]any{
+		"data": stats,
+	})
+}
+
+// Scan is the public short link printed in QR codes. It counts the scan
+// and redirects to the catalog; redirects are never cached so every scan
+// reaches us.
+func (h *QRCodeHandler) Scan(w http.ResponseWriter, r *http.Request) {
+	target, err := h.qrCodeSvc.Scan(r.Context(), r.PathValue("code"), r.URL.Query().Get("src"), r.UserAgent())
+	if err != nil {
+		if errors.Is(err, service.ErrQRCodeNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		log.Printf("failed to scan QR code: %s", err.Error())
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Robots-Tag", "noindex")
+	http.Redirect(w, r, target, http.StatusFound)
+}
+
+// parseQRCodeOptions reads size, level, fg, bg, format, logo and text.
+func parseQRCodeOptions(r *http.Request) (*model.QRCodeOptions, error) {
+	query := r.URL.Query()
+	opts := &model.QRCodeOptions{
+		Level:      strings.ToUpper(query.Get("level")),
+		Foreground: query.Get("fg"),
+		Background: query.Get("bg"),
+		Format:     strings.ToLower(query.Get("format")),
+		Text:       query.Get("text"),
+	}
+
+	var err error
+	if v := query.Get("size"); v != "" {
+		if opts.Size, err = strconv.Atoi(v); err != nil {
+			return nil, fmt.Errorf("invalid size")
+		}
+	}
+	if v := query.Get("logo"); v != "" {
+		if opts.Logo, err = strconv.ParseBool(v); err != nil {
+			return nil, fmt.Errorf("invalid logo")
+		}
+	}
+
+	if err := validator.New(validator.WithRequiredStructEnabled()).Struct(opts); err != nil {
+		return nil, err
+	}
+
+	return opts, nil
+}
+
+func writeQRCode(w http.ResponseWriter, file *service.QRCodeFile) {
+	w.Header().Set("Content-Type", file.ContentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", file.Filename))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+	w.Write(file.Data)
+}
+
+func writeQRCodeError(w http.ResponseWriter, action string, err error) {
+	switch {
+	case errors.Is(err, service.ErrStoreNotFound):
+		resp.WriteJSON(w, http.StatusNotFound, map[string]any{
+			"error": err.Error(),
+		})
+	case errors.Is(err, service.ErrWebsiteNotFound):
+		resp.WriteJSON(w, http.StatusNotFound, map[string]any{
+			"error": "website not found, please create website first",
+		})
+	case errors.Is(err, service.ErrQRCodeNotFound):
+		resp.WriteJSON(w, http.StatusNotFound, map[string]any{
+			"error": err.Error(),
+		})
+	case errors.Is(err, service.ErrInvalidQRCode),
+		errors.Is(err, service.ErrNoStoreLogo),
+		errors.Is(err, service.ErrProductNotFound):
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": err.Error(),
+		})
+	default:
+		log.Printf("%s: %s", action, err.Error())
+		resp.WriteJSON(w, http.StatusInternalServerError, map[string]any{
+			"error": "internal server error",
+		})
+	}
+}
+
+// baseURL is the scheme and host the request came in on.
+func baseURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}
+
+func shortURL(r *http.Request, qrCode *model.QRCode) string {
+	return baseURL(r) + "/q/" + qrCode.Code
+}
@@ -0,0 +1,173 @@
+// internal/handler/sale.go
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"todo-go/internal/model"
+	"todo-go/internal/service"
+	"todo-go/pkg/resp"
+
+	"github.com/go-playground/validator/v10"
+)
+
+type SaleHandler struct {
+	saleSvc *service.SaleService
+}
+
+func NewSaleHandler(saleSvc *service.SaleService) *SaleHandler {
+	return &SaleHandler{saleSvc: saleSvc}
+}
+
+func (h *SaleHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req model.CreateSaleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	err := validator.New(validator.WithRequiredStructEnabled()).Struct(&req)
+	if err != nil {
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx := r.Context()
+	user := ctx.Value("user").(*model.User)
+
+	sale, err := h.saleSvc.Create(ctx, user, &req)
+	if err != nil {
+		writeSaleError(w, "failed to create sale", err)
+		return
+	}
+
+	resp.WriteJSON(w, http.StatusOK, map[string]any{
+		"message": "sale successfully created",
+		"data":    sale,
+	})
+}
+
+func (h *SaleHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	opts, err := parseListOptions(r)
+	if err != nil {
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx := r.Context()
+	user := ctx.Value("user").(*model.User)
+
+	sales, pageInfo, err := h.saleSvc.List(ctx, user, opts)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidListOptions) {
+			resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+				"error": err.Error(),
+			})
+			return
+		}
+		log.Printf("failed to get sales: %s", err.Error())
+		resp.WriteJSON(w, http.StatusInternalServerError, map[string]any{
+			"error": "internal server error",
+		})
+		return
+	}
+
+	setLinkHeader(w, r, pageInfo)
+	resp.WriteJSON(w, http.StatusOK, map[string]any{
+		"data":       sales,
+		"count":      len(sales),
+		"pagination": pageInfo,
+	})
+}
+
+func (h *SaleHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": "invalid sale id",
+		})
+		return
+	}
+
+	var req model.UpdateSaleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	err = validator.New(validator.WithRequiredStructEnabled()).Struct(&req)
+	if err != nil {
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx := r.Context()
+	user := ctx.Value("user").(*model.User)
+	req.ID = int64(id)
+
+	sale, err := h.saleSvc.Update(ctx, user, &req)
+	if err != nil {
+		writeSaleError(w, "failed to update sale", err)
+		return
+	}
+
+	resp.WriteJSON(w, http.StatusOK, map[string]any{
+		"message": "sale successfully updated",
+		"data":    sale,
+	})
+}
+
+func (h *SaleHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": "invalid sale id",
+		})
+		return
+	}
+
+	ctx := r.Context()
+	user := ctx.Value("user").(*model.User)
+
+	if err := h.saleSvc.Delete(ctx, user, int64(id)); err != nil {
+		writeSaleError(w, "failed to delete sale", err)
+		return
+	}
+
+	resp.WriteJSON(w, http.StatusOK, map[string]any{
+		"message": "sale successfully deleted",
+	})
+}
+
+func writeSaleError(w http.ResponseWriter, action string, err error) {
+	switch {
+	case errors.Is(err, service.ErrSaleNotFound):
+		resp.WriteJSON(w, http.StatusNotFound, map[string]any{
+			"error": err.Error(),
+		})
+	case errors.Is(err, service.ErrInvalidSale),
+		errors.Is(err, service.ErrProductNotFound),
+		errors.Is(err, service.ErrCategoryNotFound):
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": err.Error(),
+		})
+	default:
+		log.Printf("%s: %s", action, err.Error())
+		resp.WriteJSON(w, http.StatusInternalServerError, map[string]any{
+			"error": "internal server error",
+		})
+	}
+}
@@ -0,0 +1,212 @@
+// internal/handler/shipping.go
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"todo-go/internal/model"
+	"todo-go/internal/service"
+	"todo-go/pkg/resp"
+
+	"github.com/go-playground/validator/v10"
+)
+
+type ShippingHandler struct {
+	shippingSvc *service.ShippingService
+}
+
+func NewShippingHandler(shippingSvc *service.ShippingService) *ShippingHandler {
+	return &ShippingHandler{shippingSvc: shippingSvc}
+}
+
+func (h *ShippingHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req model.CreateShippingMethodRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	err := validator.New(validator.WithRequiredStructEnabled()).Struct(&req)
+	if err != nil {
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx := r.Context()
+	user := ctx.Value("user").(*model.User)
+
+	method, err := h.shippingSvc.Create(ctx, user, &req)
+	if err != nil {
+		writeShippingError(w, "failed to create shipping method", err)
+		return
+	}
+
+	resp.WriteJSON(w, http.StatusOK, map[string]any{
+		"message": "shipping method successfully created",
+		"data":    method,
+	})
+}
+
+func (h *ShippingHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user := ctx.Value("user").(*model.User)
+
+	methods, err := h.shippingSvc.GetAll(ctx, user)
+	if err != nil {
+		log.Printf("failed to get shipping methods: %s", err.Error())
+		resp.WriteJSON(w, http.StatusInternalServerError, map[string]any{
+			"error": "internal server error",
+		})
+		return
+	}
+
+	resp.WriteJSON(w, http.StatusOK, map[string]any{
+		"data":  methods,
+		"count": len(methods),
+	})
+}
+
+func (h *ShippingHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": "invalid shipping method id",
+		})
+		return
+	}
+
+	var req model.UpdateShippingMethodRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	err = validator.New(validator.WithRequiredStructEnabled()).Struct(&req)
+	if err != nil {
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx := r.Context()
+	user := ctx.Value("user").(*model.User)
+	req.ID = int64(id)
+
+	method, err := h.shippingSvc.Update(ctx, user, &req)
+	if err != nil {
+		writeShippingError(w, "failed to update shipping method", err)
+		return
+	}
+
+	resp.WriteJSON(w, http.StatusOK, map[string]any{
+		"message": "shipping method successfully updated",
+		"data":    method,
+	})
+}
+
+func (h *ShippingHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": "invalid shipping method id",
+		})
+		return
+	}
+
+	ctx := r.Context()
+	user := ctx.Value("user").(*model.User)
+
+	if err := h.shippingSvc.Delete(ctx, user, int64(id)); err != nil {
+		writeShippingError(w, "failed to delete shipping method", err)
+		return
+	}
+
+	resp.WriteJSON(w, http.StatusOK, map[string]any{
+		"message": "shipping method successfully deleted",
+	})
+}
+
+// GetPublic lists a published store's delivery options.
+func (h *ShippingHandler) GetPublic(w http.ResponseWriter, r *http.Request) {
+	domain := r.PathValue("domain")
+	ctx := r.Context()
+
+	methods, err := h.shippingSvc.GetPublic(ctx, domain)
+	if err != nil {
+		writeShippingError(w, "failed to get shipping methods", err)
+		return
+	}
+
+	resp.WriteJSON(w, http.StatusOK, map[string]any{
+		"data":  methods,
+		"count": len(methods),
+	})
+}
+
+// Quote prices a basket with every delivery option of a published store.
+func (h *ShippingHandler) Quote(w http.ResponseWriter, r *http.Request) {
+	domain := r.PathValue("domain")
+
+	var req model.ShippingQuoteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	err := validator.New(validator.WithRequiredStructEnabled()).Struct(&req)
+	if err != nil {
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx := r.Context()
+
+	quotes, err := h.shippingSvc.Quote(ctx, domain, &req)
+	if err != nil {
+		writeShippingError(w, "failed to quote shipping", err)
+		return
+	}
+
+	resp.WriteJSON(w, http.StatusOK, map[string]any{
+		"data":  quotes,
+		"count": len(quotes),
+	})
+}
+
+func writeShippingError(w http.ResponseWriter, action string, err error) {
+	switch {
+	case errors.Is(err, service.ErrShippingMethodNotFound):
+		resp.WriteJSON(w, http.StatusNotFound, map[string]any{
+			"error": err.Error(),
+		})
+	case errors.Is(err, service.ErrWebsiteNotFound):
+		resp.WriteJSON(w, http.StatusNotFound, map[string]any{
+			"error":   "catalog not found",
+			"message": "The requested store catalog does not exist or is not published",
+		})
+	case errors.Is(err, service.ErrInvalidShippingMethod),
+		errors.Is(err, service.ErrInvalidOrderItem):
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": err.Error(),
+		})
+	default:
+		log.Printf("%s: %s", action, err.Error())
+		resp.WriteJSON(w, http.StatusInternalServerError, map[string]any{
+			"error": "internal server error",
+		})
+	}
+}
@@ -0,0 +1,174 @@
+// internal/handler/stock.go
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"todo-go/internal/model"
+	"todo-go/internal/service"
+	"todo-go/pkg/resp"
+
+	"github.com/go-playground/validator/v10"
+)
+
+type StockHandler struct {
+	stockSvc *service.StockService
+}
+
+func NewStockHandler(stockSvc *service.StockService) *StockHandler {
+	return &StockHandler{stockSvc: stockSvc}
+}
+
+func (h *StockHandler) Create(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": "invalid product id",
+		})
+		return
+	}
+
+	var req model.CreateStockMovementRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": err.Error(),
+		})
+		return
+	}
+	req.ProductID = int64(id)
+
+	err = validator.New(validator.WithRequiredStructEnabled()).Struct(&req)
+	if err != nil {
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx := r.Context()
+	user := ctx.Value("user").(*model.User)
+
+	movement, err := h.stockSvc.Record(ctx, user, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrProductNotFound), errors.Is(err, service.ErrVariantNotFound):
+			resp.WriteJSON(w, http.StatusNotFound, map[string]any{
+				"error": err.Error(),
+			})
+			return
+		case errors.Is(err, service.ErrInvalidStockMovement), errors.Is(err, service.ErrVariantRequired):
+			resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+				"error": err.Error(),
+			})
+			return
+		case errors.Is(err, service.ErrInsufficientStock):
+			resp.WriteJSON(w, http.StatusConflict, map[string]any{
+				"error": err.Error(),
+			})
+			return
+		default:
+			log.Printf("failed to record stock movement: %s", err.Error())
+			resp.WriteJSON(w, http.StatusInternalServerError, map[string]any{
+				"error": "internal server error",
+			})
+			return
+		}
+	}
+
+	resp.WriteJSON(w, http.StatusOK, map[string]any{
+		"message": "stock movement successfully recorded",
+		"data":    movement,
+	})
+}
+
+func (h *StockHandler) History(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": "invalid product id",
+		})
+		return
+	}
+
+	opts, err := parseListOptions(r)
+	if err != nil {
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx := r.Context()
+	user := ctx.Value("user").(*model.User)
+
+	movements, pageInfo, err := h.stockSvc.History(ctx, user, int64(id), opts)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrProductNotFound):
+			resp.WriteJSON(w, http.StatusNotFound, map[string]any{
+				"error": err.Error(),
+			})
+			return
+		case errors.Is(err, service.ErrInvalidListOptions):
+			resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+				"error": err.Error(),
+			})
+			return
+		default:
+			log.Printf("failed to get stock history: %s", err.Error())
+			resp.WriteJSON(w, http.StatusInternalServerError, map[string]any{
+				"error": "internal server error",
+			})
+			return
+		}
+	}
+
+	setLinkHeader(w, r, pageInfo)
+	resp.WriteJSON(w, http.StatusOK, map[string]any{
+		"data":       movements,
+		"count":      len(movements),
+		"pagination": pageInfo,
+	})
+}
+
+func (h *StockHandler) Reconcile(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user := ctx.Value("user").(*model.User)
+
+	drifts, err := h.stockSvc.Reconcile(ctx, user)
+	if err != nil {
+		log.Printf("failed to reconcile stock: %s", err.Error())
+		resp.WriteJSON(w, http.StatusInternalServerError, map[string]any{
+			"error": "internal server error",
+		})
+		return
+	}
+
+	resp.WriteJSON(w, http.StatusOK, map[string]any{
+		"message": "stock successfully reconciled",
+		"data":    drifts,
+		"count":   len(drifts),
+	})
+}
+
+func (h *StockHandler) LowStock(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user := ctx.Value("user").(*model.User)
+
+	items, err := h.stockSvc.LowStock(ctx, user)
+	if err != nil {
+		log.Printf("failed to get low stock report: %s", err.Error())
+		resp.WriteJSON(w, http.StatusInternalServerError, map[string]any{
+			"error": "internal server error",
+		})
+		return
+	}
+
+	resp.WriteJSON(w, http.StatusOK, map[string]any{
+		"data":  items,
+		"count": len(items),
+	})
+}
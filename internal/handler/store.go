@@ -110,6 +110,11 @@ func (h *StoreHandler) Update(w http.ResponseWriter, r *http.Request) {
 				"error": err.Error(),
 			})
 			return
+		case errors.Is(err, service.ErrInvalidQRIS):
+			resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+				"error": err.Error(),
+			})
+			return
 		default:
 			log.Printf("failed to update store: %s", err.Error())
 			resp.WriteJSON(w, http.StatusInternalServerError, map[string]any{
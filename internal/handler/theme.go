@@ -0,0 +1,67 @@
+// internal/handler/theme.go
+package handler
+
+import (
+	"net/http"
+	"todo-go/internal/storefront"
+	"todo-go/pkg/resp"
+)
+
+type ThemeHandler struct {
+	registry *storefront.Registry
+}
+
+func NewThemeHandler(registry *storefront.Registry) *ThemeHandler {
+	return &ThemeHandler{registry: registry}
+}
+
+type themeResponse struct {
+	*storefront.Theme
+	PreviewURL string `json:"preview_url,omitempty"`
+}
+
+// GetAll lists the templates a website can use, with the settings each one
+// accepts.
+func (h *ThemeHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	themes := h.registry.Themes()
+
+	data := make([]themeResponse, 0, len(themes))
+	for _, theme := range themes {
+		item := themeResponse{Theme: theme}
+		if image, _ := theme.Preview(); image != nil {
+			item.PreviewURL = "/api/v1/templates/" + theme.Name + "/preview"
+		}
+		data = append(data, item)
+	}
+
+	resp.WriteJSON(w, http.StatusOK, map[string]any{
+		"data":  data,
+		"count": len(data),
+	})
+}
+
+func (h *ThemeHandler) Preview(w http.ResponseWriter, r *http.Request) {
+	theme := h.registry.Theme(r.PathValue("name"))
+	if theme == nil {
+		resp.WriteJSON(w, http.StatusNotFound, map[string]any{
+			"error": "template not found",
+		})
+		return
+	}
+
+	image, contentType := theme.Preview()
+	if image == nil {
+		resp.WriteJSON(w, http.StatusNotFound, map[string]any{
+			"error": "template has no preview image",
+		})
+		return
+	}
+
+	// SVG previews come from theme folders; never let them run anything
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Cache-Control", "public, max-age=3600")
+	w.WriteHeader(http.StatusOK)
+	w.Write(image)
+}
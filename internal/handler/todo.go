@@ -174,14 +174,35 @@ func (h *TodoHandler) GetAllByUser(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	user := ctx.Value("user").(*model.User)
 
-	todos, err := h.todoSvc.GetAllByUser(ctx, user)
+	opts, err := parseListOptions(r)
 	if err != nil {
-		log.Printf("failed to get todo: %s", err.Error())
-		resp.WriteJSON(w, http.StatusInternalServerError, map[string]any{
-			"error": "internal server error",
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": err.Error(),
 		})
 		return
 	}
 
-	resp.WriteJSON(w, http.StatusOK, todos)
+	todos, pageInfo, err := h.todoSvc.GetAllByUser(ctx, user, opts)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidListOptions):
+			resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+				"error": err.Error(),
+			})
+			return
+		default:
+			log.Printf("failed to get todo: %s", err.Error())
+			resp.WriteJSON(w, http.StatusInternalServerError, map[string]any{
+				"error": "internal server error",
+			})
+			return
+		}
+	}
+
+	setLinkHeader(w, r, pageInfo)
+	resp.WriteJSON(w, http.StatusOK, map[string]any{
+		"data":       todos,
+		"count":      len(todos),
+		"pagination": pageInfo,
+	})
 }
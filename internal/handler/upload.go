@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"todo-go/internal/model"
+	"todo-go/internal/service"
+	"todo-go/pkg/resp"
+)
+
+type UploadHandler struct {
+	uploadSvc *service.UploadService
+}
+
+func NewUploadHandler(uploadSvc *service.UploadService) *UploadHandler {
+	return &UploadHandler{uploadSvc: uploadSvc}
+}
+
+func (h *UploadHandler) StoreLogo(w http.ResponseWriter, r *http.Request) {
+	data, ok := readUpload(w, r, "logo")
+	if !ok {
+		return
+	}
+
+	ctx := r.Context()
+	user := ctx.Value("user").(*model.User)
+
+	store, err := h.uploadSvc.UploadStoreLogo(ctx, user, data)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrStoreNotFound):
+			resp.WriteJSON(w, http.StatusNotFound, map[string]any{
+				"error": err.Error(),
+			})
+			return
+		case errors.Is(err, service.ErrUnsupportedImage), errors.Is(err, service.ErrImageTooLarge):
+			resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+				"error": err.Error(),
+			})
+			return
+		default:
+			log.Printf("failed to upload store logo: %s", err.Error())
+			resp.WriteJSON(w, http.StatusInternalServerError, map[string]any{
+				"error": "internal server error",
+			})
+			return
+		}
+	}
+
+	resp.WriteJSON(w, http.StatusOK, map[string]any{
+		"message": "store logo successfully uploaded",
+		"data":    store,
+	})
+}
+
+// readUpload reads a single multipart file field, enforcing the upload size
+// limit. It writes the error response itself and reports whether to go on.
+func readUpload(w http.ResponseWriter, r *http.Request, field string) ([]byte, bool) {
+	r.Body = http.MaxBytesReader(w, r.Body, service.MaxUploadSize+1<<20)
+
+	file, _, err := r.FormFile(field)
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			resp.WriteJSON(w, http.StatusRequestEntityTooLarge, map[string]any{
+				"error": "file is too large, maximum size is 5MB",
+			})
+			return nil, false
+		}
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": "missing file field " + strconv.Quote(field),
+		})
+		return nil, false
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, service.MaxUploadSize+1))
+	if err != nil {
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": err.Error(),
+		})
+		return nil, false
+	}
+	if len(data) > service.MaxUploadSize {
+		resp.WriteJSON(w, http.StatusRequestEntityTooLarge, map[string]any{
+			"error": "file is too large, maximum size is 5MB",
+		})
+		return nil, false
+	}
+
+	return data, true
+}
@@ -0,0 +1,175 @@
+// internal/handler/voucher.go
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"todo-go/internal/model"
+	"todo-go/internal/service"
+	"todo-go/pkg/resp"
+
+	"github.com/go-playground/validator/v10"
+)
+
+type VoucherHandler struct {
+	voucherSvc *service.VoucherService
+}
+
+func NewVoucherHandler(voucherSvc *service.VoucherService) *VoucherHandler {
+	return &VoucherHandler{voucherSvc: voucherSvc}
+}
+
+func (h *VoucherHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req model.CreateVoucherRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	err := validator.New(validator.WithRequiredStructEnabled()).Struct(&req)
+	if err != nil {
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx := r.Context()
+	user := ctx.Value("user").(*model.User)
+
+	voucher, err := h.voucherSvc.Create(ctx, user, &req)
+	if err != nil {
+		writeVoucherError(w, "failed to create voucher", err)
+		return
+	}
+
+	resp.WriteJSON(w, http.StatusOK, map[string]any{
+		"message": "voucher successfully created",
+		"data":    voucher,
+	})
+}
+
+func (h *VoucherHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	opts, err := parseListOptions(r)
+	if err != nil {
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx := r.Context()
+	user := ctx.Value("user").(*model.User)
+
+	vouchers, pageInfo, err := h.voucherSvc.List(ctx, user, opts)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidListOptions) {
+			resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+				"error": err.Error(),
+			})
+			return
+		}
+		log.Printf("failed to get vouchers: %s", err.Error())
+		resp.WriteJSON(w, http.StatusInternalServerError, map[string]any{
+			"error": "internal server error",
+		})
+		return
+	}
+
+	setLinkHeader(w, r, pageInfo)
+	resp.WriteJSON(w, http.StatusOK, map[string]any{
+		"data":       vouchers,
+		"count":      len(vouchers),
+		"pagination": pageInfo,
+	})
+}
+
+func (h *VoucherHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": "invalid voucher id",
+		})
+		return
+	}
+
+	var req model.UpdateVoucherRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	err = validator.New(validator.WithRequiredStructEnabled()).Struct(&req)
+	if err != nil {
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx := r.Context()
+	user := ctx.Value("user").(*model.User)
+	req.ID = int64(id)
+
+	voucher, err := h.voucherSvc.Update(ctx, user, &req)
+	if err != nil {
+		writeVoucherError(w, "failed to update voucher", err)
+		return
+	}
+
+	resp.WriteJSON(w, http.StatusOK, map[string]any{
+		"message": "voucher successfully updated",
+		"data":    voucher,
+	})
+}
+
+func (h *VoucherHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": "invalid voucher id",
+		})
+		return
+	}
+
+	ctx := r.Context()
+	user := ctx.Value("user").(*model.User)
+
+	if err := h.voucherSvc.Delete(ctx, user, int64(id)); err != nil {
+		writeVoucherError(w, "failed to delete voucher", err)
+		return
+	}
+
+	resp.WriteJSON(w, http.StatusOK, map[string]any{
+		"message": "voucher successfully deleted",
+	})
+}
+
+func writeVoucherError(w http.ResponseWriter, action string, err error) {
+	switch {
+	case errors.Is(err, service.ErrVoucherNotFound):
+		resp.WriteJSON(w, http.StatusNotFound, map[string]any{
+			"error": err.Error(),
+		})
+	case errors.Is(err, service.ErrInvalidVoucher),
+		errors.Is(err, service.ErrVoucherWindow),
+		errors.Is(err, service.ErrVoucherCodeTaken),
+		errors.Is(err, service.ErrProductNotFound),
+		errors.Is(err, service.ErrCategoryNotFound):
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": err.Error(),
+		})
+	default:
+		log.Printf("%s: %s", action, err.Error())
+		resp.WriteJSON(w, http.StatusInternalServerError, map[string]any{
+			"error": "internal server error",
+		})
+	}
+}
@@ -2,28 +2,36 @@
 package handler
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 	"todo-go/internal/model"
 	"todo-go/internal/service"
-	"todo-go/pkg/qr"
+	"todo-go/internal/storefront"
 	"todo-go/pkg/resp"
 
 	"github.com/go-playground/validator/v10"
 )
 
 type WebsiteHandler struct {
-	websiteSvc *service.WebsiteService
-	qrSvc      *qr.Service
+	websiteSvc  *service.WebsiteService
+	shippingSvc *service.ShippingService
+	renderer    *storefront.Renderer
 }
 
-func NewWebsiteHandler(websiteSvc *service.WebsiteService, qrSvc *qr.Service) *WebsiteHandler {
+func NewWebsiteHandler(websiteSvc *service.WebsiteService, shippingSvc *service.ShippingService, renderer *storefront.Renderer) *WebsiteHandler {
 	return &WebsiteHandler{
-		websiteSvc: websiteSvc,
-		qrSvc:      qrSvc,
+		websiteSvc:  websiteSvc,
+		shippingSvc: shippingSvc,
+		renderer:    renderer,
 	}
 }
 
@@ -49,10 +57,7 @@ func (h *WebsiteHandler) Create(w http.ResponseWriter, r *http.Request) {
 
 	website, err := h.websiteSvc.Create(ctx, user, &req)
 	if err != nil {
-		log.Printf("failed to create website: %s", err.Error())
-		resp.WriteJSON(w, http.StatusInternalServerError, map[string]any{
-			"error": "internal server error",
-		})
+		writeDomainError(w, "failed to create website", err)
 		return
 	}
 
@@ -66,7 +71,7 @@ func (h *WebsiteHandler) Get(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	user := ctx.Value("user").(*model.User)
 
-	website, err := h.websiteSvc.GetByUser(ctx, user)
+	website, err := h.websiteSvc.GetWithDraft(ctx, user)
 	if err != nil {
 		switch {
 		case errors.Is(err, service.ErrWebsiteNotFound):
@@ -117,34 +122,203 @@ func (h *WebsiteHandler) Update(w http.ResponseWriter, r *http.Request) {
 			})
 			return
 		default:
-			log.Printf("failed to update website: %s", err.Error())
-			resp.WriteJSON(w, http.StatusInternalServerError, map[string]any{
-				"error": "internal server error",
-			})
+			writeDomainError(w, "failed to update website", err)
 			return
 		}
 	}
 
 	resp.WriteJSON(w, http.StatusOK, map[string]any{
-		"message": "website successfully updated",
+		"message": "draft saved, publish to make it live",
+		"data":    website,
+	})
+}
+
+func (h *WebsiteHandler) Publish(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user := ctx.Value("user").(*model.User)
+
+	website, err := h.websiteSvc.Publish(ctx, user)
+	if err != nil {
+		writeRevisionError(w, "failed to publish website", err)
+		return
+	}
+
+	resp.WriteJSON(w, http.StatusOK, map[string]any{
+		"message": "website successfully published",
+		"data":    website,
+	})
+}
+
+func (h *WebsiteHandler) Unpublish(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user := ctx.Value("user").(*model.User)
+
+	website, err := h.websiteSvc.Unpublish(ctx, user)
+	if err != nil {
+		writeRevisionError(w, "failed to unpublish website", err)
+		return
+	}
+
+	resp.WriteJSON(w, http.StatusOK, map[string]any{
+		"message": "website successfully unpublished",
 		"data":    website,
 	})
 }
 
-func (h *WebsiteHandler) GenerateQR(w http.ResponseWriter, r *http.Request) {
+func (h *WebsiteHandler) ListRevisions(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	user := ctx.Value("user").(*model.User)
 
-	website, err := h.websiteSvc.GetByUser(ctx, user)
+	revisions, err := h.websiteSvc.ListRevisions(ctx, user)
+	if err != nil {
+		writeRevisionError(w, "failed to get website revisions", err)
+		return
+	}
+
+	resp.WriteJSON(w, http.StatusOK, map[string]any{
+		"data":  revisions,
+		"count": len(revisions),
+	})
+}
+
+// DiffRevisions compares ?from= with ?to=; either defaults to the live
+// version and the draft respectively.
+func (h *WebsiteHandler) DiffRevisions(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	var versions [2]int
+	for i, name := range []string{"from", "to"} {
+		if raw := query.Get(name); raw != "" {
+			version, err := strconv.Atoi(raw)
+			if err != nil || version < 1 {
+				resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+					"error": "invalid " + name + " version",
+				})
+				return
+			}
+			versions[i] = version
+		}
+	}
+
+	ctx := r.Context()
+	user := ctx.Value("user").(*model.User)
+
+	result, err := h.websiteSvc.DiffRevisions(ctx, user, versions[0], versions[1])
+	if err != nil {
+		writeRevisionError(w, "failed to diff website revisions", err)
+		return
+	}
+
+	resp.WriteJSON(w, http.StatusOK, map[string]any{
+		"data": result,
+	})
+}
+
+func (h *WebsiteHandler) Rollback(w http.ResponseWriter, r *http.Request) {
+	version, err := strconv.Atoi(r.PathValue("version"))
+	if err != nil {
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": "invalid version",
+		})
+		return
+	}
+
+	ctx := r.Context()
+	user := ctx.Value("user").(*model.User)
+
+	website, err := h.websiteSvc.Rollback(ctx, user, version)
+	if err != nil {
+		writeRevisionError(w, "failed to roll back website", err)
+		return
+	}
+
+	resp.WriteJSON(w, http.StatusOK, map[string]any{
+		"message": fmt.Sprintf("website rolled back to version %d", version),
+		"data":    website,
+	})
+}
+
+func (h *WebsiteHandler) CreatePreview(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user := ctx.Value("user").(*model.User)
+
+	preview, err := h.websiteSvc.CreatePreview(ctx, user)
+	if err != nil {
+		writeRevisionError(w, "failed to create preview", err)
+		return
+	}
+
+	preview.URL = baseURL(r) + preview.URL
+
+	resp.WriteJSON(w, http.StatusOK, map[string]any{
+		"message": "preview link created",
+		"data":    preview,
+	})
+}
+
+// Preview renders the website as a preview link shows it. Previews are
+// never cached or indexed.
+func (h *WebsiteHandler) Preview(w http.ResponseWriter, r *http.Request) {
+	opts, err := parseListOptions(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	catalog, err := h.websiteSvc.PreviewCatalog(r.Context(), r.PathValue("token"), opts)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrPreviewNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, service.ErrInvalidListOptions):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			log.Printf("failed to get preview: %s", err.Error())
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Robots-Tag", "noindex")
+	h.renderCatalog(w, r, catalog)
+}
+
+func (h *WebsiteHandler) GetCatalog(w http.ResponseWriter, r *http.Request) {
+	domain := r.PathValue("domain")
+	if domain == "" {
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": "domain is required",
+		})
+		return
+	}
+
+	ctx := r.Context()
+
+	opts, err := parseListOptions(r)
+	if err != nil {
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	catalog, err := h.websiteSvc.GetCatalog(ctx, domain, opts)
 	if err != nil {
 		switch {
+		case errors.Is(err, service.ErrInvalidListOptions):
+			resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+				"error": err.Error(),
+			})
+			return
 		case errors.Is(err, service.ErrWebsiteNotFound):
 			resp.WriteJSON(w, http.StatusNotFound, map[string]any{
-				"error": "website not found, please create website first",
+				"error": "catalog not found",
+				"message": "The requested store catalog does not exist or is not published",
 			})
 			return
 		default:
-			log.Printf("failed to get website: %s", err.Error())
+			log.Printf("failed to get catalog: %s", err.Error())
 			resp.WriteJSON(w, http.StatusInternalServerError, map[string]any{
 				"error": "internal server error",
 			})
@@ -152,58 +326,215 @@ func (h *WebsiteHandler) GenerateQR(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	// Generate catalog URL
-	host := r.Header.Get("Host")
-	if host == "" {
-		host = "localhost:8080"
+	// Clients may keep the catalog but must revalidate it, which costs a 304
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Add("Vary", "Accept")
+
+	if wantsHTML(r) {
+		h.renderCatalog(w, r, catalog)
+		return
 	}
-	
-	scheme := "http"
-	if r.TLS != nil {
-		scheme = "https"
+
+	body, err := json.Marshal(map[string]any{
+		"store":      catalog.Store,
+		"categories": catalog.Categories,
+		"products":   catalog.Products,
+		"count":      len(catalog.Products),
+		"pagination": catalog.Pagination,
+	})
+	if err != nil {
+		log.Printf("failed to encode catalog: %s", err.Error())
+		resp.WriteJSON(w, http.StatusInternalServerError, map[string]any{
+			"error": "internal server error",
+		})
+		return
 	}
 
-	catalogURL := fmt.Sprintf("%s://%s/catalog/%s", scheme, host, website.Domain)
+	setLinkHeader(w, r, catalog.Pagination)
+	w.Header().Set("Content-Type", "application/json")
+	writeCatalog(w, r, body, catalog.LoadedAt)
+}
 
-	// Generate QR code
-	qrCode, err := h.qrSvc.GenerateQR(catalogURL)
+// GetCatalogProduct shows one product of a catalog, the page product QR
+// codes link to.
+func (h *WebsiteHandler) GetCatalogProduct(w http.ResponseWriter, r *http.Request) {
+	productID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
 	if err != nil {
-		log.Printf("failed to generate QR code: %s", err.Error())
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": "invalid product id",
+		})
+		return
+	}
+
+	catalog, err := h.websiteSvc.GetCatalogProduct(r.Context(), r.PathValue("domain"), productID)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrWebsiteNotFound):
+			resp.WriteJSON(w, http.StatusNotFound, map[string]any{
+				"error":   "catalog not found",
+				"message": "The requested store catalog does not exist or is not published",
+			})
+			return
+		case errors.Is(err, service.ErrProductNotFound):
+			resp.WriteJSON(w, http.StatusNotFound, map[string]any{
+				"error": err.Error(),
+			})
+			return
+		default:
+			log.Printf("failed to get catalog product: %s", err.Error())
+			resp.WriteJSON(w, http.StatusInternalServerError, map[string]any{
+				"error": "internal server error",
+			})
+			return
+		}
+	}
+
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Add("Vary", "Accept")
+
+	if wantsHTML(r) {
+		h.renderCatalog(w, r, catalog)
+		return
+	}
+
+	body, err := json.Marshal(map[string]any{
+		"store":   catalog.Store,
+		"product": catalog.Products[0],
+	})
+	if err != nil {
+		log.Printf("failed to encode catalog product: %s", err.Error())
 		resp.WriteJSON(w, http.StatusInternalServerError, map[string]any{
-			"error": "failed to generate QR code",
+			"error": "internal server error",
 		})
 		return
 	}
 
-	// Set response headers for image
-	w.Header().Set("Content-Type", "image/png")
-	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"qr-catalog-%s.png\"", website.Domain))
-	w.WriteHeader(http.StatusOK)
-	w.Write(qrCode)
+	w.Header().Set("Content-Type", "application/json")
+	writeCatalog(w, r, body, catalog.LoadedAt)
 }
 
-func (h *WebsiteHandler) GetCatalog(w http.ResponseWriter, r *http.Request) {
+func (h *WebsiteHandler) GetCategoryCatalog(w http.ResponseWriter, r *http.Request) {
 	domain := r.PathValue("domain")
-	if domain == "" {
+	categorySlug := r.PathValue("slug")
+	if domain == "" || categorySlug == "" {
 		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
-			"error": "domain is required",
+			"error": "domain and category are required",
+		})
+		return
+	}
+
+	ctx := r.Context()
+
+	opts, err := parseListOptions(r)
+	if err != nil {
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	catalog, err := h.websiteSvc.GetCategoryCatalog(ctx, domain, categorySlug, opts)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidListOptions):
+			resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+				"error": err.Error(),
+			})
+			return
+		case errors.Is(err, service.ErrWebsiteNotFound):
+			resp.WriteJSON(w, http.StatusNotFound, map[string]any{
+				"error":   "catalog not found",
+				"message": "The requested store catalog does not exist or is not published",
+			})
+			return
+		case errors.Is(err, service.ErrCategoryNotFound):
+			resp.WriteJSON(w, http.StatusNotFound, map[string]any{
+				"error": err.Error(),
+			})
+			return
+		default:
+			log.Printf("failed to get category catalog: %s", err.Error())
+			resp.WriteJSON(w, http.StatusInternalServerError, map[string]any{
+				"error": "internal server error",
+			})
+			return
+		}
+	}
+
+	setLinkHeader(w, r, catalog.Pagination)
+	resp.WriteJSON(w, http.StatusOK, map[string]any{
+		"store":      catalog.Store,
+		"category":   catalog.Category,
+		"products":   catalog.Products,
+		"count":      len(catalog.Products),
+		"pagination": catalog.Pagination,
+	})
+}
+
+func (h *WebsiteHandler) SearchCatalog(w http.ResponseWriter, r *http.Request) {
+	domain := r.PathValue("domain")
+	query := r.URL.Query()
+
+	req := model.SearchProductsRequest{
+		Query: strings.TrimSpace(query.Get("q")),
+	}
+
+	for name, target := range map[string]**float64{"min_price": &req.MinPrice, "max_price": &req.MaxPrice} {
+		if raw := query.Get(name); raw != "" {
+			value, err := strconv.ParseFloat(raw, 64)
+			if err != nil {
+				resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+					"error": "invalid " + name,
+				})
+				return
+			}
+			*target = &value
+		}
+	}
+
+	if raw := query.Get("in_stock"); raw != "" {
+		inStock, err := strconv.ParseBool(raw)
+		if err != nil {
+			resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+				"error": "invalid in_stock",
+			})
+			return
+		}
+		req.InStock = inStock
+	}
+
+	if raw := query.Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil {
+			resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+				"error": "invalid limit",
+			})
+			return
+		}
+		req.Limit = limit
+	}
+
+	err := validator.New(validator.WithRequiredStructEnabled()).Struct(&req)
+	if err != nil {
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": err.Error(),
 		})
 		return
 	}
 
 	ctx := r.Context()
 
-	catalog, err := h.websiteSvc.GetCatalog(ctx, domain)
+	result, err := h.websiteSvc.SearchCatalog(ctx, domain, &req)
 	if err != nil {
 		switch {
 		case errors.Is(err, service.ErrWebsiteNotFound):
 			resp.WriteJSON(w, http.StatusNotFound, map[string]any{
-				"error": "catalog not found",
+				"error":   "catalog not found",
 				"message": "The requested store catalog does not exist or is not published",
 			})
 			return
 		default:
-			log.Printf("failed to get catalog: %s", err.Error())
+			log.Printf("failed to search catalog: %s", err.Error())
 			resp.WriteJSON(w, http.StatusInternalServerError, map[string]any{
 				"error": "internal server error",
 			})
@@ -212,8 +543,201 @@ func (h *WebsiteHandler) GetCatalog(w http.ResponseWriter, r *http.Request) {
 	}
 
 	resp.WriteJSON(w, http.StatusOK, map[string]any{
-		"store":    catalog.Store,
-		"products": catalog.Products,
-		"count":    len(catalog.Products),
+		"store":    result.Store,
+		"query":    req.Query,
+		"products": result.Products,
+		"count":    len(result.Products),
+	})
+}
+
+func (h *WebsiteHandler) CheckDomain(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user := ctx.Value("user").(*model.User)
+
+	availability, err := h.websiteSvc.CheckDomain(ctx, user, r.URL.Query().Get("domain"))
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrStoreNotFound):
+			resp.WriteJSON(w, http.StatusNotFound, map[string]any{
+				"error": "store not found, please create store first",
+			})
+			return
+		default:
+			log.Printf("failed to check domain: %s", err.Error())
+			resp.WriteJSON(w, http.StatusInternalServerError, map[string]any{
+				"error": "internal server error",
+			})
+			return
+		}
+	}
+
+	resp.WriteJSON(w, http.StatusOK, map[string]any{
+		"data": availability,
+	})
+}
+
+func (h *WebsiteHandler) SetCustomDomain(w http.ResponseWriter, r *http.Request) {
+	var req model.SetCustomDomainRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	err := validator.New(validator.WithRequiredStructEnabled()).Struct(&req)
+	if err != nil {
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx := r.Context()
+	user := ctx.Value("user").(*model.User)
+
+	verification, err := h.websiteSvc.SetCustomDomain(ctx, user, &req)
+	if err != nil {
+		writeDomainError(w, "failed to set custom domain", err)
+		return
+	}
+
+	resp.WriteJSON(w, http.StatusOK, map[string]any{
+		"message": "custom domain saved, add the TXT record and verify",
+		"data":    verification,
 	})
-}
\ No newline at end of file
+}
+
+func (h *WebsiteHandler) VerifyCustomDomain(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user := ctx.Value("user").(*model.User)
+
+	verification, err := h.websiteSvc.VerifyCustomDomain(ctx, user)
+	if err != nil {
+		writeDomainError(w, "failed to verify custom domain", err)
+		return
+	}
+
+	resp.WriteJSON(w, http.StatusOK, map[string]any{
+		"message": "custom domain verified",
+		"data":    verification,
+	})
+}
+
+func writeDomainError(w http.ResponseWriter, action string, err error) {
+	switch {
+	case errors.Is(err, service.ErrWebsiteNotFound):
+		resp.WriteJSON(w, http.StatusNotFound, map[string]any{
+			"error": "website not found, please create website first",
+		})
+	case errors.Is(err, service.ErrInvalidDomain), errors.Is(err, service.ErrDomainNotVerified),
+		errors.Is(err, service.ErrInvalidTemplate):
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": err.Error(),
+		})
+	case errors.Is(err, service.ErrDomainTaken):
+		resp.WriteJSON(w, http.StatusConflict, map[string]any{
+			"error": err.Error(),
+		})
+	default:
+		log.Printf("%s: %s", action, err.Error())
+		resp.WriteJSON(w, http.StatusInternalServerError, map[string]any{
+			"error": "internal server error",
+		})
+	}
+}
+
+func writeRevisionError(w http.ResponseWriter, action string, err error) {
+	switch {
+	case errors.Is(err, service.ErrWebsiteNotFound):
+		resp.WriteJSON(w, http.StatusNotFound, map[string]any{
+			"error": "website not found, please create website first",
+		})
+	case errors.Is(err, service.ErrRevisionNotFound):
+		resp.WriteJSON(w, http.StatusNotFound, map[string]any{
+			"error": err.Error(),
+		})
+	case errors.Is(err, service.ErrInvalidRevision):
+		resp.WriteJSON(w, http.StatusBadRequest, map[string]any{
+			"error": err.Error(),
+		})
+	default:
+		log.Printf("%s: %s", action, err.Error())
+		resp.WriteJSON(w, http.StatusInternalServerError, map[string]any{
+			"error": "internal server error",
+		})
+	}
+}
+
+// renderCatalog serves the catalog as the store's website, in the theme the
+// owner picked.
+func (h *WebsiteHandler) renderCatalog(w http.ResponseWriter, r *http.Request, catalog *service.CatalogData) {
+	ctx := r.Context()
+
+	methods, err := h.shippingSvc.GetPublic(ctx, catalog.Website.Domain)
+	if err != nil {
+		log.Printf("failed to get shipping methods: %s", err.Error())
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	page := &storefront.Page{
+		Website:    catalog.Website,
+		Store:      catalog.Store,
+		Categories: catalog.Categories,
+		Products:   catalog.Products,
+		Shipping:   methods,
+		OrderURL:   fmt.Sprintf("/api/v1/orders/%d", catalog.Store.ID),
+	}
+
+	// Set by QR code short links, see QRCodeHandler.Scan
+	query := r.URL.Query()
+	if table := strings.TrimSpace(query.Get("table")); len(table) <= 20 {
+		page.TableNumber = table
+	}
+	if code := query.Get("qr"); len(code) <= 16 {
+		page.QRCode = code
+	}
+	if p := catalog.Pagination; p != nil && p.HasMore {
+		if p.NextCursor != "" {
+			query.Set("cursor", p.NextCursor)
+		} else {
+			query.Set("page", strconv.Itoa(p.Page+1))
+		}
+		page.NextURL = r.URL.Path + "?" + query.Encode()
+	}
+
+	var body bytes.Buffer
+	if err := h.renderer.Render(&body, page); err != nil {
+		log.Printf("failed to render catalog: %s", err.Error())
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Security-Policy", storefront.ContentSecurityPolicy)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Referrer-Policy", "same-origin")
+	writeCatalog(w, r, body.Bytes(), catalog.LoadedAt)
+}
+
+// writeCatalog sends a catalog page with a strong ETag over its exact bytes
+// and the time its data was read as Last-Modified, answering conditional
+// requests with 304 Not Modified.
+func writeCatalog(w http.ResponseWriter, r *http.Request, body []byte, loadedAt time.Time) {
+	sum := sha256.Sum256(body)
+	w.Header().Set("ETag", `"`+hex.EncodeToString(sum[:16])+`"`)
+	http.ServeContent(w, r, "", loadedAt, bytes.NewReader(body))
+}
+
+// wantsHTML reports whether the catalog should be served as a web page:
+// browsers ask for text/html, API clients get JSON. ?format= overrides it.
+func wantsHTML(r *http.Request) bool {
+	switch r.URL.Query().Get("format") {
+	case "html":
+		return true
+	case "json":
+		return false
+	}
+	return strings.Contains(r.Header.Get("Accept"), "text/html")
+}
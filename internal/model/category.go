@@ -0,0 +1,54 @@
+// internal/model/category.go
+package model
+
+import "time"
+
+type Category struct {
+	ID        int64     `json:"id"`
+	StoreID   int64     `json:"store_id" gorm:"uniqueIndex:idx_categories_store_slug"`
+	ParentID  *int64    `json:"parent_id" gorm:"index"`
+	Name      string    `json:"name"`
+	Slug      string    `json:"slug" gorm:"size:100;uniqueIndex:idx_categories_store_slug"`
+	Position  int       `json:"position"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Children []*Category `json:"children,omitempty" gorm:"-"`
+}
+
+type CreateCategoryRequest struct {
+	Name     string `json:"name" validate:"required,max=100"`
+	Slug     string `json:"slug" validate:"omitempty,max=100"`
+	ParentID *int64 `json:"parent_id"`
+	Position int    `json:"position"`
+}
+
+type UpdateCategoryRequest struct {
+	ID       int64
+	Name     string `json:"name" validate:"required,max=100"`
+	Slug     string `json:"slug" validate:"omitempty,max=100"`
+	ParentID *int64 `json:"parent_id"`
+	Position int    `json:"position"`
+}
+
+// BuildCategoryTree nests a flat, ordered category list under its parents
+// and returns the roots.
+func BuildCategoryTree(categories []*Category) []*Category {
+	byID := make(map[int64]*Category, len(categories))
+	for _, category := range categories {
+		category.Children = nil
+		byID[category.ID] = category
+	}
+
+	roots := make([]*Category, 0)
+	for _, category := range categories {
+		if category.ParentID != nil {
+			if parent, ok := byID[*category.ParentID]; ok {
+				parent.Children = append(parent.Children, category)
+				continue
+			}
+		}
+		roots = append(roots, category)
+	}
+	return roots
+}
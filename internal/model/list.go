@@ -0,0 +1,32 @@
+// internal/model/list.go
+package model
+
+const (
+	DefaultListLimit = 20
+	MaxListLimit     = 100
+)
+
+// ListOptions is accepted by every list endpoint. Either Page or Cursor
+// selects the page; a cursor wins when both are given. Sort names a field,
+// prefixed with "-" for descending order (e.g. "-price").
+type ListOptions struct {
+	Page   int    `validate:"min=0"`
+	Cursor string `validate:"max=512"`
+	Limit  int    `validate:"min=0,max=100"`
+	Sort   string `validate:"max=32"`
+
+	// Filters, ignored by lists they don't apply to
+	CategoryIDs []int64
+	Active      *bool
+	InStock     bool
+}
+
+// PageInfo describes where a page sits in the full result.
+type PageInfo struct {
+	Page       int    `json:"page,omitempty"`
+	Limit      int    `json:"limit"`
+	Total      int64  `json:"total"`
+	TotalPages int    `json:"total_pages"`
+	HasMore    bool   `json:"has_more"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
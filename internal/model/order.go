@@ -9,21 +9,64 @@ type Order struct {
 	CustomerPhone string    `json:"customer_phone"`
 	Items         string    `json:"items"` // JSON string of ordered items
 	TotalAmount   float64   `json:"total_amount"`
-	Status        string    `json:"status"` // pending, confirmed, completed, cancelled
+	Status        string    `json:"status"` // one of the Order* statuses below
 	Notes         string    `json:"notes"`
 	CreatedAt     time.Time `json:"created_at"`
 	UpdatedAt     time.Time `json:"updated_at"`
+
+	// Set when a voucher was used, TotalAmount has Discount taken off
+	VoucherCode string  `json:"voucher_code,omitempty" gorm:"size:32"`
+	Subtotal    float64 `json:"subtotal"`
+	Discount    float64 `json:"discount"`
+
+	// Delivery chosen by the customer; TotalAmount includes ShippingFee
+	ShippingMethodID *int64           `json:"shipping_method_id"`
+	ShippingMethod   string           `json:"shipping_method,omitempty"` // name snapshot
+	ShippingFee      float64          `json:"shipping_fee"`
+	ShippingAddress  *ShippingAddress `json:"shipping_address" gorm:"serializer:json"`
+
+	// Set once a payment through the provider succeeds, see Payment
+	PaidAt *time.Time `json:"paid_at"`
+
+	// Filled in when the customer came through a QR code, see QRCode
+	TableNumber string `json:"table_number,omitempty" gorm:"size:20"`
+	QRCodeID    *int64 `json:"qr_code_id,omitempty" gorm:"index"`
 }
 
+const (
+	OrderPending   = "pending"
+	OrderConfirmed = "confirmed"
+	OrderCompleted = "completed"
+	OrderCancelled = "cancelled"
+)
+
 type CreateOrderRequest struct {
-	Items         []OrderItem `json:"items" validate:"required,min=1"`
+	Items         []OrderItem `json:"items" validate:"required,min=1,dive"`
 	CustomerName  string      `json:"customer_name" validate:"required"`
 	CustomerPhone string      `json:"customer_phone" validate:"required"`
 	Notes         string      `json:"notes"`
+	VoucherCode   string      `json:"voucher_code" validate:"max=32"`
+
+	// Required once the store offers shipping methods; the address may
+	// only be left out for pickup
+	ShippingMethodID int64            `json:"shipping_method_id"`
+	ShippingAddress  *ShippingAddress `json:"shipping_address"`
+
+	// Carried over from the QR code the customer scanned; an unknown
+	// qr_code is ignored
+	TableNumber string `json:"table_number" validate:"max=20"`
+	QRCode      string `json:"qr_code" validate:"max=16"`
 }
 
 type OrderItem struct {
-	ProductID int64   `json:"product_id"`
-	Quantity  int     `json:"quantity"`
+	ProductID int64   `json:"product_id" validate:"required"`
+	VariantID int64   `json:"variant_id,omitempty"`
+	Variant   string  `json:"variant,omitempty"` // option label snapshot, filled by the server
+	Quantity  int     `json:"quantity" validate:"required,min=1"`
 	Price     float64 `json:"price"`
 }
+
+type UpdateOrderStatusRequest struct {
+	ID     int64
+	Status string `json:"status" validate:"required,oneof=confirmed completed cancelled"`
+}
@@ -0,0 +1,78 @@
+// internal/model/payment.go
+package model
+
+import "time"
+
+type PaymentStatus string
+
+const (
+	PaymentPending PaymentStatus = "pending"
+	PaymentPaid    PaymentStatus = "paid"
+	PaymentFailed  PaymentStatus = "failed"
+	PaymentExpired PaymentStatus = "expired"
+
+	// PaymentRefundDue is a payment that came in after its order was
+	// cancelled; the store owes the customer a refund
+	PaymentRefundDue PaymentStatus = "refund_due"
+)
+
+// Payment is one attempt to collect an order's total through the payment
+// provider. Only pending payments change status; the first paid one marks
+// the order paid. An order has at most one pending payment.
+type Payment struct {
+	ID          int64         `json:"id"`
+	StoreID     int64         `json:"store_id" gorm:"index"`
+	OrderID     int64         `json:"order_id" gorm:"index"`
+	Provider    string        `json:"provider" gorm:"size:20"`
+	Reference   string        `json:"reference" gorm:"size:64;uniqueIndex"`    // ours, sent to the provider
+	ProviderRef string        `json:"provider_ref" gorm:"size:64;uniqueIndex"` // the provider's id
+	Method      string        `json:"method" gorm:"size:10"`                   // qris or va
+	Amount      float64       `json:"amount"`
+	Status      PaymentStatus `json:"status" gorm:"size:10;index"`
+	QRString    string        `json:"qr_string,omitempty" gorm:"type:text"`
+	VANumber    string        `json:"va_number,omitempty" gorm:"size:32"`
+	PaymentURL  string        `json:"payment_url,omitempty"`
+	ExpiresAt   time.Time     `json:"expires_at"`
+	PaidAt      *time.Time    `json:"paid_at"`
+	CreatedAt   time.Time     `json:"created_at"`
+	UpdatedAt   time.Time     `json:"updated_at"`
+
+	// PendingOrderID is OrderID while the payment is pending and NULL after,
+	// so the unique index allows one pending payment per order
+	PendingOrderID *int64 `json:"-" gorm:"->;type:bigint AS (CASE WHEN status = 'pending' THEN order_id END) STORED;uniqueIndex"`
+}
+
+// PaymentEvent records a webhook delivery so a provider retrying the same
+// event does not apply it twice.
+type PaymentEvent struct {
+	ID        int64         `json:"id"`
+	PaymentID int64         `json:"payment_id" gorm:"index"`
+	Provider  string        `json:"provider" gorm:"size:20;uniqueIndex:idx_payment_events_provider_event"`
+	EventID   string        `json:"event_id" gorm:"size:64;uniqueIndex:idx_payment_events_provider_event"`
+	Status    PaymentStatus `json:"status" gorm:"size:10"`
+	CreatedAt time.Time     `json:"created_at"`
+}
+
+// CreatePaymentRequest is sent by the customer; the phone number must match
+// the one on the order.
+type CreatePaymentRequest struct {
+	Method        string `json:"method" validate:"required,oneof=qris va"`
+	CustomerPhone string `json:"customer_phone" validate:"required"`
+}
+
+// OrderQRIS is a dynamic QRIS code for paying one order straight into the
+// store's own QRIS merchant account.
+type OrderQRIS struct {
+	OrderID  int64   `json:"order_id"`
+	Amount   float64 `json:"amount"`
+	QRString string  `json:"qr_string"`
+	QRImage  string  `json:"qr_image"` // PNG data URI
+}
+
+type OrderQRISRequest struct {
+	CustomerPhone string `json:"customer_phone" validate:"required"`
+}
+
+type SimulatePaymentRequest struct {
+	Status PaymentStatus `json:"status" validate:"required,oneof=paid failed expired"`
+}
@@ -1,37 +1,311 @@
 package model
 
-import "time"
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type Product struct {
 	ID          int64     `json:"id"`
+	SKU         string    `json:"sku" gorm:"size:64;index"`
 	Name        string    `json:"name"`
 	Description string    `json:"description"`
 	Price       float64   `json:"price"`
 	Image       string    `json:"image"`
-	Category    string    `json:"category"`
+	ImageMedium string    `json:"image_medium"`
+	ImageThumb  string    `json:"image_thumb"`
+	Category    string    `json:"category"` // name of the linked category
+	CategoryID  *int64    `json:"category_id" gorm:"index"`
 	Stock       int       `json:"stock"`
-	StoreID     int64     `json:"store_id" gorm:"index"`
+	StoreID     int64     `json:"store_id" gorm:"index;uniqueIndex:idx_products_store_sku,priority:1"`
 	IsActive    bool      `json:"is_active"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
+
+	// DeletedAt is set while the product sits in the archive
+	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+
+	// SKUKey is the SKU of live products and NULL otherwise, so SKUs are
+	// unique per store while empty ones and archived products may repeat
+	SKUKey *string `json:"-" gorm:"->;type:varchar(64) AS (CASE WHEN sku <> '' AND deleted_at IS NULL THEN sku END) STORED;uniqueIndex:idx_products_store_sku,priority:2"`
+
+	// TrackStock is off for products sold without counting stock: they can
+	// always be ordered and orders take nothing from them
+	TrackStock bool `json:"track_stock"`
+
+	// ReorderThreshold raises a low-stock alert once stock drops to it;
+	// zero turns alerts off
+	ReorderThreshold int `json:"reorder_threshold"`
+
+	// Weight in grams of one unit, used for courier shipping rates
+	Weight int `json:"weight"`
+
+	// Set by PriceList.Apply while a sale runs: Price then holds the sale
+	// price and OriginalPrice the regular one to strike through
+	OriginalPrice *float64   `json:"original_price,omitempty" gorm:"-"`
+	SaleEndsAt    *time.Time `json:"sale_ends_at,omitempty" gorm:"-"`
+
+	Options  []ProductOption   `json:"options" gorm:"serializer:json"`
+	Images   []*ProductImage   `json:"images" gorm:"foreignKey:ProductID"`
+	Variants []*ProductVariant `json:"variants" gorm:"foreignKey:ProductID"`
+}
+
+// InStock reports whether anything of the product can be ordered. Products
+// with variants are in stock when one of their active variants is, and
+// products that do not track stock always are.
+func (p *Product) InStock() bool {
+	if !p.TrackStock {
+		return true
+	}
+
+	hasVariants := false
+	for _, variant := range p.Variants {
+		if !variant.IsActive {
+			continue
+		}
+		hasVariants = true
+		if variant.Stock > 0 {
+			return true
+		}
+	}
+	return !hasVariants && p.Stock > 0
+}
+
+// LowStockItems lists what has fallen to or below the reorder threshold:
+// each active variant, or the product itself when it has no active
+// variants.
+func (p *Product) LowStockItems() []*LowStockItem {
+	if p.ReorderThreshold <= 0 || !p.TrackStock {
+		return nil
+	}
+
+	var items []*LowStockItem
+	hasVariants := false
+	for _, variant := range p.Variants {
+		if !variant.IsActive {
+			continue
+		}
+		hasVariants = true
+		if variant.Stock <= p.ReorderThreshold {
+			id := variant.ID
+			items = append(items, &LowStockItem{
+				StoreID:   p.StoreID,
+				ProductID: p.ID,
+				VariantID: &id,
+				Name:      p.Name,
+				Variant:   variant.Label(p.Options),
+				SKU:       variant.SKU,
+				Stock:     variant.Stock,
+				Threshold: p.ReorderThreshold,
+			})
+		}
+	}
+	if !hasVariants && p.Stock <= p.ReorderThreshold {
+		items = append(items, &LowStockItem{
+			StoreID:   p.StoreID,
+			ProductID: p.ID,
+			Name:      p.Name,
+			SKU:       p.SKU,
+			Stock:     p.Stock,
+			Threshold: p.ReorderThreshold,
+		})
+	}
+	return items
+}
+
+// PriceRange returns the lowest and highest price a customer can pay,
+// taking active variants into account.
+func (p *Product) PriceRange() (float64, float64) {
+	low, high := p.Price, p.Price
+	first := true
+	for _, variant := range p.Variants {
+		if !variant.IsActive {
+			continue
+		}
+		if first {
+			low, high = variant.Price, variant.Price
+			first = false
+		}
+		low = min(low, variant.Price)
+		high = max(high, variant.Price)
+	}
+	return low, high
+}
+
+// ProductOption defines one axis a product varies on, e.g. Ukuran with
+// values S, M and L.
+type ProductOption struct {
+	Name   string   `json:"name" validate:"required,max=50"`
+	Values []string `json:"values" validate:"required,min=1,dive,required,max=50"`
+}
+
+// ProductVariant is a sellable combination of option values with its own
+// SKU, price and stock.
+type ProductVariant struct {
+	ID        int64             `json:"id"`
+	ProductID int64             `json:"product_id" gorm:"index"`
+	StoreID   int64             `json:"-" gorm:"uniqueIndex:idx_product_variants_store_sku,priority:1"`
+	SKU       string            `json:"sku" gorm:"size:64;index;uniqueIndex:idx_product_variants_store_sku,priority:2"`
+	Options   map[string]string `json:"options" gorm:"serializer:json"`
+	Price     float64           `json:"price"`
+	Stock     int               `json:"stock"`
+	IsActive  bool              `json:"is_active"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+
+	OriginalPrice *float64 `json:"original_price,omitempty" gorm:"-"` // see Product.OriginalPrice
+}
+
+// InStock reports whether the variant can be ordered. trackStock is its
+// product's TrackStock; variants of products that do not track stock always
+// can.
+func (v *ProductVariant) InStock(trackStock bool) bool {
+	return !trackStock || v.Stock > 0
+}
+
+// Label renders the chosen option values in the product's option order,
+// e.g. "Ukuran: L, Warna: Merah".
+func (v *ProductVariant) Label(options []ProductOption) string {
+	parts := make([]string, 0, len(options))
+	for _, option := range options {
+		if value, ok := v.Options[option.Name]; ok {
+			parts = append(parts, option.Name+": "+value)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// MatchesOptions reports whether the variant picks exactly one valid value
+// for every option of the product.
+func (v *ProductVariant) MatchesOptions(options []ProductOption) bool {
+	if len(v.Options) != len(options) {
+		return false
+	}
+	for _, option := range options {
+		value, ok := v.Options[option.Name]
+		if !ok {
+			return false
+		}
+		valid := false
+		for _, allowed := range option.Values {
+			if allowed == value {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return false
+		}
+	}
+	return true
+}
+
+// ProductImage is one entry of a product's gallery. The primary image is
+// mirrored into Product.Image so clients that only read a single image keep
+// working.
+type ProductImage struct {
+	ID        int64     `json:"id"`
+	ProductID int64     `json:"product_id" gorm:"index"`
+	URL       string    `json:"url"`
+	MediumURL string    `json:"medium_url"`
+	ThumbURL  string    `json:"thumb_url"`
+	Keys      string    `json:"-"` // comma separated blob keys of the stored variants
+	AltText   string    `json:"alt_text"`
+	Position  int       `json:"position"`
+	IsPrimary bool      `json:"is_primary"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
 }
 
 type CreateProductRequest struct {
+	SKU         string  `json:"sku" validate:"max=64"`
 	Name        string  `json:"name" validate:"required"`
 	Description string  `json:"description"`
 	Price       float64 `json:"price" validate:"required,min=0"`
 	Image       string  `json:"image"`
-	Category    string  `json:"category"`
+	Category    string  `json:"category"` // resolved by name or slug when category_id is empty
+	CategoryID  *int64  `json:"category_id"`
 	Stock       int     `json:"stock" validate:"min=0"`
+	TrackStock  *bool   `json:"track_stock"` // defaults to true
+
+	ReorderThreshold int `json:"reorder_threshold" validate:"min=0"`
+	Weight           int `json:"weight" validate:"min=0"`
+
+	Options []ProductOption `json:"options" validate:"max=3,dive"`
 }
 
 type UpdateProductRequest struct {
 	ID          int64
+	SKU         string  `json:"sku" validate:"max=64"`
 	Name        string  `json:"name" validate:"required"`
 	Description string  `json:"description"`
 	Price       float64 `json:"price" validate:"required,min=0"`
 	Image       string  `json:"image"`
-	Category    string  `json:"category"`
+	Category    string  `json:"category"` // resolved by name or slug when category_id is empty
+	CategoryID  *int64  `json:"category_id"`
 	Stock       int     `json:"stock" validate:"min=0"`
+	TrackStock  *bool   `json:"track_stock"` // left as is when omitted
 	IsActive    bool    `json:"is_active"`
-}
\ No newline at end of file
+
+	ReorderThreshold int `json:"reorder_threshold" validate:"min=0"`
+	Weight           int `json:"weight" validate:"min=0"`
+
+	Options []ProductOption `json:"options" validate:"max=3,dive"`
+}
+
+type UpdateProductImageRequest struct {
+	ProductID int64
+	ID        int64
+	AltText   string `json:"alt_text" validate:"max=255"`
+	IsPrimary bool   `json:"is_primary"`
+}
+
+type ReorderProductImagesRequest struct {
+	ProductID int64
+	ImageIDs  []int64 `json:"image_ids" validate:"required,min=1"`
+}
+
+type CreateProductVariantRequest struct {
+	ProductID int64
+	SKU       string            `json:"sku" validate:"required,max=64"`
+	Options   map[string]string `json:"options" validate:"required"`
+	Price     float64           `json:"price" validate:"required,min=0"`
+	Stock     int               `json:"stock" validate:"min=0"`
+}
+
+type UpdateProductVariantRequest struct {
+	ProductID int64
+	ID        int64
+	SKU       string            `json:"sku" validate:"required,max=64"`
+	Options   map[string]string `json:"options" validate:"required"`
+	Price     float64           `json:"price" validate:"required,min=0"`
+	Stock     int               `json:"stock" validate:"min=0"`
+	IsActive  bool              `json:"is_active"`
+}
+
+type SearchProductsRequest struct {
+	Query    string   `validate:"required,max=100"`
+	MinPrice *float64 `validate:"omitempty,min=0"`
+	MaxPrice *float64 `validate:"omitempty,min=0"`
+	InStock  bool
+	Limit    int `validate:"min=0,max=100"`
+}
+
+// ImportProductsResult summarizes a spreadsheet import. Nothing is saved
+// when Errors is not empty or the import is a dry run; Created and Updated
+// then count what would have happened.
+type ImportProductsResult struct {
+	DryRun  bool              `json:"dry_run"`
+	Rows    int               `json:"rows"`
+	Created int               `json:"created"`
+	Updated int               `json:"updated"`
+	Errors  []*ImportRowError `json:"errors"`
+}
+
+type ImportRowError struct {
+	Row    int    `json:"row"` // spreadsheet row number, the header is row 1
+	Column string `json:"column,omitempty"`
+	Error  string `json:"error"`
+}
@@ -0,0 +1,79 @@
+// internal/model/qrcode.go
+package model
+
+import "time"
+
+// QR code formats
+const (
+	QRCodePNG = "png"
+	QRCodeSVG = "svg"
+	QRCodePDF = "pdf" // a printable A5 sheet with the store name and Text
+)
+
+// QRCodeOptions are the query parameters of the QR code endpoints. Colors
+// are #rgb or #rrggbb, the "#" may be left out.
+type QRCodeOptions struct {
+	Size       int    `validate:"omitempty,min=64,max=2048"`
+	Level      string `validate:"omitempty,oneof=L M Q H"`
+	Foreground string
+	Background string
+	Format     string `validate:"omitempty,oneof=png svg pdf"`
+	Logo       bool   // put the store logo in the middle, forces level H
+	Text       string `validate:"max=80"` // call to action printed under a PDF code
+}
+
+// QRCode is a printed code that goes through the short link /q/{Code}, so
+// each scan is counted before the customer lands on the catalog. It opens
+// the whole catalog, or one product when ProductID is set, and a
+// TableNumber is filled into the order form.
+type QRCode struct {
+	ID          int64     `json:"id"`
+	StoreID     int64     `json:"store_id" gorm:"index"`
+	Code        string    `json:"code" gorm:"size:16;uniqueIndex"`
+	Name        string    `json:"name" gorm:"size:100"`  // for the owner, e.g. "Meja 5" or "Flyer Januari"
+	Source      string    `json:"source" gorm:"size:50"` // where it is placed, e.g. "table" or "flyer"
+	ProductID   *int64    `json:"product_id"`
+	TableNumber string    `json:"table_number" gorm:"size:20"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+
+	URL    string `json:"url" gorm:"-"` // the short link, filled in by the handler
+	Scans  int64  `json:"scans" gorm:"-"`
+	Orders int64  `json:"orders" gorm:"-"`
+}
+
+// QRScan is one visit through a QR code's short link. Source is the code's
+// own unless the link carried ?src=, so one code can go on several flyers.
+type QRScan struct {
+	ID        int64     `json:"id"`
+	QRCodeID  int64     `json:"qr_code_id" gorm:"index:idx_qr_scans_code_time"`
+	StoreID   int64     `json:"store_id" gorm:"index"`
+	Source    string    `json:"source" gorm:"size:50"`
+	UserAgent string    `json:"user_agent" gorm:"size:255"`
+	ScannedAt time.Time `json:"scanned_at" gorm:"index:idx_qr_scans_code_time"`
+}
+
+type CreateQRCodeRequest struct {
+	Name        string `json:"name" validate:"required,max=100"`
+	Source      string `json:"source" validate:"max=50"`
+	ProductID   *int64 `json:"product_id"`
+	TableNumber string `json:"table_number" validate:"max=20"`
+}
+
+// QRCodeStats sums up a code's scans and the orders placed through it over
+// the last Days days. Cancelled orders are left out.
+type QRCodeStats struct {
+	QRCode   *QRCode        `json:"qr_code"`
+	Days     int            `json:"days"`
+	Scans    int64          `json:"scans"`
+	Orders   int64          `json:"orders"`
+	Revenue  float64        `json:"revenue"` // total of those orders
+	ByDay    []*QRScanCount `json:"by_day"`
+	BySource []*QRScanCount `json:"by_source"`
+	ByDevice []*QRScanCount `json:"by_device"` // android, ios, desktop or other, from the user agent
+}
+
+type QRScanCount struct {
+	Key   string `json:"key"`
+	Scans int64  `json:"scans"`
+}
@@ -0,0 +1,147 @@
+// internal/model/sale.go
+package model
+
+import (
+	"math"
+	"time"
+)
+
+type SaleType string
+
+const (
+	SalePercent SaleType = "percent" // Value percent off
+	SaleAmount  SaleType = "amount"  // Value rupiah off
+)
+
+// Sale discounts one product, or every product of a category and its
+// subcategories, from StartsAt until EndsAt.
+type Sale struct {
+	ID         int64     `json:"id"`
+	StoreID    int64     `json:"store_id" gorm:"index"`
+	Name       string    `json:"name"`
+	ProductID  *int64    `json:"product_id" gorm:"index"`
+	CategoryID *int64    `json:"category_id" gorm:"index"`
+	Type       SaleType  `json:"type" gorm:"size:10"`
+	Value      float64   `json:"value"`
+	StartsAt   time.Time `json:"starts_at" gorm:"index"`
+	EndsAt     time.Time `json:"ends_at" gorm:"index"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// Discount returns price after the sale, never below zero.
+func (s *Sale) Discount(price float64) float64 {
+	switch s.Type {
+	case SalePercent:
+		price -= price * s.Value / 100
+	case SaleAmount:
+		price -= s.Value
+	}
+	return max(math.Round(price*100)/100, 0)
+}
+
+type CreateSaleRequest struct {
+	Name       string    `json:"name" validate:"required,max=100"`
+	ProductID  *int64    `json:"product_id" validate:"required_without=CategoryID,excluded_with=CategoryID"`
+	CategoryID *int64    `json:"category_id"`
+	Type       SaleType  `json:"type" validate:"required,oneof=percent amount"`
+	Value      float64   `json:"value" validate:"gt=0"`
+	StartsAt   time.Time `json:"starts_at" validate:"required"`
+	EndsAt     time.Time `json:"ends_at" validate:"required,gtfield=StartsAt"`
+}
+
+type UpdateSaleRequest struct {
+	ID         int64
+	Name       string    `json:"name" validate:"required,max=100"`
+	ProductID  *int64    `json:"product_id" validate:"required_without=CategoryID,excluded_with=CategoryID"`
+	CategoryID *int64    `json:"category_id"`
+	Type       SaleType  `json:"type" validate:"required,oneof=percent amount"`
+	Value      float64   `json:"value" validate:"gt=0"`
+	StartsAt   time.Time `json:"starts_at" validate:"required"`
+	EndsAt     time.Time `json:"ends_at" validate:"required,gtfield=StartsAt"`
+}
+
+// PriceList resolves the price a customer pays at one point in time. It is
+// the only place sale prices are worked out; the catalog and order pricing
+// both go through Apply.
+type PriceList struct {
+	sales   []*Sale
+	parents map[int64]*int64 // category id to parent id
+}
+
+// NewPriceList takes the sales running at the moment of pricing along with
+// the store's categories, which are needed to reach subcategories.
+func NewPriceList(sales []*Sale, categories []*Category) *PriceList {
+	parents := make(map[int64]*int64, len(categories))
+	for _, category := range categories {
+		parents[category.ID] = category.ParentID
+	}
+	return &PriceList{sales: sales, parents: parents}
+}
+
+// Apply sets each product and variant to its lowest sale price, keeping
+// the regular price in OriginalPrice. Products without a running sale are
+// left as they are.
+func (l *PriceList) Apply(products ...*Product) {
+	for _, product := range products {
+		var sales []*Sale
+		for _, sale := range l.sales {
+			if l.covers(sale, product) {
+				sales = append(sales, sale)
+			}
+		}
+		if len(sales) == 0 {
+			continue
+		}
+
+		price, sale := bestSale(product.Price, sales)
+		if sale != nil {
+			original := product.Price
+			product.OriginalPrice = &original
+			product.Price = price
+			product.SaleEndsAt = &sale.EndsAt
+		}
+
+		for _, variant := range product.Variants {
+			price, sale := bestSale(variant.Price, sales)
+			if sale == nil {
+				continue
+			}
+			original := variant.Price
+			variant.OriginalPrice = &original
+			variant.Price = price
+			if product.SaleEndsAt == nil || sale.EndsAt.Before(*product.SaleEndsAt) {
+				product.SaleEndsAt = &sale.EndsAt
+			}
+		}
+	}
+}
+
+func (l *PriceList) covers(sale *Sale, product *Product) bool {
+	if sale.ProductID != nil {
+		return *sale.ProductID == product.ID
+	}
+	// Walk up from the product's category; the depth guard stops on a
+	// corrupt tree
+	id := product.CategoryID
+	for depth := 0; id != nil && depth < 10; depth++ {
+		if sale.CategoryID != nil && *id == *sale.CategoryID {
+			return true
+		}
+		id = l.parents[*id]
+	}
+	return false
+}
+
+// bestSale returns the lowest price any one of the sales gives, and the
+// sale giving it; sales never stack. No sale when none lowers the price.
+func bestSale(price float64, sales []*Sale) (float64, *Sale) {
+	lowest := price
+	var best *Sale
+	for _, sale := range sales {
+		if discounted := sale.Discount(price); discounted < lowest {
+			lowest, best = discounted, sale
+		}
+	}
+	return lowest, best
+}
@@ -0,0 +1,104 @@
+// internal/model/shipping.go
+package model
+
+import (
+	"math"
+	"time"
+)
+
+type ShippingMethodType string
+
+const (
+	ShippingPickup  ShippingMethodType = "pickup"  // customer collects, no fee
+	ShippingFlat    ShippingMethodType = "flat"    // own courier, Fee per order
+	ShippingPerKm   ShippingMethodType = "per_km"  // own courier, Fee plus PerKmFee per started km
+	ShippingCourier ShippingMethodType = "courier" // carrier, priced by WeightRates or a rate lookup
+)
+
+// ShippingMethod is a delivery option a store offers its customers.
+type ShippingMethod struct {
+	ID            int64              `json:"id"`
+	StoreID       int64              `json:"store_id" gorm:"index"`
+	Name          string             `json:"name"`
+	Type          ShippingMethodType `json:"type" gorm:"size:10"`
+	Fee           float64            `json:"fee"`
+	PerKmFee      float64            `json:"per_km_fee"`
+	MaxDistanceKm float64            `json:"max_distance_km"` // zero means no limit
+	Carrier       string             `json:"carrier" gorm:"size:20"`
+	Service       string             `json:"service" gorm:"size:20"`
+	WeightRates   []WeightRate       `json:"weight_rates" gorm:"serializer:json"`
+	Position      int                `json:"position"`
+	IsActive      bool               `json:"is_active"`
+	CreatedAt     time.Time          `json:"created_at"`
+	UpdatedAt     time.Time          `json:"updated_at"`
+}
+
+// WeightRate charges Fee for parcels up to MaxWeight grams. A courier
+// method's rates are kept sorted by MaxWeight.
+type WeightRate struct {
+	MaxWeight int     `json:"max_weight" validate:"gt=0"`
+	Fee       float64 `json:"fee" validate:"min=0"`
+}
+
+// ShippingAddress is where the customer wants the order delivered.
+// Coordinates are needed for per-km methods, the postal code for couriers.
+type ShippingAddress struct {
+	Address    string   `json:"address" validate:"required,max=500"`
+	PostalCode string   `json:"postal_code" validate:"omitempty,numeric,max=10"`
+	Latitude   *float64 `json:"latitude" validate:"required_with=Longitude,omitempty,latitude"`
+	Longitude  *float64 `json:"longitude" validate:"required_with=Latitude,omitempty,longitude"`
+}
+
+// ShippingQuote is the fee of one method for a given order, or why the
+// method cannot deliver it.
+type ShippingQuote struct {
+	Method *ShippingMethod `json:"method"`
+	Fee    float64         `json:"fee"`
+	Error  string          `json:"error,omitempty"`
+}
+
+type CreateShippingMethodRequest struct {
+	Name          string             `json:"name" validate:"required,max=100"`
+	Type          ShippingMethodType `json:"type" validate:"required,oneof=pickup flat per_km courier"`
+	Fee           float64            `json:"fee" validate:"min=0"`
+	PerKmFee      float64            `json:"per_km_fee" validate:"min=0"`
+	MaxDistanceKm float64            `json:"max_distance_km" validate:"min=0"`
+	Carrier       string             `json:"carrier" validate:"max=20"`
+	Service       string             `json:"service" validate:"max=20"`
+	WeightRates   []WeightRate       `json:"weight_rates" validate:"max=50,dive"`
+	Position      int                `json:"position"`
+	IsActive      bool               `json:"is_active"`
+}
+
+type UpdateShippingMethodRequest struct {
+	ID            int64
+	Name          string             `json:"name" validate:"required,max=100"`
+	Type          ShippingMethodType `json:"type" validate:"required,oneof=pickup flat per_km courier"`
+	Fee           float64            `json:"fee" validate:"min=0"`
+	PerKmFee      float64            `json:"per_km_fee" validate:"min=0"`
+	MaxDistanceKm float64            `json:"max_distance_km" validate:"min=0"`
+	Carrier       string             `json:"carrier" validate:"max=20"`
+	Service       string             `json:"service" validate:"max=20"`
+	WeightRates   []WeightRate       `json:"weight_rates" validate:"max=50,dive"`
+	Position      int                `json:"position"`
+	IsActive      bool               `json:"is_active"`
+}
+
+// ShippingQuoteRequest asks what each delivery option would cost for a
+// basket.
+type ShippingQuoteRequest struct {
+	Items           []OrderItem      `json:"items" validate:"required,min=1,dive"`
+	ShippingAddress *ShippingAddress `json:"shipping_address"`
+}
+
+// DistanceKm returns the great-circle distance between two points.
+func DistanceKm(lat1, lng1, lat2, lng2 float64) float64 {
+	const earthRadiusKm = 6371
+	rad := func(deg float64) float64 { return deg * math.Pi / 180 }
+
+	dLat := rad(lat2 - lat1)
+	dLng := rad(lng2 - lng1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(rad(lat1))*math.Cos(rad(lat2))*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return earthRadiusKm * 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+}
@@ -0,0 +1,78 @@
+// internal/model/stock.go
+package model
+
+import "time"
+
+type StockMovementType string
+
+const (
+	StockRestock      StockMovementType = "restock"
+	StockSale         StockMovementType = "sale"
+	StockAdjustment   StockMovementType = "adjustment"
+	StockReturn       StockMovementType = "return"
+	StockCancellation StockMovementType = "cancellation"
+)
+
+// StockMovement is one entry of the inventory ledger. Product.Stock and
+// ProductVariant.Stock are running balances of these entries; movements
+// with a VariantID count towards the variant, the others towards the
+// product itself.
+type StockMovement struct {
+	ID        int64             `json:"id"`
+	StoreID   int64             `json:"store_id" gorm:"index"`
+	ProductID int64             `json:"product_id" gorm:"index"`
+	VariantID *int64            `json:"variant_id"`
+	Type      StockMovementType `json:"type" gorm:"size:20"`
+	Quantity  int               `json:"quantity"` // signed change, negative when stock leaves
+	Balance   int               `json:"balance"`  // stock right after this movement
+	Reason    string            `json:"reason"`
+	UserID    *int64            `json:"user_id"` // owner who made it, empty for customer orders
+	OrderID   *int64            `json:"order_id" gorm:"index"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+// CreateStockMovementRequest records a manual movement. Restocks and
+// returns add stock; adjustments may go either way.
+type CreateStockMovementRequest struct {
+	ProductID int64
+	VariantID *int64            `json:"variant_id"`
+	Type      StockMovementType `json:"type" validate:"required,oneof=restock adjustment return"`
+	Quantity  int               `json:"quantity" validate:"required"`
+	Reason    string            `json:"reason" validate:"required,max=255"`
+}
+
+// StockDrift reports a balance that disagreed with the ledger and was
+// corrected by a reconcile.
+type StockDrift struct {
+	ProductID int64  `json:"product_id"`
+	VariantID *int64 `json:"variant_id"`
+	Stock     int    `json:"stock"`  // balance before the correction
+	Ledger    int    `json:"ledger"` // sum of movements, the new balance
+}
+
+// LowStockItem is a product, or one variant of it, at or below its
+// reorder threshold.
+type LowStockItem struct {
+	StoreID   int64  `json:"-"`
+	ProductID int64  `json:"product_id"`
+	VariantID *int64 `json:"variant_id"`
+	Name      string `json:"name"`
+	Variant   string `json:"variant,omitempty"`
+	SKU       string `json:"sku"`
+	Stock     int    `json:"stock"`
+	Threshold int    `json:"reorder_threshold"`
+}
+
+// StockAlert remembers that an owner was told about a low-stock item, so
+// the alert job only notifies when stock crosses the threshold. It is
+// resolved once stock climbs back above it.
+type StockAlert struct {
+	ID         int64      `json:"id"`
+	StoreID    int64      `json:"store_id" gorm:"index"`
+	ProductID  int64      `json:"product_id" gorm:"index"`
+	VariantID  *int64     `json:"variant_id"`
+	Stock      int        `json:"stock"`
+	Threshold  int        `json:"reorder_threshold"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ResolvedAt *time.Time `json:"resolved_at" gorm:"index"`
+}
@@ -8,6 +8,8 @@ type Store struct {
 	Name        string    `json:"name"`
 	Description string    `json:"description"`
 	Logo        string    `json:"logo"`
+	LogoThumb   string    `json:"logo_thumb"`
+	LogoKeys    string    `json:"-"` // comma separated blob keys of the uploaded logo
 	Address     string    `json:"address"`
 	Phone       string    `json:"phone"`
 	WhatsApp    string    `json:"whatsapp"`
@@ -15,6 +17,18 @@ type Store struct {
 	IsActive    bool      `json:"is_active"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
+
+	// HideOutOfStock keeps products nobody can order off the public catalog
+	HideOutOfStock bool `json:"hide_out_of_stock"`
+
+	// Where orders ship from: the postal code for carrier rates, the
+	// coordinates for distance based delivery fees
+	PostalCode string   `json:"postal_code" gorm:"size:10"`
+	Latitude   *float64 `json:"latitude"`
+	Longitude  *float64 `json:"longitude"`
+
+	// Static QRIS merchant payload, used to build a QRIS code per order
+	QRISPayload string `json:"qris_payload" gorm:"type:text"`
 }
 
 type CreateStoreRequest struct {
@@ -35,4 +49,12 @@ type UpdateStoreRequest struct {
 	Phone       string `json:"phone"`
 	WhatsApp    string `json:"whatsapp" validate:"required"`
 	IsActive    bool   `json:"is_active"`
-}
\ No newline at end of file
+
+	HideOutOfStock bool `json:"hide_out_of_stock"`
+
+	PostalCode string   `json:"postal_code" validate:"omitempty,numeric,max=10"`
+	Latitude   *float64 `json:"latitude" validate:"required_with=Longitude,omitempty,latitude"`
+	Longitude  *float64 `json:"longitude" validate:"required_with=Latitude,omitempty,longitude"`
+
+	QRISPayload string `json:"qris_payload" validate:"max=512"`
+}
@@ -5,17 +5,54 @@ import (
 	"time"
 
 	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 )
 
 type User struct {
-	ID        int64     `json:"id"`
-	Name      string    `json:"name"`
-	Email     string    `json:"email"`
-	Password  string    `json:"-"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID            int64          `json:"id"`
+	Name          string         `json:"name"`
+	Email         string         `json:"email"`
+	EmailVerified bool           `json:"email_verified"`
+	Password      string         `json:"-"`
+	TokenVersion  int64          `json:"-"`                // bumped to revoke every issued token
+	EmailNonce    string         `json:"-" gorm:"size:32"` // the pending email change, empty when there is none
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt `json:"-" gorm:"index"`
 }
 
+type UpdateProfileRequest struct {
+	Name string `json:"name" validate:"required"`
+}
+
+type ChangePasswordRequest struct {
+	OldPassword string `json:"old_password" validate:"required"`
+	NewPassword string `json:"new_password" validate:"required,min=5,nefield=OldPassword"`
+}
+
+type ChangeEmailRequest struct {
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" validate:"required"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+type DeleteAccountRequest struct {
+	Password string `json:"password" validate:"required"`
+}
+
+// RetentionPolicy decides what is kept when an account is deleted.
+// Stores, products and websites are always anonymized and taken offline;
+// orders are either kept for bookkeeping with customer details redacted
+// or removed together with the account.
+type RetentionPolicy struct {
+	KeepOrders bool
+}
+
+var DefaultRetentionPolicy = RetentionPolicy{KeepOrders: true}
+
 func (u *User) GeneratePassword(plainPassword string) error {
 	hashedPasswordByte, err := bcrypt.GenerateFromPassword([]byte(plainPassword), 4)
 	if err != nil {
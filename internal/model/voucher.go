@@ -0,0 +1,139 @@
+// internal/model/voucher.go
+package model
+
+import (
+	"math"
+	"slices"
+	"strings"
+	"time"
+)
+
+type VoucherType string
+
+const (
+	VoucherPercent VoucherType = "percent" // Value percent off the eligible items
+	VoucherAmount  VoucherType = "amount"  // Value rupiah off the eligible items
+)
+
+// Voucher is a discount code of one store. Zero limits mean unlimited, and
+// empty restrictions mean every product qualifies.
+type Voucher struct {
+	ID            int64       `json:"id"`
+	StoreID       int64       `json:"store_id" gorm:"uniqueIndex:idx_vouchers_store_code"`
+	Code          string      `json:"code" gorm:"size:32;uniqueIndex:idx_vouchers_store_code"`
+	Type          VoucherType `json:"type" gorm:"size:10"`
+	Value         float64     `json:"value"`
+	MinOrderTotal float64     `json:"min_order_total"`
+	UsageLimit    int         `json:"usage_limit"`     // redemptions of the code in total
+	PerPhoneLimit int         `json:"per_phone_limit"` // redemptions per customer phone number
+	UsedCount     int         `json:"used_count"`
+	StartsAt      *time.Time  `json:"starts_at"`
+	EndsAt        *time.Time  `json:"ends_at"`
+	ProductIDs    []int64     `json:"product_ids" gorm:"serializer:json"`
+	CategoryIDs   []int64     `json:"category_ids" gorm:"serializer:json"` // subcategories included
+	IsActive      bool        `json:"is_active"`
+	CreatedAt     time.Time   `json:"created_at"`
+	UpdatedAt     time.Time   `json:"updated_at"`
+}
+
+// Running reports whether the voucher can be used at the given moment.
+func (v *Voucher) Running(at time.Time) bool {
+	if !v.IsActive {
+		return false
+	}
+	if v.StartsAt != nil && at.Before(*v.StartsAt) {
+		return false
+	}
+	return v.EndsAt == nil || at.Before(*v.EndsAt)
+}
+
+// Restricted reports whether only some products qualify.
+func (v *Voucher) Restricted() bool {
+	return len(v.ProductIDs) > 0 || len(v.CategoryIDs) > 0
+}
+
+// Covers reports whether a product qualifies, given the ids of its category
+// and that category's ancestors.
+func (v *Voucher) Covers(productID int64, categoryIDs []int64) bool {
+	if !v.Restricted() || slices.Contains(v.ProductIDs, productID) {
+		return true
+	}
+	for _, id := range categoryIDs {
+		if slices.Contains(v.CategoryIDs, id) {
+			return true
+		}
+	}
+	return false
+}
+
+// Discount returns how much comes off an eligible amount; never more than
+// the amount itself.
+func (v *Voucher) Discount(eligible float64) float64 {
+	discount := v.Value
+	if v.Type == VoucherPercent {
+		discount = eligible * v.Value / 100
+	}
+	return math.Round(min(discount, eligible)*100) / 100
+}
+
+// VoucherRedemption records one use of a voucher by an order. It is what
+// the usage limits count.
+type VoucherRedemption struct {
+	ID            int64     `json:"id"`
+	VoucherID     int64     `json:"voucher_id" gorm:"index:idx_voucher_redemptions_phone"`
+	StoreID       int64     `json:"store_id" gorm:"index"`
+	OrderID       int64     `json:"order_id" gorm:"index"`
+	CustomerPhone string    `json:"customer_phone" gorm:"size:32;index:idx_voucher_redemptions_phone"`
+	Discount      float64   `json:"discount"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// NormalizeVoucherCode makes codes case-insensitive.
+func NormalizeVoucherCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// NormalizePhone reduces a phone number to digits in international form,
+// so 0812-3456-7890 and +62 812 3456 7890 count as the same customer.
+func NormalizePhone(phone string) string {
+	var b strings.Builder
+	for _, r := range phone {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	digits := b.String()
+	if strings.HasPrefix(digits, "0") {
+		digits = "62" + digits[1:]
+	}
+	return digits
+}
+
+type CreateVoucherRequest struct {
+	Code          string      `json:"code" validate:"required,alphanum,max=32"`
+	Type          VoucherType `json:"type" validate:"required,oneof=percent amount"`
+	Value         float64     `json:"value" validate:"gt=0"`
+	MinOrderTotal float64     `json:"min_order_total" validate:"min=0"`
+	UsageLimit    int         `json:"usage_limit" validate:"min=0"`
+	PerPhoneLimit int         `json:"per_phone_limit" validate:"min=0"`
+	StartsAt      *time.Time  `json:"starts_at"`
+	EndsAt        *time.Time  `json:"ends_at"`
+	ProductIDs    []int64     `json:"product_ids" validate:"max=100"`
+	CategoryIDs   []int64     `json:"category_ids" validate:"max=100"`
+	IsActive      bool        `json:"is_active"`
+}
+
+type UpdateVoucherRequest struct {
+	ID            int64
+	Code          string      `json:"code" validate:"required,alphanum,max=32"`
+	Type          VoucherType `json:"type" validate:"required,oneof=percent amount"`
+	Value         float64     `json:"value" validate:"gt=0"`
+	MinOrderTotal float64     `json:"min_order_total" validate:"min=0"`
+	UsageLimit    int         `json:"usage_limit" validate:"min=0"`
+	PerPhoneLimit int         `json:"per_phone_limit" validate:"min=0"`
+	StartsAt      *time.Time  `json:"starts_at"`
+	EndsAt        *time.Time  `json:"ends_at"`
+	ProductIDs    []int64     `json:"product_ids" validate:"max=100"`
+	CategoryIDs   []int64     `json:"category_ids" validate:"max=100"`
+	IsActive      bool        `json:"is_active"`
+}
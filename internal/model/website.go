@@ -4,29 +4,99 @@ package model
 import "time"
 
 type Website struct {
-	ID          int64     `json:"id"`
-	StoreID     int64     `json:"store_id" gorm:"index"`
-	Template    string    `json:"template"`
-	CustomCSS   string    `json:"custom_css"`
-	CustomHTML  string    `json:"custom_html"`
-	Domain      string    `json:"domain"`
-	IsPublished bool      `json:"is_published"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID          int64             `json:"id"`
+	StoreID     int64             `json:"store_id" gorm:"index"`
+	Template    string            `json:"template"`
+	Settings    map[string]string `json:"settings" gorm:"serializer:json"` // values for the template's settings
+	CustomCSS   string            `json:"custom_css"`
+	CustomHTML  string            `json:"custom_html"`
+	Domain      string            `json:"domain" gorm:"size:100;uniqueIndex"`
+	IsPublished bool              `json:"is_published"`
+	CreatedAt   time.Time         `json:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at"`
+
+	// What sanitizing took out of CustomHTML and CustomCSS on this save
+	Rejected []string `json:"rejected,omitempty" gorm:"-"`
+
+	// CustomDomain serves the catalog at the owner's own hostname once
+	// ownership is proven with a DNS TXT record carrying DomainToken
+	CustomDomain     string     `json:"custom_domain" gorm:"size:253;index"`
+	DomainToken      string     `json:"-" gorm:"size:64"`
+	DomainVerifiedAt *time.Time `json:"domain_verified_at"`
+
+	// VerifiedCustomDomain is CustomDomain once verified and NULL before,
+	// so the database lets only one website verify a hostname
+	VerifiedCustomDomain *string `json:"-" gorm:"->;type:varchar(253) AS (CASE WHEN domain_verified_at IS NOT NULL AND custom_domain <> '' THEN custom_domain END) STORED;uniqueIndex"`
+
+	// Template, Settings, CustomCSS and CustomHTML above are what
+	// LiveVersion holds. Edits are saved as newer revisions; DraftVersion is
+	// the latest one not published yet, 0 when there is none
+	LiveVersion      int              `json:"live_version"`
+	DraftVersion     int              `json:"draft_version"`
+	Draft            *WebsiteRevision `json:"draft,omitempty" gorm:"-"`
+	PreviewToken     string           `json:"-" gorm:"size:64;index"`
+	PreviewExpiresAt *time.Time       `json:"-"`
+}
+
+// WebsiteRevision is one saved version of a website's design.
+type WebsiteRevision struct {
+	ID           int64             `json:"id"`
+	WebsiteID    int64             `json:"website_id" gorm:"uniqueIndex:idx_website_version"`
+	Version      int               `json:"version" gorm:"uniqueIndex:idx_website_version"`
+	Template     string            `json:"template"`
+	Settings     map[string]string `json:"settings" gorm:"serializer:json"`
+	CustomCSS    string            `json:"custom_css"`
+	CustomHTML   string            `json:"custom_html"`
+	RestoredFrom int               `json:"restored_from,omitempty"` // the version a rollback copied
+	PublishedAt  *time.Time        `json:"published_at"`            // when it last went live
+	CreatedAt    time.Time         `json:"created_at"`
+
+	// "live" or "draft" when listed
+	Status string `json:"status,omitempty" gorm:"-"`
 }
 
 type CreateWebsiteRequest struct {
-	Template   string `json:"template" validate:"required"`
-	CustomCSS  string `json:"custom_css"`
-	CustomHTML string `json:"custom_html"`
-	Domain     string `json:"domain"`
+	Template   string            `json:"template" validate:"required"`
+	Settings   map[string]string `json:"settings"`
+	CustomCSS  string            `json:"custom_css"`
+	CustomHTML string            `json:"custom_html"`
+	Domain     string            `json:"domain" validate:"omitempty,max=63"`
 }
 
+// UpdateWebsiteRequest saves a draft; the design goes live on publish. The
+// domain changes right away.
 type UpdateWebsiteRequest struct {
-	ID          int64
-	Template    string `json:"template" validate:"required"`
-	CustomCSS   string `json:"custom_css"`
-	CustomHTML  string `json:"custom_html"`
-	Domain      string `json:"domain"`
-	IsPublished bool   `json:"is_published"`
-}
\ No newline at end of file
+	ID         int64
+	Template   string            `json:"template" validate:"required"`
+	Settings   map[string]string `json:"settings"`
+	CustomCSS  string            `json:"custom_css"`
+	CustomHTML string            `json:"custom_html"`
+	Domain     string            `json:"domain" validate:"omitempty,max=63"`
+}
+
+type WebsitePreview struct {
+	URL       string    `json:"url"`
+	Version   int       `json:"version"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type DomainAvailability struct {
+	Domain     string `json:"domain"`
+	Available  bool   `json:"available"`
+	Reason     string `json:"reason,omitempty"`
+	Suggestion string `json:"suggestion,omitempty"` // a free domain based on the store name
+}
+
+type SetCustomDomainRequest struct {
+	CustomDomain string `json:"custom_domain" validate:"max=253"` // empty removes it
+}
+
+// DomainVerification tells the owner which TXT record proves they own
+// their custom domain.
+type DomainVerification struct {
+	CustomDomain string     `json:"custom_domain"`
+	RecordType   string     `json:"record_type"`
+	RecordName   string     `json:"record_name"`
+	RecordValue  string     `json:"record_value"`
+	VerifiedAt   *time.Time `json:"verified_at"`
+}
@@ -0,0 +1,142 @@
+// internal/repository/category.go
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"todo-go/internal/model"
+	"todo-go/pkg/slug"
+
+	"gorm.io/gorm"
+)
+
+type CategoryRepository struct {
+	db *gorm.DB
+}
+
+func NewCategoryRepository(db *gorm.DB) *CategoryRepository {
+	return &CategoryRepository{db: db}
+}
+
+func (r *CategoryRepository) Save(ctx context.Context, category *model.Category) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(category).Error; err != nil {
+			return err
+		}
+
+		// Keep the denormalized name on linked products in sync, archived
+		// ones included so they come back correct when restored
+		return tx.Unscoped().Model(&model.Product{}).
+			Where("category_id = ?", category.ID).
+			Update("category", category.Name).Error
+	})
+}
+
+func (r *CategoryRepository) GetByIDAndStoreID(ctx context.Context, id, storeID int64) (*model.Category, error) {
+	var category model.Category
+	err := r.db.WithContext(ctx).First(&category, "id = ? AND store_id = ?", id, storeID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+func (r *CategoryRepository) GetBySlugAndStoreID(ctx context.Context, slug string, storeID int64) (*model.Category, error) {
+	var category model.Category
+	err := r.db.WithContext(ctx).First(&category, "slug = ? AND store_id = ?", slug, storeID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+func (r *CategoryRepository) GetByStoreID(ctx context.Context, storeID int64) ([]*model.Category, error) {
+	var categories []*model.Category
+	err := r.db.WithContext(ctx).Order("position, name").Find(&categories, "store_id = ?", storeID).Error
+	if err != nil {
+		return nil, err
+	}
+	return categories, nil
+}
+
+// Delete removes a category and moves its children and products up to its
+// parent, or to the top level when it has none.
+func (r *CategoryRepository) Delete(ctx context.Context, category *model.Category) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&model.Category{}).
+			Where("parent_id = ?", category.ID).
+			Update("parent_id", category.ParentID).Error
+		if err != nil {
+			return fmt.Errorf("failed to move child categories: %w", err)
+		}
+
+		parentName := ""
+		if category.ParentID != nil {
+			var parent model.Category
+			if err := tx.First(&parent, *category.ParentID).Error; err != nil {
+				return fmt.Errorf("failed to get parent category: %w", err)
+			}
+			parentName = parent.Name
+		}
+
+		err = tx.Unscoped().Model(&model.Product{}).
+			Where("category_id = ?", category.ID).
+			Updates(map[string]any{"category_id": category.ParentID, "category": parentName}).Error
+		if err != nil {
+			return fmt.Errorf("failed to move products: %w", err)
+		}
+
+		return tx.Delete(category).Error
+	})
+}
+
+// MigrateLegacyCategories links products that still only carry a free-text
+// category to a real one, creating a category per distinct name in each
+// store. Names without a usable slug are cleared. It runs after migrating.
+func MigrateLegacyCategories(db *gorm.DB) error {
+	var legacy []struct {
+		StoreID  int64
+		Category string
+	}
+	err := db.Unscoped().Model(&model.Product{}).
+		Distinct("store_id", "category").
+		Where("category_id IS NULL AND category <> ''").
+		Scan(&legacy).Error
+	if err != nil {
+		return err
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, row := range legacy {
+			products := tx.Unscoped().Model(&model.Product{}).
+				Where("store_id = ? AND category_id IS NULL AND category = ?", row.StoreID, row.Category)
+
+			name := strings.TrimSpace(row.Category)
+			categorySlug := slug.Make(name)
+			if len(categorySlug) > 100 {
+				categorySlug = strings.TrimRight(categorySlug[:100], "-")
+			}
+			if categorySlug == "" {
+				if err := products.Update("category", "").Error; err != nil {
+					return err
+				}
+				continue
+			}
+
+			// Names differing only in case or punctuation share a slug
+			category := model.Category{StoreID: row.StoreID, Slug: categorySlug}
+			if err := tx.Where(&category).Attrs(model.Category{Name: name}).FirstOrCreate(&category).Error; err != nil {
+				return err
+			}
+			err := products.Updates(map[string]any{
+				"category_id": category.ID,
+				"category":    category.Name,
+			}).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
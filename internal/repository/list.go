@@ -0,0 +1,155 @@
+// internal/repository/list.go
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"todo-go/internal/model"
+
+	"gorm.io/gorm"
+)
+
+var ErrInvalidListOptions = errors.New("invalid sort or cursor")
+
+// sortKey maps a public sort name to a column and reads that column's value
+// from a row so the next page can continue after it.
+type sortKey[T any] struct {
+	column string
+	value  func(T) any
+}
+
+type cursor struct {
+	Sort  string          `json:"s"`
+	Value json.RawMessage `json:"v"`
+	ID    int64           `json:"id"`
+}
+
+// paginate runs query with the sort, cursor or offset and limit from opts
+// applied. Rows are ordered by the sort column and then by id so that
+// keyset cursors stay stable when values repeat. The query must have its
+// model set for counting; scopes such as preloads only apply to the fetch.
+func paginate[T any](query *gorm.DB, opts *model.ListOptions, keys map[string]sortKey[T], defaultSort string, id func(T) int64, scopes ...func(*gorm.DB) *gorm.DB) ([]T, *model.PageInfo, error) {
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = model.DefaultListLimit
+	}
+	limit = min(limit, model.MaxListLimit)
+
+	sort := opts.Sort
+	if sort == "" {
+		sort = defaultSort
+	}
+	desc := strings.HasPrefix(sort, "-")
+	key, ok := keys[strings.TrimPrefix(sort, "-")]
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: unknown sort %q", ErrInvalidListOptions, sort)
+	}
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, nil, err
+	}
+
+	direction, cmp := "ASC", ">"
+	if desc {
+		direction, cmp = "DESC", "<"
+	}
+	query = query.Order(key.column + " " + direction).Order("id " + direction)
+
+	info := &model.PageInfo{
+		Limit:      limit,
+		Total:      total,
+		TotalPages: int((total + int64(limit) - 1) / int64(limit)),
+	}
+
+	if opts.Cursor != "" {
+		c, err := decodeCursor(opts.Cursor)
+		if err != nil || c.Sort != sort {
+			return nil, nil, fmt.Errorf("%w: cursor does not match sort %q", ErrInvalidListOptions, sort)
+		}
+
+		value, err := cursorValue(c.Value)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: %s", ErrInvalidListOptions, err.Error())
+		}
+
+		query = query.Where(
+			fmt.Sprintf("(%s %s ?) OR (%s = ? AND id %s ?)", key.column, cmp, key.column, cmp),
+			value, value, c.ID,
+		)
+	} else {
+		page := max(opts.Page, 1)
+		info.Page = page
+		query = query.Offset((page - 1) * limit)
+	}
+
+	var rows []T
+	if err := query.Scopes(scopes...).Limit(limit + 1).Find(&rows).Error; err != nil {
+		return nil, nil, err
+	}
+
+	if len(rows) > limit {
+		rows = rows[:limit]
+		info.HasMore = true
+
+		last := rows[len(rows)-1]
+		next, err := encodeCursor(sort, key.value(last), id(last))
+		if err != nil {
+			return nil, nil, err
+		}
+		info.NextCursor = next
+	}
+
+	return rows, info, nil
+}
+
+func encodeCursor(sort string, value any, id int64) (string, error) {
+	if t, ok := value.(time.Time); ok {
+		value = t.UTC().Format(time.RFC3339Nano)
+	}
+
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode cursor: %w", err)
+	}
+
+	data, err := json.Marshal(cursor{Sort: sort, Value: raw, ID: id})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode cursor: %w", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func decodeCursor(s string) (*cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+
+	var c cursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// cursorValue turns the stored JSON value back into something the database
+// driver compares correctly; timestamps travel as RFC 3339 strings.
+func cursorValue(raw json.RawMessage) (any, error) {
+	var value any
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return nil, err
+	}
+
+	if s, ok := value.(string); ok {
+		if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+			return t, nil
+		}
+	}
+	return value, nil
+}
@@ -3,10 +3,16 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"todo-go/internal/model"
 	"gorm.io/gorm"
 )
 
+// ErrOrderStatusChanged is returned when another request changed the
+// order's status after it was read.
+var ErrOrderStatusChanged = errors.New("order status was changed by another request")
+
 type OrderRepository struct {
 	db *gorm.DB
 }
@@ -15,24 +21,116 @@ func NewOrderRepository(db *gorm.DB) *OrderRepository {
 	return &OrderRepository{db: db}
 }
 
-func (r *OrderRepository) Save(ctx context.Context, order *model.Order) error {
-	return r.db.WithContext(ctx).Save(order).Error
+// Save stores the order and, in the same transaction, the voucher use and
+// stock movements it causes, both linked to the order. A voucher that ran
+// out of uses fails the whole order.
+func (r *OrderRepository) Save(ctx context.Context, order *model.Order, redemption *model.VoucherRedemption, movements ...*model.StockMovement) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(order).Error; err != nil {
+			return err
+		}
+		if redemption != nil {
+			redemption.OrderID = order.ID
+			if err := redeemVoucher(tx, redemption); err != nil {
+				return err
+			}
+		}
+		for _, m := range movements {
+			m.OrderID = &order.ID
+		}
+		return applyStockMovements(tx, movements)
+	})
 }
 
-func (r *OrderRepository) GetByID(ctx context.Context, id int64) (*model.Order, error) {
+// UpdateStatus moves the order from the status it was read with to
+// status. It fails with ErrOrderStatusChanged, changing nothing, when the
+// status has moved on since.
+func (r *OrderRepository) UpdateStatus(ctx context.Context, order *model.Order, status string) error {
+	return updateOrderStatus(r.db.WithContext(ctx), order, status)
+}
+
+func updateOrderStatus(tx *gorm.DB, order *model.Order, status string) error {
+	result := tx.Model(&model.Order{}).
+		Where("id = ? AND status = ?", order.ID, order.Status).
+		Update("status", status)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrOrderStatusChanged
+	}
+	return nil
+}
+
+// Cancel marks the order cancelled, gives back its voucher use and puts
+// back the stock its sale movements took out. Items whose product or
+// variant has since been deleted for good are skipped. Like UpdateStatus
+// it fails with ErrOrderStatusChanged if the status moved on meanwhile, or
+// if the order was paid meanwhile, so stock is never given back twice and
+// paid orders stay as they are.
+func (r *OrderRepository) Cancel(ctx context.Context, order *model.Order, userID int64) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := updateOrderStatus(tx.Where("paid_at IS NULL"), order, model.OrderCancelled); err != nil {
+			return err
+		}
+
+		if err := releaseVoucher(tx, order.ID); err != nil {
+			return err
+		}
+
+		var sales []*model.StockMovement
+		err := tx.Find(&sales, "order_id = ? AND type = ?", order.ID, model.StockSale).Error
+		if err != nil {
+			return err
+		}
+
+		for _, sale := range sales {
+			movement := &model.StockMovement{
+				StoreID:   sale.StoreID,
+				ProductID: sale.ProductID,
+				VariantID: sale.VariantID,
+				Type:      model.StockCancellation,
+				Quantity:  -sale.Quantity,
+				Reason:    fmt.Sprintf("order #%d cancelled", order.ID),
+				UserID:    &userID,
+				OrderID:   &order.ID,
+			}
+			err := applyStockMovements(tx, []*model.StockMovement{movement})
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *OrderRepository) GetByIDAndStoreID(ctx context.Context, id, storeID int64) (*model.Order, error) {
 	var order model.Order
-	err := r.db.WithContext(ctx).First(&order, id).Error
+	err := r.db.WithContext(ctx).First(&order, "id = ? AND store_id = ?", id, storeID).Error
 	if err != nil {
 		return nil, err
 	}
 	return &order, nil
 }
 
-func (r *OrderRepository) GetByStoreID(ctx context.Context, storeID int64) ([]*model.Order, error) {
-	var orders []*model.Order
-	err := r.db.WithContext(ctx).Find(&orders, "store_id = ?", storeID).Error
+func (r *OrderRepository) GetByID(ctx context.Context, id int64) (*model.Order, error) {
+	var order model.Order
+	err := r.db.WithContext(ctx).First(&order, id).Error
 	if err != nil {
 		return nil, err
 	}
-	return orders, nil
+	return &order, nil
+}
+
+var orderSortKeys = map[string]sortKey[*model.Order]{
+	"id":           {column: "id", value: func(o *model.Order) any { return o.ID }},
+	"total_amount": {column: "total_amount", value: func(o *model.Order) any { return o.TotalAmount }},
+	"created_at":   {column: "created_at", value: func(o *model.Order) any { return o.CreatedAt }},
+}
+
+// ListByStoreID returns one page of a store's orders, newest first unless
+// another sort is requested.
+func (r *OrderRepository) ListByStoreID(ctx context.Context, storeID int64, opts *model.ListOptions) ([]*model.Order, *model.PageInfo, error) {
+	query := r.db.WithContext(ctx).Model(&model.Order{}).Where("store_id = ?", storeID)
+	return paginate(query, opts, orderSortKeys, "-created_at", func(o *model.Order) int64 { return o.ID })
 }
\ No newline at end of file
@@ -0,0 +1,188 @@
+// internal/repository/payment.go
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+	"todo-go/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PaymentRepository struct {
+	db *gorm.DB
+}
+
+func NewPaymentRepository(db *gorm.DB) *PaymentRepository {
+	return &PaymentRepository{db: db}
+}
+
+func (r *PaymentRepository) Save(ctx context.Context, payment *model.Payment) error {
+	return r.db.WithContext(ctx).Save(payment).Error
+}
+
+func (r *PaymentRepository) GetByProviderRef(ctx context.Context, provider, providerRef string) (*model.Payment, error) {
+	var payment model.Payment
+	err := r.db.WithContext(ctx).First(&payment, "provider = ? AND provider_ref = ?", provider, providerRef).Error
+	if err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
+
+// GetPendingByOrderID returns the newest pending payment of an order.
+func (r *PaymentRepository) GetPendingByOrderID(ctx context.Context, orderID int64) (*model.Payment, error) {
+	var payment model.Payment
+	err := r.db.WithContext(ctx).
+		Where("order_id = ? AND status = ?", orderID, model.PaymentPending).
+		Order("id DESC").
+		First(&payment).Error
+	if err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
+
+// CreatePending saves a new pending payment for an order and returns it,
+// unless the order's current pending payment passes keep, in which case
+// that one is returned and payment is not saved. A pending payment that
+// does not pass is expired, so an order never has two the customer could
+// both pay.
+func (r *PaymentRepository) CreatePending(ctx context.Context, payment *model.Payment, keep func(*model.Payment) bool) (*model.Payment, error) {
+	result := payment
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var order model.Order
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&order, payment.OrderID).Error
+		if err != nil {
+			return err
+		}
+
+		var existing model.Payment
+		err = tx.Where("order_id = ? AND status = ?", payment.OrderID, model.PaymentPending).First(&existing).Error
+		switch {
+		case err == nil && keep(&existing):
+			result = &existing
+			return nil
+		case err == nil:
+			err = tx.Model(&existing).Where("status = ?", model.PaymentPending).Update("status", model.PaymentExpired).Error
+			if err != nil {
+				return err
+			}
+		case !errors.Is(err, gorm.ErrRecordNotFound):
+			return err
+		}
+
+		return tx.Create(payment).Error
+	})
+	return result, err
+}
+
+// GetStalePending returns pending payments created before the given time,
+// oldest first.
+func (r *PaymentRepository) GetStalePending(ctx context.Context, before time.Time, limit int) ([]*model.Payment, error) {
+	var payments []*model.Payment
+	err := r.db.WithContext(ctx).
+		Where("status = ? AND created_at < ?", model.PaymentPending, before).
+		Order("id").
+		Limit(limit).
+		Find(&payments).Error
+	return payments, err
+}
+
+// Settle moves a pending payment to its final status and, when it was paid,
+// marks the order paid in the same transaction. A payment for an order that
+// was cancelled meanwhile becomes PaymentRefundDue instead and leaves the
+// order alone. It reports false without changing anything when the event
+// was seen before or the payment is no longer pending, so webhook retries
+// and the reconciliation job can both settle the same payment safely. event
+// may be nil when the status did not come from a webhook.
+func (r *PaymentRepository) Settle(ctx context.Context, payment *model.Payment, status model.PaymentStatus, at time.Time, event *model.PaymentEvent) (bool, error) {
+	applied := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if event != nil {
+			event.PaymentID = payment.ID
+			result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(event)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return nil
+			}
+		}
+
+		var current model.Payment
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&current, payment.ID).Error
+		if err != nil {
+			return err
+		}
+		if current.Status != model.PaymentPending {
+			*payment = current
+			return nil
+		}
+
+		current.Status = status
+		if status == model.PaymentPaid {
+			current.PaidAt = &at
+
+			// The order stays locked until commit, so it cannot be
+			// cancelled between this check and marking it paid
+			var order model.Order
+			err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+				Select("id", "status", "paid_at").
+				First(&order, current.OrderID).Error
+			if err != nil {
+				return err
+			}
+			switch {
+			case order.Status == model.OrderCancelled:
+				current.Status = model.PaymentRefundDue
+			case order.PaidAt == nil:
+				if err := tx.Model(&order).Update("paid_at", at).Error; err != nil {
+					return err
+				}
+			}
+		}
+		if err := tx.Model(&current).Select("status", "paid_at").Updates(&current).Error; err != nil {
+			return err
+		}
+
+		*payment = current
+		applied = true
+		return nil
+	})
+	return applied, err
+}
+
+// PreparePendingPayments expires all but the newest pending payment of each
+// order, so the unique index on pending payments can be created. It runs
+// before migrating.
+func PreparePendingPayments(db *gorm.DB) error {
+	if !db.Migrator().HasTable(&model.Payment{}) || db.Migrator().HasColumn(&model.Payment{}, "PendingOrderID") {
+		return nil
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		var payments []*model.Payment
+		err := tx.Select("id", "order_id").
+			Where("status = ?", model.PaymentPending).
+			Order("id DESC").
+			Find(&payments).Error
+		if err != nil {
+			return err
+		}
+
+		seen := make(map[int64]bool, len(payments))
+		for _, payment := range payments {
+			if !seen[payment.OrderID] {
+				seen[payment.OrderID] = true
+				continue
+			}
+			if err := tx.Model(payment).Update("status", model.PaymentExpired).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
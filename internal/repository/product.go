@@ -3,6 +3,8 @@ package repository
 
 import (
 	"context"
+	"fmt"
+	"strings"
 	"todo-go/internal/model"
 
 	"gorm.io/gorm"
@@ -16,13 +18,43 @@ func NewProductRepository(db *gorm.DB) *ProductRepository {
 	return &ProductRepository{db: db}
 }
 
-func (r *ProductRepository) Save(ctx context.Context, product *model.Product) error {
-	return r.db.WithContext(ctx).Save(product).Error
+// Save stores the product along with stock movements for it. Stock is
+// only written as is when the product is first inserted; after that it
+// moves through the ledger alone.
+func (r *ProductRepository) Save(ctx context.Context, product *model.Product, movements ...*model.StockMovement) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return saveProduct(tx, product, movements)
+	})
+}
+
+func saveProduct(tx *gorm.DB, product *model.Product, movements []*model.StockMovement) error {
+	// Gallery and variants are managed through their own repositories
+	query := tx.Omit("Images", "Variants")
+	if product.ID != 0 {
+		query = tx.Omit("Images", "Variants", "Stock")
+	}
+	if err := query.Save(product).Error; err != nil {
+		return err
+	}
+
+	for _, m := range movements {
+		m.StoreID = product.StoreID
+		m.ProductID = product.ID
+	}
+	if err := applyStockMovements(tx, movements); err != nil {
+		return err
+	}
+	for _, m := range movements {
+		if m.VariantID == nil {
+			product.Stock = m.Balance
+		}
+	}
+	return nil
 }
 
 func (r *ProductRepository) GetByID(ctx context.Context, id int64) (*model.Product, error) {
 	var product model.Product
-	err := r.db.WithContext(ctx).First(&product, id).Error
+	err := r.withAssociations(ctx).First(&product, id).Error
 	if err != nil {
 		return nil, err
 	}
@@ -31,7 +63,53 @@ func (r *ProductRepository) GetByID(ctx context.Context, id int64) (*model.Produ
 
 func (r *ProductRepository) GetByStoreID(ctx context.Context, storeID int64) ([]*model.Product, error) {
 	var products []*model.Product
-	err := r.db.WithContext(ctx).Find(&products, "store_id = ? AND is_active = ?", storeID, true).Error
+	err := r.withAssociations(ctx).Find(&products, "store_id = ? AND is_active = ?", storeID, true).Error
+	if err != nil {
+		return nil, err
+	}
+	return products, nil
+}
+
+// GetBySKUAndStoreID looks a live product up by its own SKU.
+func (r *ProductRepository) GetBySKUAndStoreID(ctx context.Context, sku string, storeID int64) (*model.Product, error) {
+	var product model.Product
+	err := r.db.WithContext(ctx).First(&product, "sku = ? AND store_id = ?", sku, storeID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &product, nil
+}
+
+// SaveAll saves a batch of products in one transaction, so an import
+// either lands completely or not at all. movements[i] are recorded for
+// products[i].
+func (r *ProductRepository) SaveAll(ctx context.Context, products []*model.Product, movements [][]*model.StockMovement) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for i, product := range products {
+			if err := saveProduct(tx, product, movements[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// EachByStoreID walks all live products of a store in id order, batch by
+// batch, without loading the whole catalog at once.
+func (r *ProductRepository) EachByStoreID(ctx context.Context, storeID int64, batchSize int, fn func([]*model.Product) error) error {
+	var batch []*model.Product
+	return r.db.WithContext(ctx).
+		Where("store_id = ?", storeID).
+		FindInBatches(&batch, batchSize, func(tx *gorm.DB, _ int) error {
+			return fn(batch)
+		}).Error
+}
+
+// GetByIDs returns the active products of a store among the given ids, in
+// no particular order.
+func (r *ProductRepository) GetByIDs(ctx context.Context, storeID int64, ids []int64) ([]*model.Product, error) {
+	var products []*model.Product
+	err := r.withAssociations(ctx).Find(&products, "store_id = ? AND id IN ? AND is_active = ?", storeID, ids, true).Error
 	if err != nil {
 		return nil, err
 	}
@@ -40,13 +118,227 @@ func (r *ProductRepository) GetByStoreID(ctx context.Context, storeID int64) ([]
 
 func (r *ProductRepository) GetByIDAndStoreID(ctx context.Context, id, storeID int64) (*model.Product, error) {
 	var product model.Product
-	err := r.db.WithContext(ctx).First(&product, "id = ? AND store_id = ?", id, storeID).Error
+	err := r.withAssociations(ctx).First(&product, "id = ? AND store_id = ?", id, storeID).Error
 	if err != nil {
 		return nil, err
 	}
 	return &product, nil
 }
 
+// Delete moves a product to the archive. Its gallery and variants are kept
+// so the product can be restored and past orders still resolve.
 func (r *ProductRepository) Delete(ctx context.Context, id int64) error {
 	return r.db.WithContext(ctx).Delete(&model.Product{}, id).Error
 }
+
+func (r *ProductRepository) GetArchivedByIDAndStoreID(ctx context.Context, id, storeID int64) (*model.Product, error) {
+	var product model.Product
+	err := r.withAssociations(ctx).Unscoped().
+		First(&product, "id = ? AND store_id = ? AND deleted_at IS NOT NULL", id, storeID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &product, nil
+}
+
+func (r *ProductRepository) Restore(ctx context.Context, id int64) error {
+	return r.db.WithContext(ctx).Unscoped().Model(&model.Product{}).
+		Where("id = ?", id).
+		Update("deleted_at", nil).Error
+}
+
+var productSortKeys = map[string]sortKey[*model.Product]{
+	"id":         {column: "id", value: func(p *model.Product) any { return p.ID }},
+	"name":       {column: "name", value: func(p *model.Product) any { return p.Name }},
+	"price":      {column: "price", value: func(p *model.Product) any { return p.Price }},
+	"stock":      {column: "stock", value: func(p *model.Product) any { return p.Stock }},
+	"created_at": {column: "created_at", value: func(p *model.Product) any { return p.CreatedAt }},
+}
+
+// ListByStoreID returns one page of a store's products with the category,
+// active and in-stock filters from opts applied.
+func (r *ProductRepository) ListByStoreID(ctx context.Context, storeID int64, opts *model.ListOptions) ([]*model.Product, *model.PageInfo, error) {
+	query := r.db.WithContext(ctx).Model(&model.Product{}).Where("store_id = ?", storeID)
+	if len(opts.CategoryIDs) > 0 {
+		query = query.Where("category_id IN ?", opts.CategoryIDs)
+	}
+	if opts.Active != nil {
+		query = query.Where("is_active = ?", *opts.Active)
+	}
+	if opts.InStock {
+		// Same rule as Product.InStock: untracked products always count,
+		// otherwise active variants carry the stock when there are any
+		query = query.Where(
+			"NOT products.track_stock " +
+				"OR (NOT EXISTS (SELECT 1 FROM product_variants v WHERE v.product_id = products.id AND v.is_active) AND products.stock > 0) " +
+				"OR EXISTS (SELECT 1 FROM product_variants v WHERE v.product_id = products.id AND v.is_active AND v.stock > 0)")
+	}
+
+	return paginate(query, opts, productSortKeys, "id", func(p *model.Product) int64 { return p.ID }, r.preloadAssociations)
+}
+
+var archivedProductSortKeys = map[string]sortKey[*model.Product]{
+	"id":         productSortKeys["id"],
+	"name":       productSortKeys["name"],
+	"price":      productSortKeys["price"],
+	"stock":      productSortKeys["stock"],
+	"created_at": productSortKeys["created_at"],
+	"deleted_at": {column: "deleted_at", value: func(p *model.Product) any { return p.DeletedAt.Time }},
+}
+
+// ListArchivedByStoreID returns one page of a store's archived products,
+// most recently archived first by default.
+func (r *ProductRepository) ListArchivedByStoreID(ctx context.Context, storeID int64, opts *model.ListOptions) ([]*model.Product, *model.PageInfo, error) {
+	query := r.db.WithContext(ctx).Unscoped().Model(&model.Product{}).
+		Where("store_id = ? AND deleted_at IS NOT NULL", storeID)
+	if len(opts.CategoryIDs) > 0 {
+		query = query.Where("category_id IN ?", opts.CategoryIDs)
+	}
+
+	return paginate(query, opts, archivedProductSortKeys, "-deleted_at", func(p *model.Product) int64 { return p.ID }, r.preloadAssociations)
+}
+
+// GetReorderTracked returns the live, active products of every store that
+// track stock and have a reorder threshold, with their variants.
+func (r *ProductRepository) GetReorderTracked(ctx context.Context) ([]*model.Product, error) {
+	var products []*model.Product
+	err := r.db.WithContext(ctx).Preload("Variants").
+		Find(&products, "reorder_threshold > 0 AND is_active = ? AND track_stock = ?", true, true).Error
+	if err != nil {
+		return nil, err
+	}
+	return products, nil
+}
+
+// GetReorderTrackedByStoreID is GetReorderTracked for a single store.
+func (r *ProductRepository) GetReorderTrackedByStoreID(ctx context.Context, storeID int64) ([]*model.Product, error) {
+	var products []*model.Product
+	err := r.db.WithContext(ctx).Preload("Variants").
+		Find(&products, "store_id = ? AND reorder_threshold > 0 AND is_active = ? AND track_stock = ?", storeID, true, true).Error
+	if err != nil {
+		return nil, err
+	}
+	return products, nil
+}
+
+// withAssociations loads the gallery in display order along with variants.
+func (r *ProductRepository) withAssociations(ctx context.Context) *gorm.DB {
+	return r.db.WithContext(ctx).Scopes(r.preloadAssociations)
+}
+
+func (r *ProductRepository) preloadAssociations(db *gorm.DB) *gorm.DB {
+	return db.
+		Preload("Images", func(db *gorm.DB) *gorm.DB { return db.Order("position, id") }).
+		Preload("Variants", func(db *gorm.DB) *gorm.DB { return db.Order("id") })
+}
+
+// PrepareProductSKUs renames SKUs shared by live products of one store,
+// keeping the oldest and appending "-{id}" to the others, so the unique
+// index on them can be created. It runs before migrating.
+func PrepareProductSKUs(db *gorm.DB) error {
+	if !db.Migrator().HasTable(&model.Product{}) {
+		return nil
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		var products []*model.Product
+		err := tx.Select("id", "store_id", "sku").
+			Where("sku <> '' AND (store_id, sku) IN (?)", tx.Model(&model.Product{}).
+				Select("store_id, sku").
+				Where("sku <> ''").
+				Group("store_id, sku").
+				Having("COUNT(*) > 1")).
+			Order("id").
+			Find(&products).Error
+		if err != nil {
+			return err
+		}
+
+		seen := make(map[string]bool, len(products))
+		for _, product := range products {
+			key := fmt.Sprintf("%d/%s", product.StoreID, product.SKU)
+			if !seen[key] {
+				seen[key] = true
+				continue
+			}
+			suffix := fmt.Sprintf("-%d", product.ID)
+			sku := product.SKU[:min(len(product.SKU), 64-len(suffix))] + suffix
+			if err := tx.Model(product).Update("sku", sku).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// PrepareVariantSKUs adds the store_id column to an existing variants table,
+// copying it from the products, and renames SKUs shared by variants of one
+// store by appending the variant id, so the unique index on store and SKU
+// can be created. It runs before migrating.
+func PrepareVariantSKUs(db *gorm.DB) error {
+	if !db.Migrator().HasTable(&model.ProductVariant{}) || db.Migrator().HasColumn(&model.ProductVariant{}, "StoreID") {
+		return nil
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Migrator().AddColumn(&model.ProductVariant{}, "StoreID"); err != nil {
+			return err
+		}
+		err := tx.Exec("UPDATE product_variants v JOIN products p ON p.id = v.product_id SET v.store_id = p.store_id").Error
+		if err != nil {
+			return err
+		}
+
+		var variants []*model.ProductVariant
+		err = tx.Select("id", "store_id", "sku").
+			Where("(store_id, sku) IN (?)", tx.Model(&model.ProductVariant{}).
+				Select("store_id, sku").
+				Group("store_id, sku").
+				Having("COUNT(*) > 1")).
+			Order("id").
+			Find(&variants).Error
+		if err != nil {
+			return err
+		}
+
+		seen := make(map[string]bool, len(variants))
+		for _, variant := range variants {
+			key := fmt.Sprintf("%d/%s", variant.StoreID, variant.SKU)
+			if !seen[key] {
+				seen[key] = true
+				continue
+			}
+			suffix := fmt.Sprintf("-%d", variant.ID)
+			sku := variant.SKU[:min(len(variant.SKU), 64-len(suffix))] + suffix
+			if err := tx.Model(variant).Update("sku", sku).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// PrepareStockTracking adds the track_stock column to an existing products
+// table. Products from before stock was tracked that never had stock or a
+// stock movement are left untracked, so they stay orderable; all others are
+// tracked. It runs before migrating.
+func PrepareStockTracking(db *gorm.DB) error {
+	if !db.Migrator().HasTable(&model.Product{}) || db.Migrator().HasColumn(&model.Product{}, "TrackStock") {
+		return nil
+	}
+
+	tracked := []string{"products.stock <> 0"}
+	if db.Migrator().HasTable(&model.ProductVariant{}) {
+		tracked = append(tracked, "EXISTS (SELECT 1 FROM product_variants v WHERE v.product_id = products.id AND v.stock <> 0)")
+	}
+	if db.Migrator().HasTable(&model.StockMovement{}) {
+		tracked = append(tracked, "EXISTS (SELECT 1 FROM stock_movements m WHERE m.product_id = products.id)")
+	}
+
+	if err := db.Migrator().AddColumn(&model.Product{}, "TrackStock"); err != nil {
+		return err
+	}
+	return db.Unscoped().Model(&model.Product{}).
+		Where("track_stock IS NULL").
+		UpdateColumn("track_stock", gorm.Expr(strings.Join(tracked, " OR "))).Error
+}
@@ -0,0 +1,115 @@
+// internal/repository/product_image.go
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"todo-go/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrImageLimit = errors.New("product has reached its image limit")
+
+type ProductImageRepository struct {
+	db *gorm.DB
+}
+
+func NewProductImageRepository(db *gorm.DB) *ProductImageRepository {
+	return &ProductImageRepository{db: db}
+}
+
+func (r *ProductImageRepository) Save(ctx context.Context, image *model.ProductImage) error {
+	return r.db.WithContext(ctx).Save(image).Error
+}
+
+// Add appends the image to the end of its product's gallery unless the
+// product already has limit images. The product row stays locked while
+// counting, so concurrent uploads cannot both take the last slot. The image
+// becomes primary when primary is set or it is the first one.
+func (r *ProductImageRepository) Add(ctx context.Context, image *model.ProductImage, limit int, primary bool) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var product model.Product
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&product, image.ProductID).Error
+		if err != nil {
+			return err
+		}
+
+		var gallery struct {
+			Count        int
+			NextPosition int
+		}
+		err = tx.Model(&model.ProductImage{}).
+			Select("COUNT(*) AS count, COALESCE(MAX(position) + 1, 0) AS next_position").
+			Where("product_id = ?", image.ProductID).
+			Scan(&gallery).Error
+		if err != nil {
+			return err
+		}
+		if gallery.Count >= limit {
+			return ErrImageLimit
+		}
+
+		image.Position = gallery.NextPosition
+		if err := tx.Create(image).Error; err != nil {
+			return err
+		}
+
+		if primary || gallery.Count == 0 {
+			err := tx.Model(&model.ProductImage{}).
+				Where("product_id = ?", image.ProductID).
+				Update("is_primary", gorm.Expr("id = ?", image.ID)).Error
+			if err != nil {
+				return fmt.Errorf("failed to set primary image: %w", err)
+			}
+			image.IsPrimary = true
+		}
+		return nil
+	})
+}
+
+func (r *ProductImageRepository) GetByIDAndProductID(ctx context.Context, id, productID int64) (*model.ProductImage, error) {
+	var image model.ProductImage
+	err := r.db.WithContext(ctx).First(&image, "id = ? AND product_id = ?", id, productID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &image, nil
+}
+
+func (r *ProductImageRepository) GetByProductID(ctx context.Context, productID int64) ([]*model.ProductImage, error) {
+	var images []*model.ProductImage
+	err := r.db.WithContext(ctx).Order("position, id").Find(&images, "product_id = ?", productID).Error
+	if err != nil {
+		return nil, err
+	}
+	return images, nil
+}
+
+func (r *ProductImageRepository) Delete(ctx context.Context, id int64) error {
+	return r.db.WithContext(ctx).Delete(&model.ProductImage{}, id).Error
+}
+
+// SetPrimary marks one image as primary and clears the flag on the others.
+func (r *ProductImageRepository) SetPrimary(ctx context.Context, productID, id int64) error {
+	return r.db.WithContext(ctx).Model(&model.ProductImage{}).
+		Where("product_id = ?", productID).
+		Update("is_primary", gorm.Expr("id = ?", id)).Error
+}
+
+// UpdatePositions stores the gallery order given as a list of image ids.
+func (r *ProductImageRepository) UpdatePositions(ctx context.Context, productID int64, ids []int64) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for position, id := range ids {
+			err := tx.Model(&model.ProductImage{}).
+				Where("id = ? AND product_id = ?", id, productID).
+				Update("position", position).Error
+			if err != nil {
+				return fmt.Errorf("failed to update image position: %w", err)
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,67 @@
+// internal/repository/product_variant.go
+package repository
+
+import (
+	"context"
+	"todo-go/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type ProductVariantRepository struct {
+	db *gorm.DB
+}
+
+func NewProductVariantRepository(db *gorm.DB) *ProductVariantRepository {
+	return &ProductVariantRepository{db: db}
+}
+
+// Save stores the variant along with stock movements for it, which must
+// carry the store id. As with products, stock on an existing variant only
+// moves through the ledger.
+func (r *ProductVariantRepository) Save(ctx context.Context, variant *model.ProductVariant, movements ...*model.StockMovement) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		query := tx
+		if variant.ID != 0 {
+			query = tx.Omit("Stock")
+		}
+		if err := query.Save(variant).Error; err != nil {
+			return err
+		}
+
+		for _, m := range movements {
+			m.ProductID = variant.ProductID
+			m.VariantID = &variant.ID
+		}
+		if err := applyStockMovements(tx, movements); err != nil {
+			return err
+		}
+		for _, m := range movements {
+			variant.Stock = m.Balance
+		}
+		return nil
+	})
+}
+
+func (r *ProductVariantRepository) GetByIDAndProductID(ctx context.Context, id, productID int64) (*model.ProductVariant, error) {
+	var variant model.ProductVariant
+	err := r.db.WithContext(ctx).First(&variant, "id = ? AND product_id = ?", id, productID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &variant, nil
+}
+
+// GetBySKUAndStoreID looks a variant up by SKU across all products of a store.
+func (r *ProductVariantRepository) GetBySKUAndStoreID(ctx context.Context, sku string, storeID int64) (*model.ProductVariant, error) {
+	var variant model.ProductVariant
+	err := r.db.WithContext(ctx).First(&variant, "sku = ? AND store_id = ?", sku, storeID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &variant, nil
+}
+
+func (r *ProductVariantRepository) Delete(ctx context.Context, id int64) error {
+	return r.db.WithContext(ctx).Delete(&model.ProductVariant{}, id).Error
+}
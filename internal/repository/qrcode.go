@@ -0,0 +1,163 @@
+// internal/repository/qrcode.go
+package repository
+
+import (
+	"context"
+	"time"
+	"todo-go/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type QRCodeRepository struct {
+	db *gorm.DB
+}
+
+func NewQRCodeRepository(db *gorm.DB) *QRCodeRepository {
+	return &QRCodeRepository{db: db}
+}
+
+func (r *QRCodeRepository) Save(ctx context.Context, qrCode *model.QRCode) error {
+	return r.db.WithContext(ctx).Save(qrCode).Error
+}
+
+func (r *QRCodeRepository) GetByIDAndStoreID(ctx context.Context, id, storeID int64) (*model.QRCode, error) {
+	var qrCode model.QRCode
+	err := r.db.WithContext(ctx).First(&qrCode, "id = ? AND store_id = ?", id, storeID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &qrCode, nil
+}
+
+func (r *QRCodeRepository) GetByCode(ctx context.Context, code string) (*model.QRCode, error) {
+	var qrCode model.QRCode
+	err := r.db.WithContext(ctx).First(&qrCode, "code = ?", code).Error
+	if err != nil {
+		return nil, err
+	}
+	return &qrCode, nil
+}
+
+var qrCodeSortKeys = map[string]sortKey[*model.QRCode]{
+	"id":         {column: "id", value: func(q *model.QRCode) any { return q.ID }},
+	"name":       {column: "name", value: func(q *model.QRCode) any { return q.Name }},
+	"created_at": {column: "created_at", value: func(q *model.QRCode) any { return q.CreatedAt }},
+}
+
+// ListByStoreID returns one page of a store's QR codes, newest first by
+// default.
+func (r *QRCodeRepository) ListByStoreID(ctx context.Context, storeID int64, opts *model.ListOptions) ([]*model.QRCode, *model.PageInfo, error) {
+	query := r.db.WithContext(ctx).Model(&model.QRCode{}).Where("store_id = ?", storeID)
+	return paginate(query, opts, qrCodeSortKeys, "-id", func(q *model.QRCode) int64 { return q.ID })
+}
+
+// Delete removes the code and its scans. Orders keep their qr_code_id.
+func (r *QRCodeRepository) Delete(ctx context.Context, id int64) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("qr_code_id = ?", id).Delete(&model.QRScan{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&model.QRCode{}, id).Error
+	})
+}
+
+func (r *QRCodeRepository) RecordScan(ctx context.Context, scan *model.QRScan) error {
+	return r.db.WithContext(ctx).Create(scan).Error
+}
+
+// CountScans returns the number of scans of each code, keyed by code id.
+func (r *QRCodeRepository) CountScans(ctx context.Context, ids []int64) (map[int64]int64, error) {
+	return countByQRCode(r.db.WithContext(ctx).Model(&model.QRScan{}), ids)
+}
+
+// CountOrders returns the number of orders placed through each code,
+// cancelled ones left out.
+func (r *QRCodeRepository) CountOrders(ctx context.Context, ids []int64) (map[int64]int64, error) {
+	return countByQRCode(r.db.WithContext(ctx).Model(&model.Order{}).Where("status <> ?", model.OrderCancelled), ids)
+}
+
+func countByQRCode(query *gorm.DB, ids []int64) (map[int64]int64, error) {
+	counts := make(map[int64]int64, len(ids))
+	if len(ids) == 0 {
+		return counts, nil
+	}
+
+	var rows []struct {
+		QRCodeID int64
+		Total    int64
+	}
+	err := query.Select("qr_code_id, COUNT(*) AS total").
+		Where("qr_code_id IN ?", ids).
+		Group("qr_code_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		counts[row.QRCodeID] = row.Total
+	}
+	return counts, nil
+}
+
+// ScansByDay counts a code's scans since the given time per calendar day,
+// oldest first.
+func (r *QRCodeRepository) ScansByDay(ctx context.Context, qrCodeID int64, since time.Time) ([]*model.QRScanCount, error) {
+	var rows []struct {
+		Day   time.Time
+		Total int64
+	}
+	err := r.db.WithContext(ctx).Model(&model.QRScan{}).
+		Select("DATE(scanned_at) AS day, COUNT(*) AS total").
+		Where("qr_code_id = ? AND scanned_at >= ?", qrCodeID, since).
+		Group("DATE(scanned_at)").
+		Order("day").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make([]*model.QRScanCount, len(rows))
+	for i, row := range rows {
+		counts[i] = &model.QRScanCount{Key: row.Day.Format(time.DateOnly), Scans: row.Total}
+	}
+	return counts, nil
+}
+
+// ScansBySource counts a code's scans since the given time per source,
+// most scanned first.
+func (r *QRCodeRepository) ScansBySource(ctx context.Context, qrCodeID int64, since time.Time) ([]*model.QRScanCount, error) {
+	return r.scansBy(ctx, "source", qrCodeID, since)
+}
+
+// ScansByUserAgent counts a code's scans since the given time per user
+// agent, most scanned first.
+func (r *QRCodeRepository) ScansByUserAgent(ctx context.Context, qrCodeID int64, since time.Time) ([]*model.QRScanCount, error) {
+	return r.scansBy(ctx, "user_agent", qrCodeID, since)
+}
+
+func (r *QRCodeRepository) scansBy(ctx context.Context, column string, qrCodeID int64, since time.Time) ([]*model.QRScanCount, error) {
+	var counts []*model.QRScanCount
+	err := r.db.WithContext(ctx).Model(&model.QRScan{}).
+		Select(column+" AS `key`, COUNT(*) AS scans").
+		Where("qr_code_id = ? AND scanned_at >= ?", qrCodeID, since).
+		Group(column).
+		Order("scans DESC").
+		Scan(&counts).Error
+	return counts, err
+}
+
+// OrderTotals returns how many orders were placed through a code since the
+// given time and what they add up to, cancelled ones left out.
+func (r *QRCodeRepository) OrderTotals(ctx context.Context, qrCodeID int64, since time.Time) (int64, float64, error) {
+	var totals struct {
+		Orders  int64
+		Revenue float64
+	}
+	err := r.db.WithContext(ctx).Model(&model.Order{}).
+		Select("COUNT(*) AS orders, COALESCE(SUM(total_amount), 0) AS revenue").
+		Where("qr_code_id = ? AND status <> ? AND created_at >= ?", qrCodeID, model.OrderCancelled, since).
+		Scan(&totals).Error
+	return totals.Orders, totals.Revenue, err
+}
@@ -0,0 +1,60 @@
+// internal/repository/sale.go
+package repository
+
+import (
+	"context"
+	"time"
+	"todo-go/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type SaleRepository struct {
+	db *gorm.DB
+}
+
+func NewSaleRepository(db *gorm.DB) *SaleRepository {
+	return &SaleRepository{db: db}
+}
+
+func (r *SaleRepository) Save(ctx context.Context, sale *model.Sale) error {
+	return r.db.WithContext(ctx).Save(sale).Error
+}
+
+func (r *SaleRepository) GetByIDAndStoreID(ctx context.Context, id, storeID int64) (*model.Sale, error) {
+	var sale model.Sale
+	err := r.db.WithContext(ctx).First(&sale, "id = ? AND store_id = ?", id, storeID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &sale, nil
+}
+
+// GetRunningByStoreID returns the store's sales that are on at the given
+// moment.
+func (r *SaleRepository) GetRunningByStoreID(ctx context.Context, storeID int64, at time.Time) ([]*model.Sale, error) {
+	var sales []*model.Sale
+	err := r.db.WithContext(ctx).Order("id").
+		Find(&sales, "store_id = ? AND starts_at <= ? AND ends_at > ?", storeID, at, at).Error
+	if err != nil {
+		return nil, err
+	}
+	return sales, nil
+}
+
+var saleSortKeys = map[string]sortKey[*model.Sale]{
+	"id":        {column: "id", value: func(s *model.Sale) any { return s.ID }},
+	"starts_at": {column: "starts_at", value: func(s *model.Sale) any { return s.StartsAt }},
+	"ends_at":   {column: "ends_at", value: func(s *model.Sale) any { return s.EndsAt }},
+}
+
+// ListByStoreID returns one page of a store's sales, latest start first
+// by default.
+func (r *SaleRepository) ListByStoreID(ctx context.Context, storeID int64, opts *model.ListOptions) ([]*model.Sale, *model.PageInfo, error) {
+	query := r.db.WithContext(ctx).Model(&model.Sale{}).Where("store_id = ?", storeID)
+	return paginate(query, opts, saleSortKeys, "-starts_at", func(s *model.Sale) int64 { return s.ID })
+}
+
+func (r *SaleRepository) Delete(ctx context.Context, id int64) error {
+	return r.db.WithContext(ctx).Delete(&model.Sale{}, id).Error
+}
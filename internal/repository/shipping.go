@@ -0,0 +1,54 @@
+// internal/repository/shipping.go
+package repository
+
+import (
+	"context"
+	"todo-go/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type ShippingMethodRepository struct {
+	db *gorm.DB
+}
+
+func NewShippingMethodRepository(db *gorm.DB) *ShippingMethodRepository {
+	return &ShippingMethodRepository{db: db}
+}
+
+func (r *ShippingMethodRepository) Save(ctx context.Context, method *model.ShippingMethod) error {
+	return r.db.WithContext(ctx).Save(method).Error
+}
+
+func (r *ShippingMethodRepository) GetByIDAndStoreID(ctx context.Context, id, storeID int64) (*model.ShippingMethod, error) {
+	var method model.ShippingMethod
+	err := r.db.WithContext(ctx).First(&method, "id = ? AND store_id = ?", id, storeID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &method, nil
+}
+
+// GetByStoreID returns every method of the store in display order.
+func (r *ShippingMethodRepository) GetByStoreID(ctx context.Context, storeID int64) ([]*model.ShippingMethod, error) {
+	var methods []*model.ShippingMethod
+	err := r.db.WithContext(ctx).Order("position, id").Find(&methods, "store_id = ?", storeID).Error
+	if err != nil {
+		return nil, err
+	}
+	return methods, nil
+}
+
+// GetActiveByStoreID returns the methods customers can choose from.
+func (r *ShippingMethodRepository) GetActiveByStoreID(ctx context.Context, storeID int64) ([]*model.ShippingMethod, error) {
+	var methods []*model.ShippingMethod
+	err := r.db.WithContext(ctx).Order("position, id").Find(&methods, "store_id = ? AND is_active = ?", storeID, true).Error
+	if err != nil {
+		return nil, err
+	}
+	return methods, nil
+}
+
+func (r *ShippingMethodRepository) Delete(ctx context.Context, id int64) error {
+	return r.db.WithContext(ctx).Delete(&model.ShippingMethod{}, id).Error
+}
@@ -0,0 +1,245 @@
+// internal/repository/stock.go
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"todo-go/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrInsufficientStock = errors.New("insufficient stock")
+
+type StockRepository struct {
+	db *gorm.DB
+}
+
+func NewStockRepository(db *gorm.DB) *StockRepository {
+	return &StockRepository{db: db}
+}
+
+// Record applies movements to their products and variants in one
+// transaction.
+func (r *StockRepository) Record(ctx context.Context, movements ...*model.StockMovement) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return applyStockMovements(tx, movements)
+	})
+}
+
+var stockSortKeys = map[string]sortKey[*model.StockMovement]{
+	"id":         {column: "id", value: func(m *model.StockMovement) any { return m.ID }},
+	"created_at": {column: "created_at", value: func(m *model.StockMovement) any { return m.CreatedAt }},
+}
+
+// ListByProductID returns one page of a product's stock history, variants
+// included, newest first by default.
+func (r *StockRepository) ListByProductID(ctx context.Context, productID int64, opts *model.ListOptions) ([]*model.StockMovement, *model.PageInfo, error) {
+	query := r.db.WithContext(ctx).Model(&model.StockMovement{}).Where("product_id = ?", productID)
+	return paginate(query, opts, stockSortKeys, "-id", func(m *model.StockMovement) int64 { return m.ID })
+}
+
+// Reconcile resets every balance of the store, archived products included,
+// to the sum of its movements and reports the ones that were off. Stock
+// that predates the ledger, i.e. a balance without any movement, is booked
+// as an opening adjustment instead of being wiped.
+func (r *StockRepository) Reconcile(ctx context.Context, storeID int64) ([]*model.StockDrift, error) {
+	var drifts []*model.StockDrift
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var sums []struct {
+			ProductID int64
+			VariantID *int64
+			Total     int
+		}
+		err := tx.Model(&model.StockMovement{}).
+			Select("product_id, variant_id, SUM(quantity) AS total").
+			Where("store_id = ?", storeID).
+			Group("product_id, variant_id").
+			Scan(&sums).Error
+		if err != nil {
+			return fmt.Errorf("failed to sum stock movements: %w", err)
+		}
+
+		type key struct{ product, variant int64 }
+		ledger := make(map[key]int, len(sums))
+		for _, sum := range sums {
+			k := key{product: sum.ProductID}
+			if sum.VariantID != nil {
+				k.variant = *sum.VariantID
+			}
+			ledger[k] = sum.Total
+		}
+
+		var products []*model.Product
+		err = tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).
+			Preload("Variants").
+			Find(&products, "store_id = ?", storeID).Error
+		if err != nil {
+			return fmt.Errorf("failed to get products: %w", err)
+		}
+
+		check := func(table string, id, productID int64, variantID *int64, stock int, k key) error {
+			total, ok := ledger[k]
+			if !ok {
+				if stock == 0 {
+					return nil
+				}
+				return tx.Create(openingBalance(storeID, productID, variantID, stock)).Error
+			}
+			if total == stock {
+				return nil
+			}
+
+			drifts = append(drifts, &model.StockDrift{ProductID: productID, VariantID: variantID, Stock: stock, Ledger: total})
+			return tx.Table(table).Where("id = ?", id).Update("stock", total).Error
+		}
+
+		for _, product := range products {
+			if err := check("products", product.ID, product.ID, nil, product.Stock, key{product: product.ID}); err != nil {
+				return err
+			}
+			for _, variant := range product.Variants {
+				id := variant.ID
+				if err := check("product_variants", variant.ID, product.ID, &id, variant.Stock, key{product: product.ID, variant: id}); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return drifts, nil
+}
+
+// GetOpenAlerts returns the low-stock alerts of every store that have not
+// been resolved yet.
+func (r *StockRepository) GetOpenAlerts(ctx context.Context) ([]*model.StockAlert, error) {
+	var alerts []*model.StockAlert
+	err := r.db.WithContext(ctx).Find(&alerts, "resolved_at IS NULL").Error
+	if err != nil {
+		return nil, err
+	}
+	return alerts, nil
+}
+
+// GetSilencedAlertIDs returns the open alerts of live products whose owner
+// has since turned alerts off, by clearing the reorder threshold or no
+// longer tracking stock.
+func (r *StockRepository) GetSilencedAlertIDs(ctx context.Context) ([]int64, error) {
+	var ids []int64
+	err := r.db.WithContext(ctx).Model(&model.StockAlert{}).
+		Joins("JOIN products ON products.id = stock_alerts.product_id AND products.deleted_at IS NULL").
+		Where("stock_alerts.resolved_at IS NULL AND (products.reorder_threshold <= 0 OR NOT products.track_stock)").
+		Pluck("stock_alerts.id", &ids).Error
+	return ids, err
+}
+
+func (r *StockRepository) CreateAlerts(ctx context.Context, alerts []*model.StockAlert) error {
+	if len(alerts) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Create(&alerts).Error
+}
+
+// ResolveAlerts closes the given alerts as of at.
+func (r *StockRepository) ResolveAlerts(ctx context.Context, ids []int64, at time.Time) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Model(&model.StockAlert{}).
+		Where("id IN ? AND resolved_at IS NULL", ids).
+		Update("resolved_at", at).Error
+}
+
+// applyStockMovements locks each affected row, moves its stock and writes
+// the movement with the resulting balance. Product and store ids are
+// expected to be set; a balance may never drop below zero.
+func applyStockMovements(tx *gorm.DB, movements []*model.StockMovement) error {
+	for _, m := range movements {
+		var (
+			table string
+			id    int64
+			stock int
+		)
+		if m.VariantID != nil {
+			var variant model.ProductVariant
+			err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+				First(&variant, "id = ? AND product_id = ?", *m.VariantID, m.ProductID).Error
+			if err != nil {
+				return err
+			}
+			table, id, stock = "product_variants", variant.ID, variant.Stock
+		} else {
+			// Archived products keep their ledger so a restore is accurate
+			var product model.Product
+			err := tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).
+				First(&product, "id = ?", m.ProductID).Error
+			if err != nil {
+				return err
+			}
+			table, id, stock = "products", product.ID, product.Stock
+		}
+
+		if err := openLedger(tx, m, stock); err != nil {
+			return err
+		}
+
+		m.Balance = stock + m.Quantity
+		if m.Balance < 0 {
+			if m.VariantID != nil {
+				return fmt.Errorf("%w: product %d variant %d has %d left", ErrInsufficientStock, m.ProductID, *m.VariantID, stock)
+			}
+			return fmt.Errorf("%w: product %d has %d left", ErrInsufficientStock, m.ProductID, stock)
+		}
+
+		if err := tx.Table(table).Where("id = ?", id).Update("stock", m.Balance).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(m).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// openLedger books the stock a product or variant had before the ledger
+// existed as an opening balance, ahead of its first movement, so the sum of
+// movements keeps matching the balance.
+func openLedger(tx *gorm.DB, m *model.StockMovement, stock int) error {
+	if stock == 0 {
+		return nil
+	}
+
+	query := tx.Model(&model.StockMovement{}).Where("product_id = ?", m.ProductID)
+	if m.VariantID != nil {
+		query = query.Where("variant_id = ?", *m.VariantID)
+	} else {
+		query = query.Where("variant_id IS NULL")
+	}
+
+	var count int64
+	if err := query.Limit(1).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+	return tx.Create(openingBalance(m.StoreID, m.ProductID, m.VariantID, stock)).Error
+}
+
+func openingBalance(storeID, productID int64, variantID *int64, stock int) *model.StockMovement {
+	return &model.StockMovement{
+		StoreID:   storeID,
+		ProductID: productID,
+		VariantID: variantID,
+		Type:      model.StockAdjustment,
+		Quantity:  stock,
+		Balance:   stock,
+		Reason:    "opening balance",
+	}
+}
@@ -35,12 +35,13 @@ func (r *TodoRepository) Delete(ctx context.Context, id int64) error {
 	return r.db.WithContext(ctx).Delete(&model.Todo{}, id).Error
 }
 
-func (r *TodoRepository) GetAllByUserID(ctx context.Context, userID int64) ([]*model.Todo, error) {
-	var todos []*model.Todo
-	err := r.db.WithContext(ctx).Find(&todos, "user_id = ?", userID).Error
-	if err != nil {
-		return nil, err
-	}
+var todoSortKeys = map[string]sortKey[*model.Todo]{
+	"id":         {column: "id", value: func(t *model.Todo) any { return t.ID }},
+	"title":      {column: "title", value: func(t *model.Todo) any { return t.Title }},
+	"created_at": {column: "created_at", value: func(t *model.Todo) any { return t.CreatedAt }},
+}
 
-	return todos, nil
+func (r *TodoRepository) ListByUserID(ctx context.Context, userID int64, opts *model.ListOptions) ([]*model.Todo, *model.PageInfo, error) {
+	query := r.db.WithContext(ctx).Model(&model.Todo{}).Where("user_id = ?", userID)
+	return paginate(query, opts, todoSortKeys, "id", func(t *model.Todo) int64 { return t.ID })
 }
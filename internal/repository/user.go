@@ -2,11 +2,16 @@ package repository
 
 import (
 	"context"
+	"fmt"
 	"todo-go/internal/model"
 
 	"gorm.io/gorm"
 )
 
+// deletedDomainPrefix starts the domain of websites of deleted accounts.
+// The underscore is never valid in a domain, so no owner can claim one.
+const deletedDomainPrefix = "deleted_"
+
 type UserRepository struct {
 	db *gorm.DB
 }
@@ -21,6 +26,16 @@ func (u *UserRepository) Save(ctx context.Context, user *model.User) error {
 	return u.db.WithContext(ctx).Save(&user).Error
 }
 
+// ConfirmEmail switches the user to a verified email and ends the pending
+// email change. It reports false, changing nothing, when nonce is no longer
+// the pending change, so each verification token works only once.
+func (u *UserRepository) ConfirmEmail(ctx context.Context, user *model.User, email, nonce string) (bool, error) {
+	result := u.db.WithContext(ctx).Model(&model.User{}).
+		Where("id = ? AND email_nonce = ?", user.ID, nonce).
+		Updates(map[string]any{"email": email, "email_verified": true, "email_nonce": ""})
+	return result.RowsAffected > 0, result.Error
+}
+
 func (u *UserRepository) GetByEmail(ctx context.Context, email string) (*model.User, error) {
 	var user model.User
 	err := u.db.WithContext(ctx).First(&user, "email = ?", email).Error
@@ -40,3 +55,112 @@ func (u *UserRepository) GetByID(ctx context.Context, id int64) (*model.User, er
 
 	return &user, nil
 }
+
+// DeleteAccount anonymizes everything owned by the user in a single
+// transaction and soft-deletes the user row itself.
+func (u *UserRepository) DeleteAccount(ctx context.Context, user *model.User, policy model.RetentionPolicy) error {
+	return u.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		storeIDs := tx.Model(&model.Store{}).Select("id").Where("user_id = ?", user.ID)
+
+		if policy.KeepOrders {
+			err := tx.Model(&model.Order{}).Where("store_id IN (?)", storeIDs).Updates(map[string]any{
+				"customer_name":  "[redacted]",
+				"customer_phone": "",
+				"notes":          "",
+			}).Error
+			if err != nil {
+				return fmt.Errorf("failed to anonymize orders: %w", err)
+			}
+		} else {
+			if err := tx.Where("store_id IN (?)", storeIDs).Delete(&model.Order{}).Error; err != nil {
+				return fmt.Errorf("failed to delete orders: %w", err)
+			}
+			paymentIDs := tx.Model(&model.Payment{}).Select("id").Where("store_id IN (?)", storeIDs)
+			if err := tx.Where("payment_id IN (?)", paymentIDs).Delete(&model.PaymentEvent{}).Error; err != nil {
+				return fmt.Errorf("failed to delete payment events: %w", err)
+			}
+			if err := tx.Where("store_id IN (?)", storeIDs).Delete(&model.Payment{}).Error; err != nil {
+				return fmt.Errorf("failed to delete payments: %w", err)
+			}
+		}
+
+		// Redemptions hold customer phone numbers
+		if err := tx.Where("store_id IN (?)", storeIDs).Delete(&model.VoucherRedemption{}).Error; err != nil {
+			return fmt.Errorf("failed to delete voucher redemptions: %w", err)
+		}
+		if err := tx.Model(&model.Voucher{}).Where("store_id IN (?)", storeIDs).Update("is_active", false).Error; err != nil {
+			return fmt.Errorf("failed to deactivate vouchers: %w", err)
+		}
+
+		// Scans hold customer user agents
+		if err := tx.Where("store_id IN (?)", storeIDs).Delete(&model.QRScan{}).Error; err != nil {
+			return fmt.Errorf("failed to delete QR code scans: %w", err)
+		}
+		if err := tx.Where("store_id IN (?)", storeIDs).Delete(&model.QRCode{}).Error; err != nil {
+			return fmt.Errorf("failed to delete QR codes: %w", err)
+		}
+
+		err := tx.Unscoped().Model(&model.Product{}).Where("store_id IN (?)", storeIDs).Updates(map[string]any{
+			"description": "",
+			"image":       "",
+			"is_active":   false,
+		}).Error
+		if err != nil {
+			return fmt.Errorf("failed to anonymize products: %w", err)
+		}
+
+		// Domains are unique, so each deleted website keeps its own. The
+		// underscore is never a valid domain, so no owner can claim it first
+		err = tx.Model(&model.Website{}).Where("store_id IN (?)", storeIDs).Updates(map[string]any{
+			"custom_css":         "",
+			"custom_html":        "",
+			"domain":             gorm.Expr("CONCAT(?, id)", deletedDomainPrefix),
+			"is_published":       false,
+			"custom_domain":      "",
+			"domain_token":       "",
+			"domain_verified_at": nil,
+			"live_version":       0,
+			"draft_version":      0,
+			"preview_token":      "",
+			"preview_expires_at": nil,
+		}).Error
+		if err != nil {
+			return fmt.Errorf("failed to anonymize websites: %w", err)
+		}
+		websiteIDs := tx.Model(&model.Website{}).Select("id").Where("store_id IN (?)", storeIDs)
+		if err := tx.Where("website_id IN (?)", websiteIDs).Delete(&model.WebsiteRevision{}).Error; err != nil {
+			return fmt.Errorf("failed to delete website revisions: %w", err)
+		}
+
+		err = tx.Model(&model.Store{}).Where("user_id = ?", user.ID).Updates(map[string]any{
+			"name":         "Deleted store",
+			"description":  "",
+			"logo":         "",
+			"address":      "",
+			"phone":        "",
+			"whatsapp":     "",
+			"is_active":    false,
+			"postal_code":  "",
+			"latitude":     nil,
+			"longitude":    nil,
+			"qris_payload": "",
+		}).Error
+		if err != nil {
+			return fmt.Errorf("failed to anonymize store: %w", err)
+		}
+
+		// Free the email address so it can be registered again
+		err = tx.Model(user).Updates(map[string]any{
+			"name":           "Deleted user",
+			"email":          fmt.Sprintf("deleted-%d@deleted.invalid", user.ID),
+			"email_verified": false,
+			"password":       "",
+			"token_version":  gorm.Expr("token_version + 1"),
+		}).Error
+		if err != nil {
+			return fmt.Errorf("failed to anonymize user: %w", err)
+		}
+
+		return tx.Delete(user).Error
+	})
+}
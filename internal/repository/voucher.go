@@ -0,0 +1,122 @@
+// internal/repository/voucher.go
+package repository
+
+import (
+	"context"
+	"errors"
+	"todo-go/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrVoucherUsedUp     = errors.New("voucher has been fully redeemed")
+	ErrVoucherPhoneLimit = errors.New("voucher already used the maximum number of times for this phone number")
+)
+
+type VoucherRepository struct {
+	db *gorm.DB
+}
+
+func NewVoucherRepository(db *gorm.DB) *VoucherRepository {
+	return &VoucherRepository{db: db}
+}
+
+func (r *VoucherRepository) Save(ctx context.Context, voucher *model.Voucher) error {
+	return r.db.WithContext(ctx).Save(voucher).Error
+}
+
+func (r *VoucherRepository) GetByIDAndStoreID(ctx context.Context, id, storeID int64) (*model.Voucher, error) {
+	var voucher model.Voucher
+	err := r.db.WithContext(ctx).First(&voucher, "id = ? AND store_id = ?", id, storeID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &voucher, nil
+}
+
+func (r *VoucherRepository) GetByCodeAndStoreID(ctx context.Context, code string, storeID int64) (*model.Voucher, error) {
+	var voucher model.Voucher
+	err := r.db.WithContext(ctx).First(&voucher, "code = ? AND store_id = ?", code, storeID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &voucher, nil
+}
+
+var voucherSortKeys = map[string]sortKey[*model.Voucher]{
+	"id":         {column: "id", value: func(v *model.Voucher) any { return v.ID }},
+	"code":       {column: "code", value: func(v *model.Voucher) any { return v.Code }},
+	"used_count": {column: "used_count", value: func(v *model.Voucher) any { return v.UsedCount }},
+	"created_at": {column: "created_at", value: func(v *model.Voucher) any { return v.CreatedAt }},
+}
+
+// ListByStoreID returns one page of a store's vouchers, newest first by
+// default. The active filter from opts applies.
+func (r *VoucherRepository) ListByStoreID(ctx context.Context, storeID int64, opts *model.ListOptions) ([]*model.Voucher, *model.PageInfo, error) {
+	query := r.db.WithContext(ctx).Model(&model.Voucher{}).Where("store_id = ?", storeID)
+	if opts.Active != nil {
+		query = query.Where("is_active = ?", *opts.Active)
+	}
+	return paginate(query, opts, voucherSortKeys, "-id", func(v *model.Voucher) int64 { return v.ID })
+}
+
+// Delete removes the voucher. Its redemptions stay with their orders.
+func (r *VoucherRepository) Delete(ctx context.Context, id int64) error {
+	return r.db.WithContext(ctx).Delete(&model.Voucher{}, id).Error
+}
+
+// redeemVoucher counts one use of the voucher against its limits and
+// records the redemption. The voucher row stays locked until the
+// transaction ends, so concurrent orders cannot both take the last use.
+func redeemVoucher(tx *gorm.DB, redemption *model.VoucherRedemption) error {
+	var voucher model.Voucher
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&voucher, redemption.VoucherID).Error
+	if err != nil {
+		return err
+	}
+	if voucher.UsageLimit > 0 && voucher.UsedCount >= voucher.UsageLimit {
+		return ErrVoucherUsedUp
+	}
+
+	if voucher.PerPhoneLimit > 0 {
+		var used int64
+		err := tx.Model(&model.VoucherRedemption{}).
+			Where("voucher_id = ? AND customer_phone = ?", voucher.ID, redemption.CustomerPhone).
+			Count(&used).Error
+		if err != nil {
+			return err
+		}
+		if used >= int64(voucher.PerPhoneLimit) {
+			return ErrVoucherPhoneLimit
+		}
+	}
+
+	err = tx.Model(&voucher).Update("used_count", gorm.Expr("used_count + 1")).Error
+	if err != nil {
+		return err
+	}
+	return tx.Create(redemption).Error
+}
+
+// releaseVoucher gives back the voucher uses of an order.
+func releaseVoucher(tx *gorm.DB, orderID int64) error {
+	var redemptions []*model.VoucherRedemption
+	if err := tx.Find(&redemptions, "order_id = ?", orderID).Error; err != nil {
+		return err
+	}
+
+	for _, redemption := range redemptions {
+		err := tx.Model(&model.Voucher{}).
+			Where("id = ? AND used_count > 0", redemption.VoucherID).
+			Update("used_count", gorm.Expr("used_count - 1")).Error
+		if err != nil {
+			return err
+		}
+		if err := tx.Delete(redemption).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -3,9 +3,12 @@ package repository
 
 import (
 	"context"
+	"fmt"
+	"strings"
 	"todo-go/internal/model"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type WebsiteRepository struct {
@@ -20,6 +23,12 @@ func (r *WebsiteRepository) Save(ctx context.Context, website *model.Website) er
 	return r.db.WithContext(ctx).Save(website).Error
 }
 
+// SaveFields writes only the given columns of website, leaving the rest of
+// the row as concurrent requests may have changed it.
+func (r *WebsiteRepository) SaveFields(ctx context.Context, website *model.Website, columns ...string) error {
+	return r.db.WithContext(ctx).Model(website).Select(columns).Updates(website).Error
+}
+
 func (r *WebsiteRepository) GetByStoreID(ctx context.Context, storeID int64) (*model.Website, error) {
 	var website model.Website
 	err := r.db.WithContext(ctx).First(&website, "store_id = ?", storeID).Error
@@ -37,3 +46,193 @@ func (r *WebsiteRepository) GetByDomain(ctx context.Context, domain string) (*mo
 	}
 	return &website, nil
 }
+
+// GetByCustomDomain returns the published website whose verified custom
+// domain is host.
+func (r *WebsiteRepository) GetByCustomDomain(ctx context.Context, host string) (*model.Website, error) {
+	var website model.Website
+	err := r.db.WithContext(ctx).
+		First(&website, "verified_custom_domain = ? AND is_published = ?", host, true).Error
+	if err != nil {
+		return nil, err
+	}
+	return &website, nil
+}
+
+// DomainTaken reports whether another store's website uses domain.
+func (r *WebsiteRepository) DomainTaken(ctx context.Context, domain string, storeID int64) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&model.Website{}).
+		Where("domain = ? AND store_id <> ?", domain, storeID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// CustomDomainTaken reports whether another store has verified host as its
+// custom domain. Unverified claims do not block anyone.
+func (r *WebsiteRepository) CustomDomainTaken(ctx context.Context, host string, storeID int64) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&model.Website{}).
+		Where("verified_custom_domain = ? AND store_id <> ?", host, storeID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// GetByPreviewToken returns the website a preview link points at, published
+// or not. Callers check PreviewExpiresAt.
+func (r *WebsiteRepository) GetByPreviewToken(ctx context.Context, token string) (*model.Website, error) {
+	var website model.Website
+	err := r.db.WithContext(ctx).First(&website, "preview_token = ?", token).Error
+	if err != nil {
+		return nil, err
+	}
+	return &website, nil
+}
+
+// SaveRevisions stores new or changed revisions together with the website
+// pointing at them.
+func (r *WebsiteRepository) SaveRevisions(ctx context.Context, website *model.Website, revisions ...*model.WebsiteRevision) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, revision := range revisions {
+			if err := tx.Save(revision).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Save(website).Error
+	})
+}
+
+// Revise hands revise a fresh copy of the website, read with its row
+// locked, and the website's latest revision number. The revisions revise
+// returns are stored together with the changed website before the lock is
+// released, so concurrent edits apply one after the other instead of
+// writing back stale copies or picking the same revision number.
+func (r *WebsiteRepository) Revise(ctx context.Context, websiteID int64, revise func(website *model.Website, latest int) ([]*model.WebsiteRevision, error)) (*model.Website, error) {
+	var website model.Website
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&website, websiteID).Error
+		if err != nil {
+			return err
+		}
+
+		var latest int
+		err = tx.Model(&model.WebsiteRevision{}).
+			Where("website_id = ?", websiteID).
+			Select("COALESCE(MAX(version), 0)").
+			Scan(&latest).Error
+		if err != nil {
+			return err
+		}
+
+		revisions, err := revise(&website, latest)
+		if err != nil {
+			return err
+		}
+		for _, revision := range revisions {
+			if err := tx.Save(revision).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Save(&website).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &website, nil
+}
+
+func (r *WebsiteRepository) GetRevision(ctx context.Context, websiteID int64, version int) (*model.WebsiteRevision, error) {
+	var revision model.WebsiteRevision
+	err := r.db.WithContext(ctx).First(&revision, "website_id = ? AND version = ?", websiteID, version).Error
+	if err != nil {
+		return nil, err
+	}
+	return &revision, nil
+}
+
+// ListRevisions returns a website's revisions, newest first.
+func (r *WebsiteRepository) ListRevisions(ctx context.Context, websiteID int64) ([]*model.WebsiteRevision, error) {
+	var revisions []*model.WebsiteRevision
+	err := r.db.WithContext(ctx).Where("website_id = ?", websiteID).Order("version DESC").Find(&revisions).Error
+	return revisions, err
+}
+
+// PrepareWebsiteDomains lowercases website domains and gives websites
+// without a usable domain, i.e. one allowed rejects or an older website
+// already has, the placeholder "toko-{store_id}", so the unique index on
+// domain can be created and every domain passes the rules new ones do.
+// Websites of deleted accounts keep theirs. It runs before migrating.
+func PrepareWebsiteDomains(db *gorm.DB, allowed func(name string, storeID int64) bool) error {
+	if !db.Migrator().HasTable(&model.Website{}) {
+		return nil
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		var websites []*model.Website
+		if err := tx.Select("id", "store_id", "domain").Order("id").Find(&websites).Error; err != nil {
+			return err
+		}
+
+		seen := make(map[string]bool, len(websites))
+		for _, website := range websites {
+			if strings.HasPrefix(website.Domain, deletedDomainPrefix) {
+				seen[website.Domain] = true
+				continue
+			}
+
+			name := strings.ToLower(strings.TrimSpace(website.Domain))
+			if allowed(name, website.StoreID) && !seen[name] {
+				seen[name] = true
+				if name != website.Domain {
+					if err := tx.Model(website).Update("domain", name).Error; err != nil {
+						return err
+					}
+				}
+				continue
+			}
+
+			placeholder := fmt.Sprintf("toko-%d", website.StoreID)
+			if seen[placeholder] {
+				placeholder = fmt.Sprintf("toko-%d-%d", website.StoreID, website.ID)
+			}
+			if err := tx.Model(website).Update("domain", placeholder).Error; err != nil {
+				return err
+			}
+			seen[placeholder] = true
+		}
+		return nil
+	})
+}
+
+// PrepareCustomDomains keeps only the first verification of a custom domain
+// that several websites verified; the others have to verify again. This
+// lets the unique index on verified custom domains be created. It runs
+// before migrating.
+func PrepareCustomDomains(db *gorm.DB) error {
+	if !db.Migrator().HasTable(&model.Website{}) || db.Migrator().HasColumn(&model.Website{}, "VerifiedCustomDomain") {
+		return nil
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		var websites []*model.Website
+		err := tx.Select("id", "custom_domain").
+			Where("custom_domain <> '' AND domain_verified_at IS NOT NULL").
+			Order("domain_verified_at, id").
+			Find(&websites).Error
+		if err != nil {
+			return err
+		}
+
+		seen := make(map[string]bool, len(websites))
+		for _, website := range websites {
+			if !seen[website.CustomDomain] {
+				seen[website.CustomDomain] = true
+				continue
+			}
+			if err := tx.Model(website).Update("domain_verified_at", nil).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,311 @@
+// internal/search/index.go
+package search
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"todo-go/internal/model"
+)
+
+// Field weights: a hit in the name counts more than one in the category,
+// which counts more than one buried in the description.
+var fieldWeights = map[string]float64{
+	"name":        3,
+	"category":    2,
+	"description": 1,
+}
+
+// Match weights by how the query token matched the indexed term.
+const (
+	exactWeight  = 1.0
+	prefixWeight = 0.7
+	typoWeight   = 0.5
+)
+
+// Loader fetches the products of a store that should be searchable.
+type Loader func(ctx context.Context, storeID int64) ([]*model.Product, error)
+
+type posting struct {
+	field string
+	count int
+}
+
+// pendingLoad collects the updates a store receives while its index is
+// being loaded, so they can be replayed on top of the loaded products.
+type pendingLoad struct {
+	loaders int
+	updates []update
+	dropped bool // set by Reindex; the load is then used once and thrown away
+}
+
+// update is an indexed product, or a removal when product is nil.
+type update struct {
+	productID int64
+	product   *model.Product
+}
+
+type storeIndex struct {
+	// terms maps a term to the products containing it, per field
+	terms map[string]map[int64][]posting
+	// docs keeps the terms of every product so it can be removed again
+	docs map[int64][]string
+}
+
+// Index is an embedded inverted index, built lazily per store from the
+// database and kept current through Index and Remove.
+type Index struct {
+	load Loader
+
+	mu      sync.RWMutex
+	stores  map[int64]*storeIndex
+	loading map[int64]*pendingLoad
+}
+
+func NewIndex(load Loader) *Index {
+	return &Index{
+		load:    load,
+		stores:  make(map[int64]*storeIndex),
+		loading: make(map[int64]*pendingLoad),
+	}
+}
+
+func (idx *Index) Search(ctx context.Context, storeID int64, text string, limit int) ([]Hit, error) {
+	queryTokens := tokenize(text)
+	if len(queryTokens) == 0 {
+		return nil, nil
+	}
+
+	si, err := idx.store(ctx, storeID)
+	if err != nil {
+		return nil, err
+	}
+
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	total := float64(len(si.docs))
+	scores := make(map[int64]float64)
+	matched := make(map[int64]int)
+
+	for _, token := range queryTokens {
+		best := make(map[int64]float64)
+		for term, docs := range si.terms {
+			weight := matchWeight(token, term)
+			if weight == 0 {
+				continue
+			}
+
+			idf := math.Log(1 + total/float64(len(docs)))
+			for productID, postings := range docs {
+				var score float64
+				for _, p := range postings {
+					// Repeated words help, with diminishing returns
+					score += fieldWeights[p.field] * (1 + math.Log(float64(p.count)))
+				}
+				score *= weight * idf
+				best[productID] = max(best[productID], score)
+			}
+		}
+
+		for productID, score := range best {
+			scores[productID] += score
+			matched[productID]++
+		}
+	}
+
+	hits := make([]Hit, 0, len(scores))
+	for productID, score := range scores {
+		// Products matching every query word rank above partial matches
+		coverage := float64(matched[productID]) / float64(len(queryTokens))
+		hits = append(hits, Hit{ProductID: productID, Score: score * coverage * coverage})
+	}
+
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].ProductID < hits[j].ProductID
+	})
+
+	if limit > 0 && len(hits) > limit {
+		hits = hits[:limit]
+	}
+
+	return hits, nil
+}
+
+func (idx *Index) Index(ctx context.Context, product *model.Product) error {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	// Stores that were never searched are built in full on first search
+	si, ok := idx.stores[product.StoreID]
+	if !ok {
+		if pending, ok := idx.loading[product.StoreID]; ok {
+			pending.updates = append(pending.updates, update{productID: product.ID, product: product})
+		}
+		return nil
+	}
+
+	si.apply(update{productID: product.ID, product: product})
+	return nil
+}
+
+func (idx *Index) Remove(ctx context.Context, storeID, productID int64) error {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	if si, ok := idx.stores[storeID]; ok {
+		si.remove(productID)
+	} else if pending, ok := idx.loading[storeID]; ok {
+		pending.updates = append(pending.updates, update{productID: productID})
+	}
+	return nil
+}
+
+func (idx *Index) Reindex(ctx context.Context, storeID int64) error {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	delete(idx.stores, storeID)
+	if pending, ok := idx.loading[storeID]; ok {
+		pending.dropped = true
+		delete(idx.loading, storeID)
+	}
+	return nil
+}
+
+func (idx *Index) store(ctx context.Context, storeID int64) (*storeIndex, error) {
+	idx.mu.RLock()
+	si, ok := idx.stores[storeID]
+	idx.mu.RUnlock()
+	if ok {
+		return si, nil
+	}
+
+	// Register the load before reading so that updates arriving meanwhile
+	// are kept rather than lost
+	idx.mu.Lock()
+	if si, ok := idx.stores[storeID]; ok {
+		idx.mu.Unlock()
+		return si, nil
+	}
+	pending, ok := idx.loading[storeID]
+	if !ok {
+		pending = &pendingLoad{}
+		idx.loading[storeID] = pending
+	}
+	pending.loaders++
+	idx.mu.Unlock()
+
+	products, err := idx.load(ctx, storeID)
+	if err == nil {
+		si = &storeIndex{
+			terms: make(map[string]map[int64][]posting),
+			docs:  make(map[int64][]string),
+		}
+		for _, product := range products {
+			si.add(product)
+		}
+	}
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	pending.loaders--
+	if err != nil {
+		if pending.loaders == 0 && idx.loading[storeID] == pending {
+			delete(idx.loading, storeID)
+		}
+		return nil, fmt.Errorf("failed to load products for search: %w", err)
+	}
+
+	// Another request may have built it meanwhile; keep the first one since
+	// it may already have received incremental updates
+	if existing, ok := idx.stores[storeID]; ok {
+		return existing, nil
+	}
+
+	for _, u := range pending.updates {
+		si.apply(u)
+	}
+	if !pending.dropped {
+		idx.stores[storeID] = si
+		delete(idx.loading, storeID)
+	}
+	return si, nil
+}
+
+// apply replays one update: the product is dropped and, if still active,
+// added back.
+func (si *storeIndex) apply(u update) {
+	si.remove(u.productID)
+	if u.product != nil && u.product.IsActive {
+		si.add(u.product)
+	}
+}
+
+func (si *storeIndex) add(product *model.Product) {
+	fields := map[string]string{
+		"name":        product.Name,
+		"category":    product.Category,
+		"description": product.Description,
+	}
+
+	seen := make(map[string]bool)
+	for field, text := range fields {
+		counts := make(map[string]int)
+		for _, token := range tokenize(text) {
+			counts[token]++
+		}
+
+		for term, count := range counts {
+			docs, ok := si.terms[term]
+			if !ok {
+				docs = make(map[int64][]posting)
+				si.terms[term] = docs
+			}
+			docs[product.ID] = append(docs[product.ID], posting{field: field, count: count})
+
+			if !seen[term] {
+				seen[term] = true
+				si.docs[product.ID] = append(si.docs[product.ID], term)
+			}
+		}
+	}
+}
+
+func (si *storeIndex) remove(productID int64) {
+	for _, term := range si.docs[productID] {
+		delete(si.terms[term], productID)
+		if len(si.terms[term]) == 0 {
+			delete(si.terms, term)
+		}
+	}
+	delete(si.docs, productID)
+}
+
+// matchWeight scores how well an indexed term answers a query token: an
+// exact hit, the token being a prefix of the term (search-as-you-type), or
+// a term within the typo budget.
+func matchWeight(token, term string) float64 {
+	switch {
+	case token == term:
+		return exactWeight
+	case len(token) >= 2 && strings.HasPrefix(term, token):
+		return prefixWeight
+	}
+
+	limit := maxEdits(token)
+	if limit == 0 {
+		return 0
+	}
+	if d := editDistance(token, term, limit); d <= limit {
+		return typoWeight / float64(d)
+	}
+	return 0
+}
@@ -0,0 +1,25 @@
+// internal/search/searcher.go
+package search
+
+import (
+	"context"
+	"todo-go/internal/model"
+)
+
+// Hit is one matching product with its relevance score.
+type Hit struct {
+	ProductID int64
+	Score     float64
+}
+
+// ProductSearcher ranks a store's products against free text. The in-memory
+// Index is the default; a database-native full-text implementation can be
+// swapped in as long as it keeps the same ranking contract (best hit first).
+type ProductSearcher interface {
+	Search(ctx context.Context, storeID int64, text string, limit int) ([]Hit, error)
+	Index(ctx context.Context, product *model.Product) error
+	Remove(ctx context.Context, storeID, productID int64) error
+	// Reindex drops everything known about a store so it is rebuilt from
+	// the database, e.g. after a category rename touched many products.
+	Reindex(ctx context.Context, storeID int64) error
+}
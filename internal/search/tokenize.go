@@ -0,0 +1,102 @@
+// internal/search/tokenize.go
+package search
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Indonesian particles and possessive suffixes that customers attach
+// inconsistently ("kopinya", "kopiku", "murahlah"). Derivational affixes are
+// left alone since stripping them blindly does more harm than good on
+// product names.
+var suffixes = []string{"nya", "lah", "kah", "pun", "ku", "mu"}
+
+// tokenize lowercases text, folds accents, splits on anything that is not
+// a letter or digit and strips particle suffixes.
+func tokenize(text string) []string {
+	var tokens []string
+	var b strings.Builder
+
+	flush := func() {
+		if b.Len() > 0 {
+			tokens = append(tokens, stem(b.String()))
+			b.Reset()
+		}
+	}
+
+	for _, r := range norm.NFKD.String(text) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			continue
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(unicode.ToLower(r))
+		default:
+			flush()
+		}
+	}
+	flush()
+
+	return tokens
+}
+
+func stem(token string) string {
+	for _, suffix := range suffixes {
+		if strings.HasSuffix(token, suffix) && len(token)-len(suffix) >= 4 {
+			return strings.TrimSuffix(token, suffix)
+		}
+	}
+	return token
+}
+
+// maxEdits is the typo budget for a query token of the given length.
+func maxEdits(token string) int {
+	switch n := len([]rune(token)); {
+	case n >= 8:
+		return 2
+	case n >= 4:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// editDistance is the optimal string alignment distance (Levenshtein plus
+// adjacent transpositions), capped at limit+1 to stop early.
+func editDistance(a, b string, limit int) int {
+	ra, rb := []rune(a), []rune(b)
+	if d := len(ra) - len(rb); d > limit || -d > limit {
+		return limit + 1
+	}
+
+	prev2 := make([]int, len(rb)+1)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		rowMin := curr[0]
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				curr[j] = min(curr[j], prev2[j-2]+1)
+			}
+			rowMin = min(rowMin, curr[j])
+		}
+		if rowMin > limit {
+			return limit + 1
+		}
+		prev2, prev, curr = prev, curr, prev2
+	}
+
+	return prev[len(rb)]
+}
@@ -76,13 +76,22 @@ func (s *AuthService) SignIn(ctx context.Context, req *model.SignInRequest) (str
 	}
 
 	// Generate access token
-	tokenExp := time.Now().Add(24 * time.Hour).Unix()
-	tokenData := map[string]any{"user_id": user.ID}
-
-	accessToken, err := s.jwtSvc.GenerateToken(ctx, tokenData, tokenExp)
+	accessToken, err := generateAccessToken(ctx, s.jwtSvc, user)
 	if err != nil {
 		return "", fmt.Errorf("failed to generate access token: %w", err)
 	}
 
 	return accessToken, nil
 }
+
+// generateAccessToken embeds the user's token version so that bumping it
+// revokes every token issued before.
+func generateAccessToken(ctx context.Context, jwtSvc *jwt.Service, user *model.User) (string, error) {
+	tokenExp := time.Now().Add(24 * time.Hour).Unix()
+	tokenData := map[string]any{
+		"user_id":       user.ID,
+		"token_version": user.TokenVersion,
+	}
+
+	return jwtSvc.GenerateToken(ctx, tokenData, tokenExp)
+}
@@ -0,0 +1,181 @@
+// internal/service/catalog_cache.go
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+	"todo-go/internal/model"
+	"todo-go/pkg/cache"
+)
+
+// CatalogCache keeps public catalog pages so scanning a QR code does not
+// query the database every time. Services that change what a catalog shows
+// call Invalidate; anything else (stock sold through orders, scheduled
+// sales) shows up once the entry expires.
+//
+// Pages are keyed by the current version of their store, so a change
+// orphans them all at once. A version that is missing, e.g. because the
+// cache evicted it, is replaced by a new one, which can only cost misses and
+// never brings back a stale page.
+type CatalogCache struct {
+	cache cache.Cache
+	ttl   time.Duration
+}
+
+// catalogVersionTTL is how long versions and the stores of domains are
+// kept; they are tiny and every page depends on them
+const catalogVersionTTL = 24 * time.Hour
+
+func NewCatalogCache(cache cache.Cache, ttl time.Duration) *CatalogCache {
+	return &CatalogCache{cache: cache, ttl: ttl}
+}
+
+// cachedCatalog is CatalogData as stored, including the website the HTML
+// page needs.
+type cachedCatalog struct {
+	Website    *model.Website    `json:"website"`
+	Store      *model.Store      `json:"store"`
+	Categories []*model.Category `json:"categories"`
+	Products   []*model.Product  `json:"products"`
+	Pagination *model.PageInfo   `json:"pagination"`
+	LoadedAt   time.Time         `json:"loaded_at"`
+}
+
+// catalogSlot is where a page is cached: under the version its store had
+// before the page was read.
+type catalogSlot struct {
+	key     string
+	storeID int64
+}
+
+// get returns the cached page of domain or, on a miss, the slot to store it
+// in once it is loaded. The slot is nil while the store of the domain is not
+// known yet. Cache errors count as a miss.
+func (c *CatalogCache) get(ctx context.Context, domain string, opts *model.ListOptions) (*CatalogData, *catalogSlot) {
+	if c == nil {
+		return nil, nil
+	}
+
+	value, ok, err := c.cache.Get(ctx, domainKey(domain))
+	if err != nil {
+		log.Printf("failed to read catalog cache: %s", err.Error())
+		return nil, nil
+	}
+	storeID, _ := strconv.ParseInt(string(value), 10, 64)
+	if !ok || storeID == 0 {
+		return nil, nil
+	}
+
+	version, err := c.version(ctx, storeID)
+	if err != nil {
+		log.Printf("failed to read catalog cache: %s", err.Error())
+		return nil, nil
+	}
+	slot := &catalogSlot{key: catalogKey(storeID, version, domain, opts), storeID: storeID}
+
+	value, ok, err = c.cache.Get(ctx, slot.key)
+	if err != nil {
+		log.Printf("failed to read catalog cache: %s", err.Error())
+		return nil, slot
+	}
+	if !ok {
+		return nil, slot
+	}
+
+	var cached cachedCatalog
+	if err := json.Unmarshal(value, &cached); err != nil || cached.Store == nil || cached.Website == nil {
+		return nil, slot
+	}
+
+	return &CatalogData{
+		Website:    cached.Website,
+		Store:      cached.Store,
+		Categories: cached.Categories,
+		Products:   cached.Products,
+		Pagination: cached.Pagination,
+		LoadedAt:   cached.LoadedAt,
+	}, slot
+}
+
+// set stores a page loaded after get returned slot, and remembers the store
+// of the domain for the next lookup. A page whose domain turned out to
+// belong to another store than slot's is not stored.
+func (c *CatalogCache) set(ctx context.Context, slot *catalogSlot, domain string, data *CatalogData) {
+	if c == nil {
+		return
+	}
+
+	storeID := data.Website.StoreID
+	if slot == nil || slot.storeID != storeID {
+		err := c.cache.Set(ctx, domainKey(domain), []byte(strconv.FormatInt(storeID, 10)), catalogVersionTTL)
+		if err != nil {
+			log.Printf("failed to write catalog cache: %s", err.Error())
+		}
+		return
+	}
+
+	value, err := json.Marshal(&cachedCatalog{
+		Website:    data.Website,
+		Store:      data.Store,
+		Categories: data.Categories,
+		Products:   data.Products,
+		Pagination: data.Pagination,
+		LoadedAt:   data.LoadedAt,
+	})
+	if err != nil {
+		log.Printf("failed to encode catalog: %s", err.Error())
+		return
+	}
+	if err := c.cache.Set(ctx, slot.key, value, c.ttl); err != nil {
+		log.Printf("failed to write catalog cache: %s", err.Error())
+	}
+}
+
+// Invalidate drops every cached page of a store by giving the store a new
+// version; pages of the old one are ignored until they expire.
+func (c *CatalogCache) Invalidate(ctx context.Context, storeID int64) {
+	if c == nil {
+		return
+	}
+
+	if _, err := c.newVersion(ctx, storeID); err != nil {
+		log.Printf("failed to invalidate catalog cache for store %d: %s", storeID, err.Error())
+	}
+}
+
+// version returns the store's current version, starting a new one when the
+// cache has none.
+func (c *CatalogCache) version(ctx context.Context, storeID int64) (string, error) {
+	value, ok, err := c.cache.Get(ctx, versionKey(storeID))
+	if err != nil {
+		return "", err
+	}
+	if ok {
+		return string(value), nil
+	}
+	return c.newVersion(ctx, storeID)
+}
+
+func (c *CatalogCache) newVersion(ctx context.Context, storeID int64) (string, error) {
+	version := strconv.FormatInt(time.Now().UnixNano(), 10)
+	if err := c.cache.Set(ctx, versionKey(storeID), []byte(version), catalogVersionTTL); err != nil {
+		return "", err
+	}
+	return version, nil
+}
+
+func catalogKey(storeID int64, version, domain string, opts *model.ListOptions) string {
+	return fmt.Sprintf("catalog:%d:%s:%s:%d:%d:%s:%v:%s", storeID, version, domain, opts.Page, opts.Limit, opts.Sort, opts.CategoryIDs, opts.Cursor)
+}
+
+func versionKey(storeID int64) string {
+	return fmt.Sprintf("catalog-version:%d", storeID)
+}
+
+func domainKey(domain string) string {
+	return fmt.Sprintf("catalog-domain:%s", domain)
+}
@@ -0,0 +1,227 @@
+// internal/service/category.go
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"todo-go/internal/model"
+	"todo-go/internal/repository"
+	"todo-go/internal/search"
+	"todo-go/pkg/slug"
+
+	"gorm.io/gorm"
+)
+
+const maxCategoryDepth = 3
+
+var (
+	ErrCategoryNotFound = errors.New("category not found")
+	ErrInvalidSlug      = errors.New("slug may only contain lowercase letters, digits and dashes")
+	ErrSlugTaken        = errors.New("slug already used by another category")
+	ErrInvalidParent    = fmt.Errorf("parent must be another category of the store, at most %d levels deep", maxCategoryDepth)
+)
+
+type CategoryService struct {
+	categoryRepo *repository.CategoryRepository
+	storeRepo    *repository.StoreRepository
+	searcher     search.ProductSearcher
+	catalogCache *CatalogCache
+}
+
+func NewCategoryService(categoryRepo *repository.CategoryRepository, storeRepo *repository.StoreRepository, searcher search.ProductSearcher, catalogCache *CatalogCache) *CategoryService {
+	return &CategoryService{
+		categoryRepo: categoryRepo,
+		storeRepo:    storeRepo,
+		searcher:     searcher,
+		catalogCache: catalogCache,
+	}
+}
+
+func (s *CategoryService) Create(ctx context.Context, user *model.User, req *model.CreateCategoryRequest) (*model.Category, error) {
+	store, err := s.storeRepo.GetByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get store: %w", err)
+	}
+
+	categories, err := s.categoryRepo.GetByStoreID(ctx, store.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get categories: %w", err)
+	}
+
+	category := &model.Category{
+		StoreID:  store.ID,
+		ParentID: req.ParentID,
+		Name:     req.Name,
+		Position: req.Position,
+	}
+
+	if err := s.prepare(category, req.Slug, categories); err != nil {
+		return nil, err
+	}
+
+	if err := s.categoryRepo.Save(ctx, category); err != nil {
+		return nil, fmt.Errorf("failed to save category: %w", err)
+	}
+
+	return category, nil
+}
+
+// GetTree returns the store's categories nested under their parents.
+func (s *CategoryService) GetTree(ctx context.Context, user *model.User) ([]*model.Category, error) {
+	store, err := s.storeRepo.GetByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get store: %w", err)
+	}
+
+	categories, err := s.categoryRepo.GetByStoreID(ctx, store.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get categories: %w", err)
+	}
+
+	return model.BuildCategoryTree(categories), nil
+}
+
+func (s *CategoryService) Update(ctx context.Context, user *model.User, req *model.UpdateCategoryRequest) (*model.Category, error) {
+	store, err := s.storeRepo.GetByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get store: %w", err)
+	}
+
+	categories, err := s.categoryRepo.GetByStoreID(ctx, store.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get categories: %w", err)
+	}
+
+	var category *model.Category
+	for _, c := range categories {
+		if c.ID == req.ID {
+			category = c
+			break
+		}
+	}
+	if category == nil {
+		return nil, ErrCategoryNotFound
+	}
+
+	category.Name = req.Name
+	category.ParentID = req.ParentID
+	category.Position = req.Position
+
+	if err := s.prepare(category, req.Slug, categories); err != nil {
+		return nil, err
+	}
+
+	if err := s.categoryRepo.Save(ctx, category); err != nil {
+		return nil, fmt.Errorf("failed to update category: %w", err)
+	}
+	s.reindex(ctx, store.ID)
+
+	category.Children = nil
+	return category, nil
+}
+
+func (s *CategoryService) Delete(ctx context.Context, user *model.User, id int64) error {
+	store, err := s.storeRepo.GetByUserID(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get store: %w", err)
+	}
+
+	category, err := s.categoryRepo.GetByIDAndStoreID(ctx, id, store.ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrCategoryNotFound
+		}
+		return fmt.Errorf("failed to get category: %w", err)
+	}
+
+	if err := s.categoryRepo.Delete(ctx, category); err != nil {
+		return fmt.Errorf("failed to delete category: %w", err)
+	}
+	s.reindex(ctx, store.ID)
+
+	return nil
+}
+
+// reindex refreshes search since linked products carry the category name,
+// and drops the cached catalog showing the old tree.
+func (s *CategoryService) reindex(ctx context.Context, storeID int64) {
+	if err := s.searcher.Reindex(ctx, storeID); err != nil {
+		log.Printf("failed to reindex store %d: %s", storeID, err.Error())
+	}
+	s.catalogCache.Invalidate(ctx, storeID)
+}
+
+// prepare fills in the slug and validates the parent against the other
+// categories of the same store.
+func (s *CategoryService) prepare(category *model.Category, requestedSlug string, categories []*model.Category) error {
+	byID := make(map[int64]*model.Category, len(categories))
+	children := make(map[int64][]int64)
+	usedSlugs := make(map[string]bool, len(categories))
+	for _, c := range categories {
+		byID[c.ID] = c
+		if c.ParentID != nil {
+			children[*c.ParentID] = append(children[*c.ParentID], c.ID)
+		}
+		if c.ID != category.ID {
+			usedSlugs[c.Slug] = true
+		}
+	}
+
+	if requestedSlug != "" {
+		if !slug.Valid(requestedSlug) {
+			return ErrInvalidSlug
+		}
+		if usedSlugs[requestedSlug] {
+			return ErrSlugTaken
+		}
+		category.Slug = requestedSlug
+	} else if category.Slug == "" || category.ID == 0 {
+		category.Slug = uniqueSlug(slug.Make(category.Name), usedSlugs)
+	}
+
+	// Walk up from the new parent: it must exist, must not be the category
+	// itself or one of its descendants, and the tree must stay shallow with
+	// the category's own subcategories moved along
+	depth := 1
+	if category.ID != 0 {
+		depth = subtreeHeight(category.ID, children)
+	}
+	for parentID := category.ParentID; parentID != nil; depth++ {
+		parent, ok := byID[*parentID]
+		if !ok || parent.ID == category.ID || depth >= maxCategoryDepth {
+			return ErrInvalidParent
+		}
+		parentID = parent.ParentID
+	}
+
+	return nil
+}
+
+// subtreeHeight counts the levels from a category down to its deepest
+// subcategory, 1 for a category without any. It stops counting past
+// maxCategoryDepth.
+func subtreeHeight(id int64, children map[int64][]int64) int {
+	height := 1
+	for level := children[id]; len(level) > 0 && height <= maxCategoryDepth; height++ {
+		var next []int64
+		for _, child := range level {
+			next = append(next, children[child]...)
+		}
+		level = next
+	}
+	return height
+}
+
+// uniqueSlug appends -2, -3, ... to base until it no longer collides.
+func uniqueSlug(base string, used map[string]bool) string {
+	if base == "" {
+		base = "kategori"
+	}
+	candidate := base
+	for i := 2; used[candidate]; i++ {
+		candidate = fmt.Sprintf("%s-%d", base, i)
+	}
+	return candidate
+}
@@ -4,38 +4,155 @@ package service
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/url"
+	"slices"
+	"strings"
+	"time"
 	"todo-go/internal/model"
 	"todo-go/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidOrderItem   = errors.New("invalid order item")
+	ErrOrderNotFound      = errors.New("order not found")
+	ErrInvalidOrderStatus = errors.New("order status cannot change that way")
 )
 
+// orderTransitions lists the statuses an order may move to from each
+// status. Completed and cancelled orders are final.
+var orderTransitions = map[string][]string{
+	model.OrderPending:   {model.OrderConfirmed, model.OrderCancelled},
+	model.OrderConfirmed: {model.OrderCompleted, model.OrderCancelled},
+}
+
 type OrderService struct {
-	orderRepo   *repository.OrderRepository
-	storeRepo   *repository.StoreRepository
-	productRepo *repository.ProductRepository
+	orderRepo    *repository.OrderRepository
+	storeRepo    *repository.StoreRepository
+	productRepo  *repository.ProductRepository
+	voucherRepo  *repository.VoucherRepository
+	categoryRepo *repository.CategoryRepository
+	qrCodeRepo   *repository.QRCodeRepository
+	pricer       *Pricer
+	shippingSvc  *ShippingService
 }
 
-func NewOrderService(orderRepo *repository.OrderRepository, storeRepo *repository.StoreRepository, productRepo *repository.ProductRepository) *OrderService {
+func NewOrderService(orderRepo *repository.OrderRepository, storeRepo *repository.StoreRepository, productRepo *repository.ProductRepository, voucherRepo *repository.VoucherRepository, categoryRepo *repository.CategoryRepository, qrCodeRepo *repository.QRCodeRepository, pricer *Pricer, shippingSvc *ShippingService) *OrderService {
 	return &OrderService{
-		orderRepo:   orderRepo,
-		storeRepo:   storeRepo,
-		productRepo: productRepo,
+		orderRepo:    orderRepo,
+		storeRepo:    storeRepo,
+		productRepo:  productRepo,
+		voucherRepo:  voucherRepo,
+		categoryRepo: categoryRepo,
+		qrCodeRepo:   qrCodeRepo,
+		pricer:       pricer,
+		shippingSvc:  shippingSvc,
 	}
 }
 
 func (s *OrderService) Create(ctx context.Context, storeID int64, req *model.CreateOrderRequest) (*model.Order, string, error) {
 	store, err := s.storeRepo.GetByID(ctx, storeID)
 	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, "", ErrStoreNotFound
+		}
 		return nil, "", fmt.Errorf("failed to get store: %w", err)
 	}
 
-	var totalAmount float64
+	// Prices come from the catalog, never from the client, with any running
+	// sale applied
+	now := time.Now()
+	prices, err := s.pricer.PriceList(ctx, storeID, now)
+	if err != nil {
+		return nil, "", err
+	}
+
+	items := make([]model.OrderItem, 0, len(req.Items))
+	products := make([]*model.Product, 0, len(req.Items))
+	movements := make([]*model.StockMovement, 0, len(req.Items))
+	var subtotal float64
+	weight := 0
 	for _, item := range req.Items {
-		totalAmount += item.Price * float64(item.Quantity)
+		product, err := s.productRepo.GetByIDAndStoreID(ctx, item.ProductID, storeID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, "", fmt.Errorf("%w: product %d not found", ErrInvalidOrderItem, item.ProductID)
+			}
+			return nil, "", fmt.Errorf("failed to get product: %w", err)
+		}
+		if !product.IsActive {
+			return nil, "", fmt.Errorf("%w: product %d is not available", ErrInvalidOrderItem, item.ProductID)
+		}
+		prices.Apply(product)
+
+		item.Price = product.Price
+		item.Variant = ""
+		if item.VariantID != 0 {
+			variant := findVariant(product, item.VariantID)
+			if variant == nil || !variant.IsActive {
+				return nil, "", fmt.Errorf("%w: variant %d not available for product %d", ErrInvalidOrderItem, item.VariantID, item.ProductID)
+			}
+			item.Price = variant.Price
+			item.Variant = variant.Label(product.Options)
+		} else if hasActiveVariants(product) {
+			return nil, "", fmt.Errorf("%w: product %d requires a variant_id", ErrInvalidOrderItem, item.ProductID)
+		}
+
+		// Products that do not track stock are sold without taking any
+		if product.TrackStock {
+			movement := &model.StockMovement{
+				StoreID:   storeID,
+				ProductID: product.ID,
+				Type:      model.StockSale,
+				Quantity:  -item.Quantity,
+				Reason:    "customer order",
+			}
+			if item.VariantID != 0 {
+				movement.VariantID = &item.VariantID
+			}
+			movements = append(movements, movement)
+		}
+
+		items = append(items, item)
+		products = append(products, product)
+		subtotal += item.Price * float64(item.Quantity)
+		weight += product.Weight * item.Quantity
+	}
+
+	var redemption *model.VoucherRedemption
+	var discount float64
+	code := model.NormalizeVoucherCode(req.VoucherCode)
+	if code != "" {
+		voucher, err := s.voucherRepo.GetByCodeAndStoreID(ctx, code, storeID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, "", fmt.Errorf("%w: code %s not found", ErrVoucherNotApplicable, code)
+			}
+			return nil, "", fmt.Errorf("failed to get voucher: %w", err)
+		}
+
+		discount, err = s.voucherDiscount(ctx, voucher, items, products, subtotal, now)
+		if err != nil {
+			return nil, "", err
+		}
+		redemption = &model.VoucherRedemption{
+			VoucherID:     voucher.ID,
+			StoreID:       storeID,
+			CustomerPhone: model.NormalizePhone(req.CustomerPhone),
+			Discount:      discount,
+		}
+	}
+
+	method, shippingFee, err := s.shippingSvc.ForOrder(ctx, store, req.ShippingMethodID, req.ShippingAddress, weight)
+	if err != nil {
+		return nil, "", err
 	}
+	totalAmount := subtotal - discount + shippingFee
 
-	itemsJSON, _ := json.Marshal(req.Items)
+	itemsJSON, _ := json.Marshal(items)
 
 	order := &model.Order{
 		StoreID:       storeID,
@@ -43,28 +160,72 @@ func (s *OrderService) Create(ctx context.Context, storeID int64, req *model.Cre
 		CustomerPhone: req.CustomerPhone,
 		Items:         string(itemsJSON),
 		TotalAmount:   totalAmount,
-		Status:        "pending",
+		Status:        model.OrderPending,
 		Notes:         req.Notes,
+		TableNumber:   strings.TrimSpace(req.TableNumber),
+		VoucherCode:   code,
+		Subtotal:      subtotal,
+		Discount:      discount,
+		ShippingFee:   shippingFee,
+	}
+	if method != nil {
+		order.ShippingMethodID = &method.ID
+		order.ShippingMethod = method.Name
+		order.ShippingAddress = req.ShippingAddress
+	}
+
+	// Credit the QR code the customer scanned; a stale or foreign code only
+	// loses the credit
+	if req.QRCode != "" {
+		qrCode, err := s.qrCodeRepo.GetByCode(ctx, req.QRCode)
+		switch {
+		case err == nil && qrCode.StoreID == storeID:
+			order.QRCodeID = &qrCode.ID
+		case err != nil && !errors.Is(err, gorm.ErrRecordNotFound):
+			return nil, "", fmt.Errorf("failed to get QR code: %w", err)
+		}
 	}
 
-	if err := s.orderRepo.Save(ctx, order); err != nil {
+	if err := s.orderRepo.Save(ctx, order, redemption, movements...); err != nil {
+		switch {
+		case errors.Is(err, ErrInsufficientStock):
+			return nil, "", fmt.Errorf("%w: %s", ErrInvalidOrderItem, err.Error())
+		case errors.Is(err, repository.ErrVoucherUsedUp), errors.Is(err, repository.ErrVoucherPhoneLimit):
+			return nil, "", fmt.Errorf("%w: %s", ErrVoucherNotApplicable, err.Error())
+		}
 		return nil, "", fmt.Errorf("failed to save order: %w", err)
 	}
 
 	// Generate WhatsApp message
 	message := fmt.Sprintf("*Pesanan Baru #%d*\n\n", order.ID)
 	message += fmt.Sprintf("Nama: %s\n", req.CustomerName)
-	message += fmt.Sprintf("Telepon: %s\n\n", req.CustomerPhone)
-	message += "*Detail Pesanan:*\n"
+	message += fmt.Sprintf("Telepon: %s\n", req.CustomerPhone)
+	if order.TableNumber != "" {
+		message += fmt.Sprintf("Meja: %s\n", order.TableNumber)
+	}
+	message += "\n*Detail Pesanan:*\n"
 
-	for _, item := range req.Items {
-		product, _ := s.productRepo.GetByID(ctx, item.ProductID)
-		if product != nil {
-			message += fmt.Sprintf("- %s x%d = Rp %.0f\n", product.Name, item.Quantity, item.Price*float64(item.Quantity))
+	for i, item := range items {
+		name := products[i].Name
+		if item.Variant != "" {
+			name += " (" + item.Variant + ")"
 		}
+		message += fmt.Sprintf("- %s x%d = Rp %.0f\n", name, item.Quantity, item.Price*float64(item.Quantity))
 	}
 
+	if discount > 0 || method != nil {
+		message += fmt.Sprintf("\nSubtotal: Rp %.0f\n", subtotal)
+	}
+	if discount > 0 {
+		message += fmt.Sprintf("Diskon (%s): -Rp %.0f\n", code, discount)
+	}
+	if method != nil {
+		message += fmt.Sprintf("Ongkir (%s): Rp %.0f\n", method.Name, shippingFee)
+	}
 	message += fmt.Sprintf("\n*Total: Rp %.0f*\n", totalAmount)
+	if method != nil && method.Type != model.ShippingPickup && req.ShippingAddress != nil {
+		message += fmt.Sprintf("\nAlamat: %s\n", req.ShippingAddress.Address)
+	}
 	if req.Notes != "" {
 		message += fmt.Sprintf("\nCatatan: %s", req.Notes)
 	}
@@ -74,16 +235,113 @@ func (s *OrderService) Create(ctx context.Context, storeID int64, req *model.Cre
 	return order, whatsappURL, nil
 }
 
-func (s *OrderService) GetByStore(ctx context.Context, user *model.User) ([]*model.Order, error) {
+func (s *OrderService) GetByStore(ctx context.Context, user *model.User, opts *model.ListOptions) ([]*model.Order, *model.PageInfo, error) {
+	store, err := s.storeRepo.GetByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get store: %w", err)
+	}
+
+	orders, pageInfo, err := s.orderRepo.ListByStoreID(ctx, store.ID, opts)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get orders: %w", err)
+	}
+
+	return orders, pageInfo, nil
+}
+
+// UpdateStatus moves an order along its lifecycle. Cancelling returns the
+// ordered stock through the ledger.
+func (s *OrderService) UpdateStatus(ctx context.Context, user *model.User, req *model.UpdateOrderStatusRequest) (*model.Order, error) {
 	store, err := s.storeRepo.GetByUserID(ctx, user.ID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get store: %w", err)
 	}
 
-	orders, err := s.orderRepo.GetByStoreID(ctx, store.ID)
+	order, err := s.orderRepo.GetByIDAndStoreID(ctx, req.ID, store.ID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get orders: %w", err)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrOrderNotFound
+		}
+		return nil, fmt.Errorf("failed to get order: %w", err)
+	}
+
+	if !slices.Contains(orderTransitions[order.Status], req.Status) {
+		return nil, fmt.Errorf("%w: %s to %s", ErrInvalidOrderStatus, order.Status, req.Status)
+	}
+	if req.Status == model.OrderCancelled && order.PaidAt != nil {
+		return nil, fmt.Errorf("%w: paid orders cannot be cancelled", ErrInvalidOrderStatus)
+	}
+
+	if req.Status == model.OrderCancelled {
+		err = s.orderRepo.Cancel(ctx, order, user.ID)
+	} else {
+		err = s.orderRepo.UpdateStatus(ctx, order, req.Status)
+	}
+	if err != nil {
+		if errors.Is(err, repository.ErrOrderStatusChanged) {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidOrderStatus, err.Error())
+		}
+		return nil, fmt.Errorf("failed to update order status: %w", err)
+	}
+	order.Status = req.Status
+
+	return order, nil
+}
+
+// voucherDiscount checks that the voucher can be used on the order right
+// now and works out what it takes off the items it covers. Usage limits are
+// enforced when the order is saved.
+func (s *OrderService) voucherDiscount(ctx context.Context, voucher *model.Voucher, items []model.OrderItem, products []*model.Product, subtotal float64, at time.Time) (float64, error) {
+	if !voucher.Running(at) {
+		return 0, fmt.Errorf("%w: code %s is not valid at this time", ErrVoucherNotApplicable, voucher.Code)
+	}
+	if subtotal < voucher.MinOrderTotal {
+		return 0, fmt.Errorf("%w: order total must be at least Rp %.0f", ErrVoucherNotApplicable, voucher.MinOrderTotal)
+	}
+
+	// Category restrictions include subcategories
+	parents := make(map[int64]*int64)
+	if len(voucher.CategoryIDs) > 0 {
+		categories, err := s.categoryRepo.GetByStoreID(ctx, voucher.StoreID)
+		if err != nil {
+			return 0, fmt.Errorf("failed to get categories: %w", err)
+		}
+		for _, category := range categories {
+			parents[category.ID] = category.ParentID
+		}
 	}
 
-	return orders, nil
+	var eligible float64
+	for i, item := range items {
+		var categoryIDs []int64
+		for id := products[i].CategoryID; id != nil && len(categoryIDs) < maxCategoryDepth; id = parents[*id] {
+			categoryIDs = append(categoryIDs, *id)
+		}
+		if voucher.Covers(item.ProductID, categoryIDs) {
+			eligible += item.Price * float64(item.Quantity)
+		}
+	}
+	if eligible == 0 {
+		return 0, fmt.Errorf("%w: none of the items qualify for code %s", ErrVoucherNotApplicable, voucher.Code)
+	}
+
+	return voucher.Discount(eligible), nil
+}
+
+func findVariant(product *model.Product, id int64) *model.ProductVariant {
+	for _, variant := range product.Variants {
+		if variant.ID == id {
+			return variant
+		}
+	}
+	return nil
+}
+
+func hasActiveVariants(product *model.Product) bool {
+	for _, variant := range product.Variants {
+		if variant.IsActive {
+			return true
+		}
+	}
+	return false
 }
@@ -0,0 +1,285 @@
+// internal/service/payment.go
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"time"
+	"todo-go/internal/model"
+	"todo-go/internal/repository"
+	"todo-go/pkg/payment"
+	"todo-go/pkg/qris"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrPaymentNotFound  = errors.New("payment not found")
+	ErrOrderAlreadyPaid = errors.New("order is already paid")
+	ErrOrderNotPayable  = errors.New("cancelled orders cannot be paid")
+	ErrQRISNotAccepted  = errors.New("store does not accept QRIS payments")
+	ErrNothingToPay     = errors.New("order total is zero, there is nothing to pay")
+
+	// ErrInvalidPaymentEvent is returned for webhooks that fail signature
+	// verification or do not match the payment they refer to
+	ErrInvalidPaymentEvent = errors.New("invalid payment event")
+)
+
+const (
+	// paymentTTL is how long a customer has to complete a payment
+	paymentTTL = 30 * time.Minute
+	// reconcileAfter is how long a payment may stay pending before the
+	// provider is asked about it directly
+	reconcileAfter  = 5 * time.Minute
+	reconcileBatch  = 100
+	amountTolerance = 0.5
+)
+
+type PaymentService struct {
+	paymentRepo *repository.PaymentRepository
+	orderRepo   *repository.OrderRepository
+	storeRepo   *repository.StoreRepository
+	provider    payment.Provider
+}
+
+func NewPaymentService(paymentRepo *repository.PaymentRepository, orderRepo *repository.OrderRepository, storeRepo *repository.StoreRepository, provider payment.Provider) *PaymentService {
+	return &PaymentService{
+		paymentRepo: paymentRepo,
+		orderRepo:   orderRepo,
+		storeRepo:   storeRepo,
+		provider:    provider,
+	}
+}
+
+// CreateIntent starts a payment for an order. Asking again while a payment
+// with the same method is still open returns that payment instead of
+// opening another one.
+func (s *PaymentService) CreateIntent(ctx context.Context, storeID, orderID int64, req *model.CreatePaymentRequest) (*model.Payment, error) {
+	order, err := s.payableOrder(ctx, storeID, orderID, req.CustomerPhone)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	reusable := func(existing *model.Payment) bool {
+		return existing.Method == req.Method && existing.Amount == order.TotalAmount && now.Before(existing.ExpiresAt)
+	}
+	existing, err := s.paymentRepo.GetPendingByOrderID(ctx, order.ID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to get payment: %w", err)
+	}
+	if existing != nil && reusable(existing) {
+		return existing, nil
+	}
+
+	p := &model.Payment{
+		StoreID:   storeID,
+		OrderID:   order.ID,
+		Provider:  s.provider.Name(),
+		Reference: fmt.Sprintf("ORD%d-%d", order.ID, now.UnixNano()),
+		Method:    req.Method,
+		Amount:    order.TotalAmount,
+		Status:    model.PaymentPending,
+		ExpiresAt: now.Add(paymentTTL),
+	}
+
+	intent, err := s.provider.CreateIntent(ctx, payment.IntentRequest{
+		Reference:     p.Reference,
+		Method:        p.Method,
+		Amount:        p.Amount,
+		CustomerName:  order.CustomerName,
+		CustomerPhone: order.CustomerPhone,
+		ExpiresAt:     p.ExpiresAt,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create payment intent: %w", err)
+	}
+	p.ProviderRef = intent.ProviderRef
+	p.QRString = intent.QRString
+	p.VANumber = intent.VANumber
+	p.PaymentURL = intent.PaymentURL
+	if !intent.ExpiresAt.IsZero() {
+		p.ExpiresAt = intent.ExpiresAt
+	}
+
+	// A concurrent request may have opened one meanwhile; the customer
+	// pays that one and the intent created here lapses unused
+	p, err = s.paymentRepo.CreatePending(ctx, p, reusable)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save payment: %w", err)
+	}
+
+	return p, nil
+}
+
+// OrderQRIS builds a QRIS code for the order total from the store's static
+// QRIS payload. The money goes straight to the store, so nothing reports it
+// back; the owner confirms the order after checking their QRIS account.
+func (s *PaymentService) OrderQRIS(ctx context.Context, storeID, orderID int64, req *model.OrderQRISRequest) (*model.OrderQRIS, error) {
+	order, err := s.payableOrder(ctx, storeID, orderID, req.CustomerPhone)
+	if err != nil {
+		return nil, err
+	}
+
+	store, err := s.storeRepo.GetByID(ctx, storeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get store: %w", err)
+	}
+	if store.QRISPayload == "" {
+		return nil, ErrQRISNotAccepted
+	}
+
+	payload, err := qris.Dynamic(store.QRISPayload, order.TotalAmount)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build QRIS for order %d: %w", order.ID, err)
+	}
+
+	return &model.OrderQRIS{
+		OrderID:  order.ID,
+		Amount:   order.TotalAmount,
+		QRString: payload,
+	}, nil
+}
+
+// payableOrder returns an order the customer may still pay for.
+func (s *PaymentService) payableOrder(ctx context.Context, storeID, orderID int64, phone string) (*model.Order, error) {
+	order, err := s.orderRepo.GetByIDAndStoreID(ctx, orderID, storeID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrOrderNotFound
+		}
+		return nil, fmt.Errorf("failed to get order: %w", err)
+	}
+	// Orders are public by id, the phone number keeps strangers out
+	if model.NormalizePhone(order.CustomerPhone) != model.NormalizePhone(phone) {
+		return nil, ErrOrderNotFound
+	}
+	if order.PaidAt != nil {
+		return nil, ErrOrderAlreadyPaid
+	}
+	if order.Status == model.OrderCancelled {
+		return nil, ErrOrderNotPayable
+	}
+	// e.g. a voucher took the whole total off; QRIS and the provider both
+	// need an amount
+	if order.TotalAmount <= 0 {
+		return nil, ErrNothingToPay
+	}
+	return order, nil
+}
+
+// HandleWebhook verifies and applies a provider callback. Deliveries of an
+// event that was already applied are accepted and ignored.
+func (s *PaymentService) HandleWebhook(ctx context.Context, provider string, header http.Header, body []byte) (*model.Payment, error) {
+	if provider != s.provider.Name() {
+		return nil, fmt.Errorf("%w: unknown provider %s", ErrInvalidPaymentEvent, provider)
+	}
+
+	event, err := s.provider.ParseWebhook(header, body)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidPaymentEvent, err)
+	}
+	if event.EventID == "" {
+		return nil, fmt.Errorf("%w: missing event id", ErrInvalidPaymentEvent)
+	}
+
+	p, err := s.paymentRepo.GetByProviderRef(ctx, provider, event.ProviderRef)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPaymentNotFound
+		}
+		return nil, fmt.Errorf("failed to get payment: %w", err)
+	}
+	if event.Reference != "" && event.Reference != p.Reference {
+		return nil, fmt.Errorf("%w: reference does not match", ErrInvalidPaymentEvent)
+	}
+	if event.Status == payment.StatusPaid && math.Abs(event.Amount-p.Amount) > amountTolerance {
+		return nil, fmt.Errorf("%w: paid Rp %.0f, expected Rp %.0f", ErrInvalidPaymentEvent, event.Amount, p.Amount)
+	}
+	if event.Status == payment.StatusPending {
+		return p, nil
+	}
+
+	applied, err := s.paymentRepo.Settle(ctx, p, model.PaymentStatus(event.Status), time.Now(), &model.PaymentEvent{
+		Provider: provider,
+		EventID:  event.EventID,
+		Status:   model.PaymentStatus(event.Status),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to settle payment: %w", err)
+	}
+	if applied && p.Status == model.PaymentRefundDue {
+		log.Printf("payment %d came in for cancelled order %d and must be refunded", p.ID, p.OrderID)
+	}
+
+	return p, nil
+}
+
+// RunReconciliation checks stuck payments every interval until ctx is done.
+func (s *PaymentService) RunReconciliation(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.Reconcile(ctx); err != nil {
+			log.Printf("failed to reconcile payments: %s", err.Error())
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Reconcile asks the provider about payments that have been pending for a
+// while, in case their webhook was lost, and expires the ones the customer
+// never completed.
+func (s *PaymentService) Reconcile(ctx context.Context) error {
+	now := time.Now()
+	payments, err := s.paymentRepo.GetStalePending(ctx, now.Add(-reconcileAfter), reconcileBatch)
+	if err != nil {
+		return fmt.Errorf("failed to get pending payments: %w", err)
+	}
+
+	for _, p := range payments {
+		status := model.PaymentPending
+		if p.Provider == s.provider.Name() {
+			remote, err := s.provider.Status(ctx, p.ProviderRef)
+			switch {
+			case err == nil:
+				status = model.PaymentStatus(remote)
+			case errors.Is(err, payment.ErrUnknownPayment):
+				// Never reached the provider, it can only expire
+			default:
+				log.Printf("failed to check payment %d: %s", p.ID, err.Error())
+				continue
+			}
+		}
+		if status == model.PaymentPending && now.After(p.ExpiresAt) {
+			status = model.PaymentExpired
+		}
+		if status == model.PaymentPending {
+			continue
+		}
+
+		applied, err := s.paymentRepo.Settle(ctx, p, status, now, nil)
+		if err != nil {
+			log.Printf("failed to settle payment %d: %s", p.ID, err.Error())
+			continue
+		}
+		if applied {
+			log.Printf("reconciled payment %d of order %d as %s", p.ID, p.OrderID, p.Status)
+		}
+		if applied && p.Status == model.PaymentRefundDue {
+			log.Printf("payment %d came in for cancelled order %d and must be refunded", p.ID, p.OrderID)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,46 @@
+// internal/service/pricing.go
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"todo-go/internal/model"
+	"todo-go/internal/repository"
+)
+
+// Pricer loads what is needed to price a store's products at a moment.
+// Everything a customer sees or pays goes through the PriceList it
+// returns.
+type Pricer struct {
+	saleRepo     *repository.SaleRepository
+	categoryRepo *repository.CategoryRepository
+}
+
+func NewPricer(saleRepo *repository.SaleRepository, categoryRepo *repository.CategoryRepository) *Pricer {
+	return &Pricer{
+		saleRepo:     saleRepo,
+		categoryRepo: categoryRepo,
+	}
+}
+
+func (p *Pricer) PriceList(ctx context.Context, storeID int64, at time.Time) (*model.PriceList, error) {
+	sales, err := p.saleRepo.GetRunningByStoreID(ctx, storeID, at)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sales: %w", err)
+	}
+
+	// Categories are only needed to reach subcategories of a category sale
+	var categories []*model.Category
+	for _, sale := range sales {
+		if sale.CategoryID != nil {
+			categories, err = p.categoryRepo.GetByStoreID(ctx, storeID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get categories: %w", err)
+			}
+			break
+		}
+	}
+
+	return model.NewPriceList(sales, categories), nil
+}
@@ -5,22 +5,44 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log"
+	"gorm.io/gorm"
 	"todo-go/internal/model"
 	"todo-go/internal/repository"
-	"gorm.io/gorm"
+	"todo-go/internal/search"
+	"todo-go/pkg/slug"
 )
 
-var ErrProductNotFound = errors.New("product not found")
+var (
+	ErrProductNotFound      = errors.New("product not found")
+	ErrVariantNotFound      = errors.New("variant not found")
+	ErrInvalidOptions       = errors.New("option names and values must be unique")
+	ErrOptionsInUse         = errors.New("options no longer match existing variants, update or delete them first")
+	ErrInvalidVariantOption = errors.New("variant must pick one valid value for every product option")
+	ErrDuplicateVariant     = errors.New("a variant with these options already exists")
+	ErrDuplicateSKU         = errors.New("sku already used in this store")
+
+	// ErrInvalidListOptions is returned by every paginated list
+	ErrInvalidListOptions = repository.ErrInvalidListOptions
+)
 
 type ProductService struct {
-	productRepo *repository.ProductRepository
-	storeRepo   *repository.StoreRepository
+	productRepo  *repository.ProductRepository
+	variantRepo  *repository.ProductVariantRepository
+	categoryRepo *repository.CategoryRepository
+	storeRepo    *repository.StoreRepository
+	searcher     search.ProductSearcher
+	catalogCache *CatalogCache
 }
 
-func NewProductService(productRepo *repository.ProductRepository, storeRepo *repository.StoreRepository) *ProductService {
+func NewProductService(productRepo *repository.ProductRepository, variantRepo *repository.ProductVariantRepository, categoryRepo *repository.CategoryRepository, storeRepo *repository.StoreRepository, searcher search.ProductSearcher, catalogCache *CatalogCache) *ProductService {
 	return &ProductService{
-		productRepo: productRepo,
-		storeRepo:   storeRepo,
+		productRepo:  productRepo,
+		variantRepo:  variantRepo,
+		categoryRepo: categoryRepo,
+		storeRepo:    storeRepo,
+		searcher:     searcher,
+		catalogCache: catalogCache,
 	}
 }
 
@@ -30,36 +52,66 @@ func (s *ProductService) Create(ctx context.Context, user *model.User, req *mode
 		return nil, fmt.Errorf("failed to get store: %w", err)
 	}
 
+	if !validOptions(req.Options) {
+		return nil, ErrInvalidOptions
+	}
+
+	if err := s.checkSKU(ctx, store.ID, req.SKU, 0, 0); err != nil {
+		return nil, err
+	}
+
+	category, err := s.resolveCategory(ctx, store.ID, req.CategoryID, req.Category)
+	if err != nil {
+		return nil, err
+	}
+
 	product := &model.Product{
+		SKU:         req.SKU,
 		Name:        req.Name,
 		Description: req.Description,
 		Price:       req.Price,
 		Image:       req.Image,
-		Category:    req.Category,
-		Stock:       req.Stock,
 		StoreID:     store.ID,
 		IsActive:    true,
+		Options:     req.Options,
+
+		TrackStock:       req.TrackStock == nil || *req.TrackStock,
+		ReorderThreshold: req.ReorderThreshold,
+		Weight:           req.Weight,
+	}
+	if category != nil {
+		product.CategoryID = &category.ID
+		product.Category = category.Name
 	}
 
-	if err := s.productRepo.Save(ctx, product); err != nil {
+	movements := stockMovements(user, store.ID, 0, req.Stock, model.StockRestock, "initial stock")
+	if err := s.productRepo.Save(ctx, product, movements...); err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, ErrDuplicateSKU
+		}
 		return nil, fmt.Errorf("failed to save product: %w", err)
 	}
+	s.index(ctx, product)
+	s.catalogCache.Invalidate(ctx, store.ID)
 
 	return product, nil
 }
 
-func (s *ProductService) GetByStore(ctx context.Context, user *model.User) ([]*model.Product, error) {
+// GetByStore is the owner's view of the store's products: inactive products
+// are included unless opts filters on Active. Archived products are listed
+// by GetArchived instead.
+func (s *ProductService) GetByStore(ctx context.Context, user *model.User, opts *model.ListOptions) ([]*model.Product, *model.PageInfo, error) {
 	store, err := s.storeRepo.GetByUserID(ctx, user.ID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get store: %w", err)
+		return nil, nil, fmt.Errorf("failed to get store: %w", err)
 	}
 
-	products, err := s.productRepo.GetByStoreID(ctx, store.ID)
+	products, pageInfo, err := s.productRepo.ListByStoreID(ctx, store.ID, opts)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get products: %w", err)
+		return nil, nil, fmt.Errorf("failed to get products: %w", err)
 	}
 
-	return products, nil
+	return products, pageInfo, nil
 }
 
 func (s *ProductService) GetByID(ctx context.Context, user *model.User, id int64) (*model.Product, error) {
@@ -96,14 +148,54 @@ func (s *ProductService) Update(ctx context.Context, user *model.User, req *mode
 	product.Name = req.Name
 	product.Description = req.Description
 	product.Price = req.Price
+	if !validOptions(req.Options) {
+		return nil, ErrInvalidOptions
+	}
+	for _, variant := range product.Variants {
+		if !variant.MatchesOptions(req.Options) {
+			return nil, ErrOptionsInUse
+		}
+	}
+
+	if err := s.checkSKU(ctx, store.ID, req.SKU, product.ID, 0); err != nil {
+		return nil, err
+	}
+
+	category, err := s.resolveCategory(ctx, store.ID, req.CategoryID, req.Category)
+	if err != nil {
+		return nil, err
+	}
+
+	product.SKU = req.SKU
+	if product.Image != req.Image {
+		// Resized variants only exist for uploaded images
+		product.ImageMedium = ""
+		product.ImageThumb = ""
+	}
 	product.Image = req.Image
-	product.Category = req.Category
-	product.Stock = req.Stock
+	product.CategoryID = nil
+	product.Category = ""
+	if category != nil {
+		product.CategoryID = &category.ID
+		product.Category = category.Name
+	}
 	product.IsActive = req.IsActive
+	product.Options = req.Options
+	if req.TrackStock != nil {
+		product.TrackStock = *req.TrackStock
+	}
+	product.ReorderThreshold = req.ReorderThreshold
+	product.Weight = req.Weight
 
-	if err := s.productRepo.Save(ctx, product); err != nil {
+	movements := stockMovements(user, store.ID, product.Stock, req.Stock, model.StockAdjustment, "stock set on product update")
+	if err := s.productRepo.Save(ctx, product, movements...); err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, ErrDuplicateSKU
+		}
 		return nil, fmt.Errorf("failed to update product: %w", err)
 	}
+	s.index(ctx, product)
+	s.catalogCache.Invalidate(ctx, store.ID)
 
 	return product, nil
 }
@@ -125,6 +217,268 @@ func (s *ProductService) Delete(ctx context.Context, user *model.User, id int64)
 	if err := s.productRepo.Delete(ctx, product.ID); err != nil {
 		return fmt.Errorf("failed to delete product: %w", err)
 	}
+	if err := s.searcher.Remove(ctx, product.StoreID, product.ID); err != nil {
+		log.Printf("failed to remove product %d from search index: %s", product.ID, err.Error())
+	}
+	s.catalogCache.Invalidate(ctx, store.ID)
 
 	return nil
-}
\ No newline at end of file
+}
+
+func (s *ProductService) GetArchived(ctx context.Context, user *model.User, opts *model.ListOptions) ([]*model.Product, *model.PageInfo, error) {
+	store, err := s.storeRepo.GetByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get store: %w", err)
+	}
+
+	products, pageInfo, err := s.productRepo.ListArchivedByStoreID(ctx, store.ID, opts)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get archived products: %w", err)
+	}
+
+	return products, pageInfo, nil
+}
+
+// Restore brings an archived product back with its gallery and variants.
+func (s *ProductService) Restore(ctx context.Context, user *model.User, id int64) (*model.Product, error) {
+	store, err := s.storeRepo.GetByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get store: %w", err)
+	}
+
+	product, err := s.productRepo.GetArchivedByIDAndStoreID(ctx, id, store.ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrProductNotFound
+		}
+		return nil, fmt.Errorf("failed to get archived product: %w", err)
+	}
+
+	// The SKU may have been reused while the product was archived
+	if err := s.checkSKU(ctx, store.ID, product.SKU, product.ID, 0); err != nil {
+		return nil, err
+	}
+
+	if err := s.productRepo.Restore(ctx, product.ID); err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, ErrDuplicateSKU
+		}
+		return nil, fmt.Errorf("failed to restore product: %w", err)
+	}
+	product.DeletedAt = gorm.DeletedAt{}
+
+	s.index(ctx, product)
+	s.catalogCache.Invalidate(ctx, store.ID)
+
+	return product, nil
+}
+
+func (s *ProductService) CreateVariant(ctx context.Context, user *model.User, req *model.CreateProductVariantRequest) (*model.ProductVariant, error) {
+	store, err := s.storeRepo.GetByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get store: %w", err)
+	}
+
+	product, err := s.productRepo.GetByIDAndStoreID(ctx, req.ProductID, store.ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrProductNotFound
+		}
+		return nil, fmt.Errorf("failed to get product: %w", err)
+	}
+
+	variant := &model.ProductVariant{
+		ProductID: product.ID,
+		StoreID:   store.ID,
+		SKU:       req.SKU,
+		Options:   req.Options,
+		Price:     req.Price,
+		IsActive:  true,
+	}
+
+	if err := s.validateVariant(ctx, store.ID, product, variant); err != nil {
+		return nil, err
+	}
+
+	movements := stockMovements(user, store.ID, 0, req.Stock, model.StockRestock, "initial stock")
+	if err := s.variantRepo.Save(ctx, variant, movements...); err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, ErrDuplicateSKU
+		}
+		return nil, fmt.Errorf("failed to save variant: %w", err)
+	}
+	s.catalogCache.Invalidate(ctx, store.ID)
+
+	return variant, nil
+}
+
+func (s *ProductService) UpdateVariant(ctx context.Context, user *model.User, req *model.UpdateProductVariantRequest) (*model.ProductVariant, error) {
+	store, err := s.storeRepo.GetByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get store: %w", err)
+	}
+
+	product, err := s.productRepo.GetByIDAndStoreID(ctx, req.ProductID, store.ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrProductNotFound
+		}
+		return nil, fmt.Errorf("failed to get product: %w", err)
+	}
+
+	variant, err := s.variantRepo.GetByIDAndProductID(ctx, req.ID, product.ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrVariantNotFound
+		}
+		return nil, fmt.Errorf("failed to get variant: %w", err)
+	}
+
+	variant.SKU = req.SKU
+	variant.Options = req.Options
+	variant.Price = req.Price
+	variant.IsActive = req.IsActive
+
+	if err := s.validateVariant(ctx, store.ID, product, variant); err != nil {
+		return nil, err
+	}
+
+	movements := stockMovements(user, store.ID, variant.Stock, req.Stock, model.StockAdjustment, "stock set on variant update")
+	if err := s.variantRepo.Save(ctx, variant, movements...); err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, ErrDuplicateSKU
+		}
+		return nil, fmt.Errorf("failed to update variant: %w", err)
+	}
+	s.catalogCache.Invalidate(ctx, store.ID)
+
+	return variant, nil
+}
+
+func (s *ProductService) DeleteVariant(ctx context.Context, user *model.User, productID, id int64) error {
+	store, err := s.storeRepo.GetByUserID(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get store: %w", err)
+	}
+
+	product, err := s.productRepo.GetByIDAndStoreID(ctx, productID, store.ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrProductNotFound
+		}
+		return fmt.Errorf("failed to get product: %w", err)
+	}
+
+	variant, err := s.variantRepo.GetByIDAndProductID(ctx, id, product.ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrVariantNotFound
+		}
+		return fmt.Errorf("failed to get variant: %w", err)
+	}
+
+	if err := s.variantRepo.Delete(ctx, variant.ID); err != nil {
+		return fmt.Errorf("failed to delete variant: %w", err)
+	}
+	s.catalogCache.Invalidate(ctx, store.ID)
+
+	return nil
+}
+
+// validateVariant checks the option values against the product and makes
+// sure the SKU is not taken by another variant of the same store.
+func (s *ProductService) validateVariant(ctx context.Context, storeID int64, product *model.Product, variant *model.ProductVariant) error {
+	if !variant.MatchesOptions(product.Options) {
+		return ErrInvalidVariantOption
+	}
+
+	for _, other := range product.Variants {
+		if other.ID != variant.ID && other.Label(product.Options) == variant.Label(product.Options) {
+			return ErrDuplicateVariant
+		}
+	}
+
+	return s.checkSKU(ctx, storeID, variant.SKU, 0, variant.ID)
+}
+
+// checkSKU makes sure sku is not used by another product or variant of the
+// store. productID and variantID name the record being saved, if any.
+func (s *ProductService) checkSKU(ctx context.Context, storeID int64, sku string, productID, variantID int64) error {
+	if sku == "" {
+		return nil
+	}
+
+	product, err := s.productRepo.GetBySKUAndStoreID(ctx, sku, storeID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("failed to get product by sku: %w", err)
+	}
+	if product != nil && (variantID != 0 || product.ID != productID) {
+		return ErrDuplicateSKU
+	}
+
+	variant, err := s.variantRepo.GetBySKUAndStoreID(ctx, sku, storeID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("failed to get variant by sku: %w", err)
+	}
+	if variant != nil && variant.ID != variantID {
+		return ErrDuplicateSKU
+	}
+
+	return nil
+}
+
+func validOptions(options []model.ProductOption) bool {
+	names := make(map[string]bool, len(options))
+	for _, option := range options {
+		if names[option.Name] {
+			return false
+		}
+		names[option.Name] = true
+
+		values := make(map[string]bool, len(option.Values))
+		for _, value := range option.Values {
+			if values[value] {
+				return false
+			}
+			values[value] = true
+		}
+	}
+	return true
+}
+
+// resolveCategory finds the category a product should link to. An explicit
+// id wins; otherwise the legacy free-text category is slugified and matched
+// against the store's category slugs. No category at all is allowed.
+func (s *ProductService) resolveCategory(ctx context.Context, storeID int64, id *int64, name string) (*model.Category, error) {
+	if id != nil {
+		category, err := s.categoryRepo.GetByIDAndStoreID(ctx, *id, storeID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, ErrCategoryNotFound
+			}
+			return nil, fmt.Errorf("failed to get category: %w", err)
+		}
+		return category, nil
+	}
+
+	if name == "" {
+		return nil, nil
+	}
+
+	category, err := s.categoryRepo.GetBySlugAndStoreID(ctx, slug.Make(name), storeID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCategoryNotFound
+		}
+		return nil, fmt.Errorf("failed to get category: %w", err)
+	}
+	return category, nil
+}
+
+// index refreshes the product in the search index. The database stays the
+// source of truth, so a failure only degrades search results.
+func (s *ProductService) index(ctx context.Context, product *model.Product) {
+	if err := s.searcher.Index(ctx, product); err != nil {
+		log.Printf("failed to index product %d: %s", product.ID, err.Error())
+	}
+}
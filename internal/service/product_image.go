@@ -0,0 +1,224 @@
+// internal/service/product_image.go
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"todo-go/internal/model"
+	"todo-go/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+const maxProductImages = 10
+
+var (
+	ErrProductImageNotFound = errors.New("product image not found")
+	ErrTooManyProductImages = fmt.Errorf("a product can have at most %d images", maxProductImages)
+	ErrInvalidImageOrder    = errors.New("image_ids must list every image of the product exactly once")
+)
+
+type ProductImageService struct {
+	imageRepo    *repository.ProductImageRepository
+	productRepo  *repository.ProductRepository
+	storeRepo    *repository.StoreRepository
+	uploadSvc    *UploadService
+	catalogCache *CatalogCache
+}
+
+func NewProductImageService(imageRepo *repository.ProductImageRepository, productRepo *repository.ProductRepository, storeRepo *repository.StoreRepository, uploadSvc *UploadService, catalogCache *CatalogCache) *ProductImageService {
+	return &ProductImageService{
+		imageRepo:    imageRepo,
+		productRepo:  productRepo,
+		storeRepo:    storeRepo,
+		uploadSvc:    uploadSvc,
+		catalogCache: catalogCache,
+	}
+}
+
+// Add stores an uploaded image at the end of the gallery. The first image of
+// a product always becomes primary.
+func (s *ProductImageService) Add(ctx context.Context, user *model.User, productID int64, data []byte, altText string, primary bool) (*model.Product, error) {
+	product, err := s.getProduct(ctx, user, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Checked again when saving; this spares the upload in the common case
+	if len(product.Images) >= maxProductImages {
+		return nil, ErrTooManyProductImages
+	}
+
+	stored, err := s.uploadSvc.StoreImage(ctx, fmt.Sprintf("stores/%d/products/%d", product.StoreID, product.ID), data)
+	if err != nil {
+		return nil, err
+	}
+
+	image := &model.ProductImage{
+		ProductID: product.ID,
+		URL:       stored.Original,
+		MediumURL: stored.Medium,
+		ThumbURL:  stored.Thumbnail,
+		Keys:      strings.Join(stored.Keys, ","),
+		AltText:   altText,
+	}
+
+	if err := s.imageRepo.Add(ctx, image, maxProductImages, primary); err != nil {
+		s.uploadSvc.DeleteKeys(ctx, image.Keys)
+		if errors.Is(err, repository.ErrImageLimit) {
+			return nil, ErrTooManyProductImages
+		}
+		return nil, fmt.Errorf("failed to save product image: %w", err)
+	}
+
+	return s.sync(ctx, product)
+}
+
+func (s *ProductImageService) Update(ctx context.Context, user *model.User, req *model.UpdateProductImageRequest) (*model.Product, error) {
+	product, err := s.getProduct(ctx, user, req.ProductID)
+	if err != nil {
+		return nil, err
+	}
+
+	image, err := s.getImage(ctx, product, req.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	image.AltText = req.AltText
+	if err := s.imageRepo.Save(ctx, image); err != nil {
+		return nil, fmt.Errorf("failed to update product image: %w", err)
+	}
+
+	if req.IsPrimary && !image.IsPrimary {
+		if err := s.imageRepo.SetPrimary(ctx, product.ID, image.ID); err != nil {
+			return nil, fmt.Errorf("failed to set primary image: %w", err)
+		}
+	}
+
+	return s.sync(ctx, product)
+}
+
+func (s *ProductImageService) Reorder(ctx context.Context, user *model.User, req *model.ReorderProductImagesRequest) (*model.Product, error) {
+	product, err := s.getProduct(ctx, user, req.ProductID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(req.ImageIDs) != len(product.Images) {
+		return nil, ErrInvalidImageOrder
+	}
+
+	existing := make(map[int64]bool, len(product.Images))
+	for _, image := range product.Images {
+		existing[image.ID] = true
+	}
+	for _, id := range req.ImageIDs {
+		if !existing[id] {
+			return nil, ErrInvalidImageOrder
+		}
+		delete(existing, id)
+	}
+
+	if err := s.imageRepo.UpdatePositions(ctx, product.ID, req.ImageIDs); err != nil {
+		return nil, fmt.Errorf("failed to reorder product images: %w", err)
+	}
+
+	return s.sync(ctx, product)
+}
+
+func (s *ProductImageService) Delete(ctx context.Context, user *model.User, productID, id int64) (*model.Product, error) {
+	product, err := s.getProduct(ctx, user, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	image, err := s.getImage(ctx, product, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.imageRepo.Delete(ctx, image.ID); err != nil {
+		return nil, fmt.Errorf("failed to delete product image: %w", err)
+	}
+	s.uploadSvc.DeleteKeys(ctx, image.Keys)
+
+	// Promote the next image so the gallery keeps a primary
+	if image.IsPrimary {
+		for _, other := range product.Images {
+			if other.ID != image.ID {
+				if err := s.imageRepo.SetPrimary(ctx, product.ID, other.ID); err != nil {
+					return nil, fmt.Errorf("failed to set primary image: %w", err)
+				}
+				break
+			}
+		}
+	}
+
+	return s.sync(ctx, product)
+}
+
+func (s *ProductImageService) getProduct(ctx context.Context, user *model.User, productID int64) (*model.Product, error) {
+	store, err := s.storeRepo.GetByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get store: %w", err)
+	}
+
+	product, err := s.productRepo.GetByIDAndStoreID(ctx, productID, store.ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrProductNotFound
+		}
+		return nil, fmt.Errorf("failed to get product: %w", err)
+	}
+
+	return product, nil
+}
+
+func (s *ProductImageService) getImage(ctx context.Context, product *model.Product, id int64) (*model.ProductImage, error) {
+	image, err := s.imageRepo.GetByIDAndProductID(ctx, id, product.ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrProductImageNotFound
+		}
+		return nil, fmt.Errorf("failed to get product image: %w", err)
+	}
+	return image, nil
+}
+
+// sync reloads the gallery and mirrors the primary image into the product's
+// single image fields.
+func (s *ProductImageService) sync(ctx context.Context, product *model.Product) (*model.Product, error) {
+	images, err := s.imageRepo.GetByProductID(ctx, product.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product images: %w", err)
+	}
+	product.Images = images
+
+	var primary *model.ProductImage
+	for _, image := range images {
+		if image.IsPrimary {
+			primary = image
+			break
+		}
+	}
+
+	if primary != nil {
+		product.Image = primary.URL
+		product.ImageMedium = primary.MediumURL
+		product.ImageThumb = primary.ThumbURL
+	} else {
+		product.Image = ""
+		product.ImageMedium = ""
+		product.ImageThumb = ""
+	}
+
+	if err := s.productRepo.Save(ctx, product); err != nil {
+		return nil, fmt.Errorf("failed to update product: %w", err)
+	}
+	s.catalogCache.Invalidate(ctx, product.StoreID)
+
+	return product, nil
+}
@@ -0,0 +1,339 @@
+// internal/service/product_import.go
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"todo-go/internal/model"
+	"todo-go/pkg/spreadsheet"
+
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+)
+
+const maxImportRows = 2000
+
+var ErrInvalidImportFile = errors.New("invalid import file")
+
+// productColumns are the spreadsheet columns used by export and understood
+// by import. Only name and price are required when importing; columns left
+// out keep their current value on products matched by SKU.
+var productColumns = []string{"sku", "name", "description", "category", "price", "stock", "is_active", "image"}
+
+// importRow is one parsed spreadsheet row along with which optional
+// columns it carried.
+type importRow struct {
+	line     int
+	req      model.CreateProductRequest
+	isActive *bool
+	has      map[string]bool
+}
+
+// Import creates or updates products from a CSV or XLSX file. Rows with a
+// SKU that matches an existing product update it, all other rows create a
+// product. Every row is checked before anything is written; a single bad
+// row, or dryRun, leaves the catalog untouched.
+func (s *ProductService) Import(ctx context.Context, user *model.User, data []byte, dryRun bool) (*model.ImportProductsResult, error) {
+	store, err := s.storeRepo.GetByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get store: %w", err)
+	}
+
+	sheet, err := spreadsheet.Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidImportFile, err.Error())
+	}
+
+	rows, result, err := parseImportRows(sheet)
+	if err != nil {
+		return nil, err
+	}
+	result.DryRun = dryRun
+
+	categories := make(map[string]*model.Category)
+	seen := make(map[string]int)
+	var products []*model.Product
+	var movements [][]*model.StockMovement
+	for _, row := range rows {
+		rowErr := func(column string, err error) {
+			result.Errors = append(result.Errors, &model.ImportRowError{Row: row.line, Column: column, Error: err.Error()})
+		}
+
+		var product *model.Product
+		if sku := row.req.SKU; sku != "" {
+			if line, ok := seen[sku]; ok {
+				rowErr("sku", fmt.Errorf("sku already used on row %d", line))
+				continue
+			}
+			seen[sku] = row.line
+
+			product, err = s.productRepo.GetBySKUAndStoreID(ctx, sku, store.ID)
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, fmt.Errorf("failed to get product by sku: %w", err)
+			}
+			if err := s.checkSKU(ctx, store.ID, sku, idOf(product), 0); err != nil {
+				if errors.Is(err, ErrDuplicateSKU) {
+					rowErr("sku", err)
+					continue
+				}
+				return nil, err
+			}
+		}
+
+		var category *model.Category
+		if name := row.req.Category; name != "" {
+			var ok bool
+			if category, ok = categories[name]; !ok {
+				category, err = s.resolveCategory(ctx, store.ID, nil, name)
+				if err != nil && !errors.Is(err, ErrCategoryNotFound) {
+					return nil, err
+				}
+				categories[name] = category
+			}
+			if category == nil {
+				rowErr("category", ErrCategoryNotFound)
+				continue
+			}
+		}
+
+		var stock []*model.StockMovement
+		if product == nil {
+			product = &model.Product{StoreID: store.ID, IsActive: true, TrackStock: true}
+			stock = stockMovements(user, store.ID, 0, row.req.Stock, model.StockRestock, "initial stock")
+			result.Created++
+		} else {
+			if row.has["stock"] {
+				stock = stockMovements(user, store.ID, product.Stock, row.req.Stock, model.StockAdjustment, "stock set by import")
+			}
+			result.Updated++
+		}
+		applyImportRow(product, row, category)
+		products = append(products, product)
+		movements = append(movements, stock)
+	}
+
+	if dryRun || len(result.Errors) > 0 {
+		return result, nil
+	}
+
+	if err := s.productRepo.SaveAll(ctx, products, movements); err != nil {
+		// Another import or create took one of the SKUs meanwhile
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, ErrDuplicateSKU
+		}
+		return nil, fmt.Errorf("failed to save products: %w", err)
+	}
+	for _, product := range products {
+		s.index(ctx, product)
+	}
+	s.catalogCache.Invalidate(ctx, store.ID)
+
+	return result, nil
+}
+
+// Export writes every live product of the store, active or not, as one row
+// per product under a header of productColumns. The writer is only opened
+// once the store is known, so callers can still report that error cleanly.
+func (s *ProductService) Export(ctx context.Context, user *model.User, open func() (spreadsheet.Writer, error)) error {
+	store, err := s.storeRepo.GetByUserID(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get store: %w", err)
+	}
+
+	w, err := open()
+	if err != nil {
+		return fmt.Errorf("failed to start export: %w", err)
+	}
+
+	header := make([]any, len(productColumns))
+	for i, column := range productColumns {
+		header[i] = column
+	}
+	if err := w.WriteRow(header); err != nil {
+		return err
+	}
+
+	err = s.productRepo.EachByStoreID(ctx, store.ID, model.MaxListLimit, func(products []*model.Product) error {
+		for _, p := range products {
+			row := []any{p.SKU, p.Name, p.Description, p.Category, p.Price, p.Stock, p.IsActive, p.Image}
+			if err := w.WriteRow(row); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to export products: %w", err)
+	}
+
+	return w.Close()
+}
+
+// parseImportRows maps the sheet onto productColumns by header name and
+// checks each row against the CreateProductRequest rules. Rows that fail
+// are reported in the result and left out of the returned rows.
+func parseImportRows(sheet [][]string) ([]*importRow, *model.ImportProductsResult, error) {
+	start := 0
+	for start < len(sheet) && isBlankRow(sheet[start]) {
+		start++
+	}
+	if start == len(sheet) {
+		return nil, nil, fmt.Errorf("%w: file is empty", ErrInvalidImportFile)
+	}
+
+	known := make(map[string]bool, len(productColumns))
+	for _, column := range productColumns {
+		known[column] = true
+	}
+	columns := make(map[string]int)
+	for i, cell := range sheet[start] {
+		name := strings.ToLower(strings.TrimSpace(cell))
+		if _, dup := columns[name]; known[name] && !dup {
+			columns[name] = i
+		}
+	}
+	for _, required := range []string{"name", "price"} {
+		if _, ok := columns[required]; !ok {
+			return nil, nil, fmt.Errorf("%w: missing column %q", ErrInvalidImportFile, required)
+		}
+	}
+
+	validate := validator.New(validator.WithRequiredStructEnabled())
+	result := &model.ImportProductsResult{}
+	var rows []*importRow
+	for i := start + 1; i < len(sheet); i++ {
+		if isBlankRow(sheet[i]) {
+			continue
+		}
+		result.Rows++
+		if result.Rows > maxImportRows {
+			return nil, nil, fmt.Errorf("%w: at most %d rows can be imported at once", ErrInvalidImportFile, maxImportRows)
+		}
+
+		row := &importRow{line: i + 1, has: make(map[string]bool)}
+		cell := func(column string) string {
+			index, ok := columns[column]
+			row.has[column] = ok
+			if !ok || index >= len(sheet[i]) {
+				return ""
+			}
+			return strings.TrimSpace(sheet[i][index])
+		}
+		reported := make(map[string]bool)
+		rowErr := func(column, message string) {
+			if reported[column] {
+				return
+			}
+			reported[column] = true
+			result.Errors = append(result.Errors, &model.ImportRowError{Row: row.line, Column: column, Error: message})
+		}
+
+		row.req = model.CreateProductRequest{
+			SKU:         cell("sku"),
+			Name:        cell("name"),
+			Description: cell("description"),
+			Category:    cell("category"),
+			Image:       cell("image"),
+		}
+
+		if v := cell("price"); v != "" {
+			price, err := parseDecimal(v)
+			if err != nil {
+				rowErr("price", "price must be a number")
+			}
+			row.req.Price = price
+		}
+		if v := cell("stock"); v != "" {
+			stock, err := parseDecimal(v)
+			if err != nil || stock != float64(int(stock)) {
+				rowErr("stock", "stock must be a whole number")
+			}
+			row.req.Stock = int(stock)
+		}
+		if v := cell("is_active"); v != "" {
+			active, err := strconv.ParseBool(strings.ToLower(v))
+			if err != nil {
+				rowErr("is_active", "is_active must be true or false")
+			}
+			row.isActive = &active
+		} else {
+			delete(row.has, "is_active")
+		}
+
+		if err := validate.Struct(&row.req); err != nil {
+			var verrs validator.ValidationErrors
+			if !errors.As(err, &verrs) {
+				return nil, nil, err
+			}
+			for _, fe := range verrs {
+				column := strings.ToLower(fe.Field())
+				rowErr(column, fmt.Sprintf("%s failed on the %q rule", column, fe.Tag()))
+			}
+		}
+
+		if len(reported) == 0 {
+			rows = append(rows, row)
+		}
+	}
+
+	return rows, result, nil
+}
+
+func applyImportRow(product *model.Product, row *importRow, category *model.Category) {
+	req := row.req
+	product.SKU = req.SKU
+	product.Name = req.Name
+	product.Price = req.Price
+	if row.has["description"] {
+		product.Description = req.Description
+	}
+	if row.has["category"] {
+		product.CategoryID = nil
+		product.Category = ""
+		if category != nil {
+			product.CategoryID = &category.ID
+			product.Category = category.Name
+		}
+	}
+	if row.has["image"] && product.Image != req.Image {
+		product.Image = req.Image
+		product.ImageMedium = ""
+		product.ImageThumb = ""
+	}
+	if row.isActive != nil {
+		product.IsActive = *row.isActive
+	}
+}
+
+// parseDecimal accepts a plain number as well as a decimal comma, as
+// written by spreadsheets in the Indonesian locale.
+func parseDecimal(s string) (float64, error) {
+	if strings.Contains(s, ",") && !strings.Contains(s, ".") {
+		s = strings.Replace(s, ",", ".", 1)
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0, errors.New("not a number")
+	}
+	return f, nil
+}
+
+func isBlankRow(row []string) bool {
+	for _, cell := range row {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func idOf(product *model.Product) int64 {
+	if product == nil {
+		return 0
+	}
+	return product.ID
+}
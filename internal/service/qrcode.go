@@ -0,0 +1,439 @@
+// internal/service/qrcode.go
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"todo-go/internal/model"
+	"todo-go/internal/repository"
+	"todo-go/pkg/qr"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidQRCode  = errors.New("invalid QR code options")
+	ErrQRCodeNotFound = errors.New("QR code not found")
+)
+
+const (
+	// pdfCodeSize keeps the code sharp on paper unless a size is asked for
+	pdfCodeSize = 1024
+
+	defaultQRCodeText = "Scan untuk lihat katalog & pesan"
+
+	// Sources given to codes created without one
+	qrSourceTable = "table"
+	qrSourceOther = "qr"
+
+	DefaultQRStatsDays = 30
+)
+
+// shortCode spells short link codes in lowercase letters and digits
+var shortCode = base32.NewEncoding("abcdefghijklmnopqrstuvwxyz234567").WithPadding(base32.NoPadding)
+
+// QRCodeFile is a rendered QR code ready to download.
+type QRCodeFile struct {
+	Data        []byte
+	ContentType string
+	Filename    string
+}
+
+var qrContentTypes = map[string]string{
+	model.QRCodePNG: "image/png",
+	model.QRCodeSVG: "image/svg+xml",
+	model.QRCodePDF: "application/pdf",
+}
+
+type QRCodeService struct {
+	qrCodeRepo  *repository.QRCodeRepository
+	websiteRepo *repository.WebsiteRepository
+	storeRepo   *repository.StoreRepository
+	productRepo *repository.ProductRepository
+	uploadSvc   *UploadService
+	qrSvc       *qr.Service
+}
+
+func NewQRCodeService(qrCodeRepo *repository.QRCodeRepository, websiteRepo *repository.WebsiteRepository, storeRepo *repository.StoreRepository, productRepo *repository.ProductRepository, uploadSvc *UploadService, qrSvc *qr.Service) *QRCodeService {
+	return &QRCodeService{
+		qrCodeRepo:  qrCodeRepo,
+		websiteRepo: websiteRepo,
+		storeRepo:   storeRepo,
+		productRepo: productRepo,
+		uploadSvc:   uploadSvc,
+		qrSvc:       qrSvc,
+	}
+}
+
+// Catalog renders a QR code linking to the user's catalog under baseURL.
+func (s *QRCodeService) Catalog(ctx context.Context, user *model.User, baseURL string, opts *model.QRCodeOptions) (*QRCodeFile, error) {
+	store, website, err := s.website(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.render(ctx, store, baseURL+"/catalog/"+website.Domain, "qr-catalog-"+website.Domain, defaultQRCodeText, opts)
+}
+
+// Create adds a short link QR code to the user's store. The website must
+// exist for the link to lead anywhere.
+func (s *QRCodeService) Create(ctx context.Context, user *model.User, req *model.CreateQRCodeRequest) (*model.QRCode, error) {
+	store, _, err := s.website(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.ProductID != nil {
+		if _, err := s.product(ctx, store.ID, *req.ProductID); err != nil {
+			return nil, err
+		}
+	}
+
+	code, err := newShortCode()
+	if err != nil {
+		return nil, err
+	}
+
+	qrCode := &model.QRCode{
+		StoreID:     store.ID,
+		Code:        code,
+		Name:        strings.TrimSpace(req.Name),
+		Source:      strings.TrimSpace(req.Source),
+		ProductID:   req.ProductID,
+		TableNumber: strings.TrimSpace(req.TableNumber),
+	}
+	if qrCode.Source == "" {
+		qrCode.Source = qrSourceOther
+		if qrCode.TableNumber != "" {
+			qrCode.Source = qrSourceTable
+		}
+	}
+
+	if err := s.qrCodeRepo.Save(ctx, qrCode); err != nil {
+		return nil, fmt.Errorf("failed to save QR code: %w", err)
+	}
+
+	return qrCode, nil
+}
+
+// List returns one page of the store's QR codes with their scan and order
+// counts.
+func (s *QRCodeService) List(ctx context.Context, user *model.User, opts *model.ListOptions) ([]*model.QRCode, *model.PageInfo, error) {
+	store, err := s.store(ctx, user)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	qrCodes, pageInfo, err := s.qrCodeRepo.ListByStoreID(ctx, store.ID, opts)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get QR codes: %w", err)
+	}
+
+	ids := make([]int64, len(qrCodes))
+	for i, qrCode := range qrCodes {
+		ids[i] = qrCode.ID
+	}
+	scans, err := s.qrCodeRepo.CountScans(ctx, ids)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to count scans: %w", err)
+	}
+	orders, err := s.qrCodeRepo.CountOrders(ctx, ids)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to count orders: %w", err)
+	}
+	for _, qrCode := range qrCodes {
+		qrCode.Scans = scans[qrCode.ID]
+		qrCode.Orders = orders[qrCode.ID]
+	}
+
+	return qrCodes, pageInfo, nil
+}
+
+// Delete removes a QR code; its short link stops working.
+func (s *QRCodeService) Delete(ctx context.Context, user *model.User, id int64) error {
+	qrCode, _, err := s.get(ctx, user, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.qrCodeRepo.Delete(ctx, qrCode.ID); err != nil {
+		return fmt.Errorf("failed to delete QR code: %w", err)
+	}
+
+	return nil
+}
+
+// Image renders a QR code for the short link of one of the user's codes.
+// PDF sheets name the table or product under the code by default.
+func (s *QRCodeService) Image(ctx context.Context, user *model.User, id int64, baseURL string, opts *model.QRCodeOptions) (*QRCodeFile, error) {
+	qrCode, store, err := s.get(ctx, user, id)
+	if err != nil {
+		return nil, err
+	}
+
+	caption := defaultQRCodeText
+	switch {
+	case qrCode.TableNumber != "":
+		caption = fmt.Sprintf("Meja %s - Scan untuk pesan", qrCode.TableNumber)
+	case qrCode.ProductID != nil:
+		if product, err := s.product(ctx, store.ID, *qrCode.ProductID); err == nil {
+			caption = fmt.Sprintf("%s - Scan untuk pesan", product.Name)
+		}
+	}
+
+	return s.render(ctx, store, baseURL+"/q/"+qrCode.Code, "qr-"+qrCode.Code, caption, opts)
+}
+
+// Stats sums up a code's scans and orders over the last days days.
+func (s *QRCodeService) Stats(ctx context.Context, user *model.User, id int64, days int) (*model.QRCodeStats, error) {
+	qrCode, _, err := s.get(ctx, user, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if days <= 0 {
+		days = DefaultQRStatsDays
+	}
+	now := time.Now()
+	since := time.Date(now.Year(), now.Month(), now.Day()-days+1, 0, 0, 0, 0, now.Location())
+
+	stats := &model.QRCodeStats{QRCode: qrCode, Days: days}
+	if stats.ByDay, err = s.qrCodeRepo.ScansByDay(ctx, qrCode.ID, since); err != nil {
+		return nil, fmt.Errorf("failed to count scans: %w", err)
+	}
+	if stats.BySource, err = s.qrCodeRepo.ScansBySource(ctx, qrCode.ID, since); err != nil {
+		return nil, fmt.Errorf("failed to count scans: %w", err)
+	}
+	userAgents, err := s.qrCodeRepo.ScansByUserAgent(ctx, qrCode.ID, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count scans: %w", err)
+	}
+	stats.ByDevice = byDevice(userAgents)
+	for _, day := range stats.ByDay {
+		stats.Scans += day.Scans
+	}
+	if stats.Orders, stats.Revenue, err = s.qrCodeRepo.OrderTotals(ctx, qrCode.ID, since); err != nil {
+		return nil, fmt.Errorf("failed to count orders: %w", err)
+	}
+
+	return stats, nil
+}
+
+// Scan records a visit through a short link and returns where to send the
+// customer: the catalog, or the product page, carrying the code and table
+// number for the order form. source overrides the code's own when set.
+// Failing to record the scan does not stop the customer.
+func (s *QRCodeService) Scan(ctx context.Context, code, source, userAgent string) (string, error) {
+	qrCode, err := s.qrCodeRepo.GetByCode(ctx, code)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", ErrQRCodeNotFound
+		}
+		return "", fmt.Errorf("failed to get QR code: %w", err)
+	}
+
+	website, err := s.websiteRepo.GetByStoreID(ctx, qrCode.StoreID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", ErrQRCodeNotFound
+		}
+		return "", fmt.Errorf("failed to get website: %w", err)
+	}
+
+	source = truncate(strings.TrimSpace(source), 50)
+	if source == "" {
+		source = qrCode.Source
+	}
+	scan := &model.QRScan{
+		QRCodeID:  qrCode.ID,
+		StoreID:   qrCode.StoreID,
+		Source:    source,
+		UserAgent: truncate(userAgent, 255),
+		ScannedAt: time.Now(),
+	}
+	if err := s.qrCodeRepo.RecordScan(ctx, scan); err != nil {
+		log.Printf("failed to record scan of QR code %d: %s", qrCode.ID, err.Error())
+	}
+
+	target := "/catalog/" + website.Domain
+	if qrCode.ProductID != nil {
+		target += "/products/" + strconv.FormatInt(*qrCode.ProductID, 10)
+	}
+	query := url.Values{"qr": {qrCode.Code}}
+	if qrCode.TableNumber != "" {
+		query.Set("table", qrCode.TableNumber)
+	}
+	return target + "?" + query.Encode(), nil
+}
+
+func (s *QRCodeService) store(ctx context.Context, user *model.User) (*model.Store, error) {
+	store, err := s.storeRepo.GetByUserID(ctx, user.ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrStoreNotFound
+		}
+		return nil, fmt.Errorf("failed to get store: %w", err)
+	}
+	return store, nil
+}
+
+// get returns one of the user's QR codes together with the store.
+func (s *QRCodeService) get(ctx context.Context, user *model.User, id int64) (*model.QRCode, *model.Store, error) {
+	store, err := s.store(ctx, user)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	qrCode, err := s.qrCodeRepo.GetByIDAndStoreID(ctx, id, store.ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrQRCodeNotFound
+		}
+		return nil, nil, fmt.Errorf("failed to get QR code: %w", err)
+	}
+
+	return qrCode, store, nil
+}
+
+func (s *QRCodeService) product(ctx context.Context, storeID, productID int64) (*model.Product, error) {
+	product, err := s.productRepo.GetByIDAndStoreID(ctx, productID, storeID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrProductNotFound
+		}
+		return nil, fmt.Errorf("failed to get product: %w", err)
+	}
+	return product, nil
+}
+
+func (s *QRCodeService) website(ctx context.Context, user *model.User) (*model.Store, *model.Website, error) {
+	store, err := s.store(ctx, user)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	website, err := s.websiteRepo.GetByStoreID(ctx, store.ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrWebsiteNotFound
+		}
+		return nil, nil, fmt.Errorf("failed to get website: %w", err)
+	}
+
+	return store, website, nil
+}
+
+// render encodes text in the requested format. PDF sheets carry the store
+// name above the code, the call to action below (caption unless opts has
+// one) and text at the bottom.
+func (s *QRCodeService) render(ctx context.Context, store *model.Store, text, filename, caption string, opts *model.QRCodeOptions) (*QRCodeFile, error) {
+	format := opts.Format
+	if format == "" {
+		format = model.QRCodePNG
+	}
+
+	qrOpts := qr.Options{
+		Size:       opts.Size,
+		Level:      opts.Level,
+		Foreground: opts.Foreground,
+		Background: opts.Background,
+	}
+	if format == model.QRCodePDF && qrOpts.Size == 0 {
+		qrOpts.Size = pdfCodeSize
+	}
+	if opts.Logo {
+		logo, err := s.uploadSvc.StoreLogo(ctx, store)
+		if err != nil {
+			return nil, err
+		}
+		qrOpts.Logo = logo
+	}
+
+	code, err := s.qrSvc.New(text, qrOpts)
+	if err != nil {
+		if errors.Is(err, qr.ErrInvalidLevel) || errors.Is(err, qr.ErrInvalidColor) ||
+			errors.Is(err, qr.ErrLowContrast) || errors.Is(err, qr.ErrTooSmall) {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidQRCode, err)
+		}
+		return nil, err
+	}
+
+	var data []byte
+	switch format {
+	case model.QRCodeSVG:
+		data, err = code.SVG()
+	case model.QRCodePDF:
+		if opts.Text != "" {
+			caption = opts.Text
+		}
+		data, err = code.PDF(qr.Sheet{Title: store.Name, Caption: caption, Footer: text})
+	default:
+		data, err = code.PNG()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &QRCodeFile{
+		Data:        data,
+		ContentType: qrContentTypes[format],
+		Filename:    filename + "." + format,
+	}, nil
+}
+
+// byDevice folds user agent counts into the kind of device that scanned,
+// most scans first.
+func byDevice(userAgents []*model.QRScanCount) []*model.QRScanCount {
+	totals := make(map[string]int64)
+	for _, count := range userAgents {
+		ua := strings.ToLower(count.Key)
+		device := "other"
+		switch {
+		case strings.Contains(ua, "android"):
+			device = "android"
+		case strings.Contains(ua, "iphone"), strings.Contains(ua, "ipad"):
+			device = "ios"
+		case strings.Contains(ua, "windows"), strings.Contains(ua, "macintosh"), strings.Contains(ua, "linux"):
+			device = "desktop"
+		}
+		totals[device] += count.Scans
+	}
+
+	devices := make([]*model.QRScanCount, 0, len(totals))
+	for device, scans := range totals {
+		devices = append(devices, &model.QRScanCount{Key: device, Scans: scans})
+	}
+	sort.Slice(devices, func(i, j int) bool {
+		if devices[i].Scans != devices[j].Scans {
+			return devices[i].Scans > devices[j].Scans
+		}
+		return devices[i].Key < devices[j].Key
+	})
+	return devices
+}
+
+func newShortCode() (string, error) {
+	b := make([]byte, 5)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate code: %w", err)
+	}
+	return shortCode.EncodeToString(b), nil
+}
+
+// truncate cuts s to at most n characters.
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}
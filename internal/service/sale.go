@@ -0,0 +1,160 @@
+// internal/service/sale.go
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"todo-go/internal/model"
+	"todo-go/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrSaleNotFound = errors.New("sale not found")
+	ErrInvalidSale  = errors.New("a percent sale takes at most 100 percent off")
+)
+
+type SaleService struct {
+	saleRepo     *repository.SaleRepository
+	productRepo  *repository.ProductRepository
+	categoryRepo *repository.CategoryRepository
+	storeRepo    *repository.StoreRepository
+	catalogCache *CatalogCache
+}
+
+func NewSaleService(saleRepo *repository.SaleRepository, productRepo *repository.ProductRepository, categoryRepo *repository.CategoryRepository, storeRepo *repository.StoreRepository, catalogCache *CatalogCache) *SaleService {
+	return &SaleService{
+		saleRepo:     saleRepo,
+		productRepo:  productRepo,
+		categoryRepo: categoryRepo,
+		storeRepo:    storeRepo,
+		catalogCache: catalogCache,
+	}
+}
+
+func (s *SaleService) Create(ctx context.Context, user *model.User, req *model.CreateSaleRequest) (*model.Sale, error) {
+	store, err := s.storeRepo.GetByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get store: %w", err)
+	}
+
+	sale := &model.Sale{
+		StoreID:    store.ID,
+		Name:       req.Name,
+		ProductID:  req.ProductID,
+		CategoryID: req.CategoryID,
+		Type:       req.Type,
+		Value:      req.Value,
+		StartsAt:   req.StartsAt,
+		EndsAt:     req.EndsAt,
+	}
+	if err := s.check(ctx, sale); err != nil {
+		return nil, err
+	}
+
+	if err := s.saleRepo.Save(ctx, sale); err != nil {
+		return nil, fmt.Errorf("failed to save sale: %w", err)
+	}
+	s.catalogCache.Invalidate(ctx, store.ID)
+
+	return sale, nil
+}
+
+func (s *SaleService) List(ctx context.Context, user *model.User, opts *model.ListOptions) ([]*model.Sale, *model.PageInfo, error) {
+	store, err := s.storeRepo.GetByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get store: %w", err)
+	}
+
+	sales, pageInfo, err := s.saleRepo.ListByStoreID(ctx, store.ID, opts)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get sales: %w", err)
+	}
+
+	return sales, pageInfo, nil
+}
+
+func (s *SaleService) Update(ctx context.Context, user *model.User, req *model.UpdateSaleRequest) (*model.Sale, error) {
+	store, err := s.storeRepo.GetByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get store: %w", err)
+	}
+
+	sale, err := s.saleRepo.GetByIDAndStoreID(ctx, req.ID, store.ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSaleNotFound
+		}
+		return nil, fmt.Errorf("failed to get sale: %w", err)
+	}
+
+	sale.Name = req.Name
+	sale.ProductID = req.ProductID
+	sale.CategoryID = req.CategoryID
+	sale.Type = req.Type
+	sale.Value = req.Value
+	sale.StartsAt = req.StartsAt
+	sale.EndsAt = req.EndsAt
+	if err := s.check(ctx, sale); err != nil {
+		return nil, err
+	}
+
+	if err := s.saleRepo.Save(ctx, sale); err != nil {
+		return nil, fmt.Errorf("failed to update sale: %w", err)
+	}
+	s.catalogCache.Invalidate(ctx, store.ID)
+
+	return sale, nil
+}
+
+func (s *SaleService) Delete(ctx context.Context, user *model.User, id int64) error {
+	store, err := s.storeRepo.GetByUserID(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get store: %w", err)
+	}
+
+	if _, err := s.saleRepo.GetByIDAndStoreID(ctx, id, store.ID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrSaleNotFound
+		}
+		return fmt.Errorf("failed to get sale: %w", err)
+	}
+
+	if err := s.saleRepo.Delete(ctx, id); err != nil {
+		return fmt.Errorf("failed to delete sale: %w", err)
+	}
+	s.catalogCache.Invalidate(ctx, store.ID)
+
+	return nil
+}
+
+// check makes sure the discount is sensible and the product or category
+// belongs to the sale's store.
+func (s *SaleService) check(ctx context.Context, sale *model.Sale) error {
+	if sale.Type == model.SalePercent && sale.Value > 100 {
+		return ErrInvalidSale
+	}
+
+	if sale.ProductID != nil {
+		_, err := s.productRepo.GetByIDAndStoreID(ctx, *sale.ProductID, sale.StoreID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrProductNotFound
+			}
+			return fmt.Errorf("failed to get product: %w", err)
+		}
+	}
+	if sale.CategoryID != nil {
+		_, err := s.categoryRepo.GetByIDAndStoreID(ctx, *sale.CategoryID, sale.StoreID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrCategoryNotFound
+			}
+			return fmt.Errorf("failed to get category: %w", err)
+		}
+	}
+
+	return nil
+}
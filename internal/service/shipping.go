@@ -0,0 +1,305 @@
+// internal/service/shipping.go
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"todo-go/internal/model"
+	"todo-go/internal/repository"
+	"todo-go/pkg/shipping"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrShippingMethodNotFound = errors.New("shipping method not found")
+	ErrInvalidShippingMethod  = errors.New("courier methods need weight_rates or a carrier")
+
+	// ErrInvalidShipping is returned when an order's delivery choice cannot
+	// be fulfilled
+	ErrInvalidShipping = errors.New("invalid shipping")
+)
+
+type ShippingService struct {
+	shippingRepo *repository.ShippingMethodRepository
+	storeRepo    *repository.StoreRepository
+	websiteRepo  *repository.WebsiteRepository
+	productRepo  *repository.ProductRepository
+	rater        shipping.Rater
+}
+
+func NewShippingService(shippingRepo *repository.ShippingMethodRepository, storeRepo *repository.StoreRepository, websiteRepo *repository.WebsiteRepository, productRepo *repository.ProductRepository, rater shipping.Rater) *ShippingService {
+	return &ShippingService{
+		shippingRepo: shippingRepo,
+		storeRepo:    storeRepo,
+		websiteRepo:  websiteRepo,
+		productRepo:  productRepo,
+		rater:        rater,
+	}
+}
+
+func (s *ShippingService) Create(ctx context.Context, user *model.User, req *model.CreateShippingMethodRequest) (*model.ShippingMethod, error) {
+	store, err := s.storeRepo.GetByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get store: %w", err)
+	}
+
+	method := &model.ShippingMethod{
+		StoreID:       store.ID,
+		Name:          req.Name,
+		Type:          req.Type,
+		Fee:           req.Fee,
+		PerKmFee:      req.PerKmFee,
+		MaxDistanceKm: req.MaxDistanceKm,
+		Carrier:       req.Carrier,
+		Service:       req.Service,
+		WeightRates:   req.WeightRates,
+		Position:      req.Position,
+		IsActive:      req.IsActive,
+	}
+	if err := prepareShippingMethod(method); err != nil {
+		return nil, err
+	}
+
+	if err := s.shippingRepo.Save(ctx, method); err != nil {
+		return nil, fmt.Errorf("failed to save shipping method: %w", err)
+	}
+
+	return method, nil
+}
+
+func (s *ShippingService) GetAll(ctx context.Context, user *model.User) ([]*model.ShippingMethod, error) {
+	store, err := s.storeRepo.GetByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get store: %w", err)
+	}
+
+	methods, err := s.shippingRepo.GetByStoreID(ctx, store.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get shipping methods: %w", err)
+	}
+
+	return methods, nil
+}
+
+func (s *ShippingService) Update(ctx context.Context, user *model.User, req *model.UpdateShippingMethodRequest) (*model.ShippingMethod, error) {
+	store, err := s.storeRepo.GetByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get store: %w", err)
+	}
+
+	method, err := s.shippingRepo.GetByIDAndStoreID(ctx, req.ID, store.ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrShippingMethodNotFound
+		}
+		return nil, fmt.Errorf("failed to get shipping method: %w", err)
+	}
+
+	method.Name = req.Name
+	method.Type = req.Type
+	method.Fee = req.Fee
+	method.PerKmFee = req.PerKmFee
+	method.MaxDistanceKm = req.MaxDistanceKm
+	method.Carrier = req.Carrier
+	method.Service = req.Service
+	method.WeightRates = req.WeightRates
+	method.Position = req.Position
+	method.IsActive = req.IsActive
+	if err := prepareShippingMethod(method); err != nil {
+		return nil, err
+	}
+
+	if err := s.shippingRepo.Save(ctx, method); err != nil {
+		return nil, fmt.Errorf("failed to update shipping method: %w", err)
+	}
+
+	return method, nil
+}
+
+func (s *ShippingService) Delete(ctx context.Context, user *model.User, id int64) error {
+	store, err := s.storeRepo.GetByUserID(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get store: %w", err)
+	}
+
+	if _, err := s.shippingRepo.GetByIDAndStoreID(ctx, id, store.ID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrShippingMethodNotFound
+		}
+		return fmt.Errorf("failed to get shipping method: %w", err)
+	}
+
+	if err := s.shippingRepo.Delete(ctx, id); err != nil {
+		return fmt.Errorf("failed to delete shipping method: %w", err)
+	}
+
+	return nil
+}
+
+// GetPublic lists the delivery options of a published store.
+func (s *ShippingService) GetPublic(ctx context.Context, domain string) ([]*model.ShippingMethod, error) {
+	website, err := s.websiteRepo.GetByDomain(ctx, domain)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrWebsiteNotFound
+		}
+		return nil, fmt.Errorf("failed to get website: %w", err)
+	}
+
+	methods, err := s.shippingRepo.GetActiveByStoreID(ctx, website.StoreID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get shipping methods: %w", err)
+	}
+
+	return methods, nil
+}
+
+// Quote prices every delivery option of a published store for a basket.
+// Options that cannot deliver it are listed with the reason.
+func (s *ShippingService) Quote(ctx context.Context, domain string, req *model.ShippingQuoteRequest) ([]*model.ShippingQuote, error) {
+	website, err := s.websiteRepo.GetByDomain(ctx, domain)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrWebsiteNotFound
+		}
+		return nil, fmt.Errorf("failed to get website: %w", err)
+	}
+
+	store, err := s.storeRepo.GetByID(ctx, website.StoreID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get store: %w", err)
+	}
+
+	weight := 0
+	for _, item := range req.Items {
+		product, err := s.productRepo.GetByIDAndStoreID(ctx, item.ProductID, store.ID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, fmt.Errorf("%w: product %d not found", ErrInvalidOrderItem, item.ProductID)
+			}
+			return nil, fmt.Errorf("failed to get product: %w", err)
+		}
+		weight += product.Weight * item.Quantity
+	}
+
+	methods, err := s.shippingRepo.GetActiveByStoreID(ctx, store.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get shipping methods: %w", err)
+	}
+
+	quotes := make([]*model.ShippingQuote, 0, len(methods))
+	for _, method := range methods {
+		quote := &model.ShippingQuote{Method: method}
+		quote.Fee, err = s.Fee(ctx, store, method, weight, req.ShippingAddress)
+		if err != nil {
+			if !errors.Is(err, ErrInvalidShipping) {
+				return nil, err
+			}
+			quote.Error = err.Error()
+		}
+		quotes = append(quotes, quote)
+	}
+
+	return quotes, nil
+}
+
+// ForOrder resolves the delivery choice of an order and its fee. Stores
+// without active methods keep taking orders without one.
+func (s *ShippingService) ForOrder(ctx context.Context, store *model.Store, methodID int64, address *model.ShippingAddress, weight int) (*model.ShippingMethod, float64, error) {
+	if methodID == 0 {
+		methods, err := s.shippingRepo.GetActiveByStoreID(ctx, store.ID)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to get shipping methods: %w", err)
+		}
+		if len(methods) > 0 {
+			return nil, 0, fmt.Errorf("%w: choose a shipping_method_id", ErrInvalidShipping)
+		}
+		return nil, 0, nil
+	}
+
+	method, err := s.shippingRepo.GetByIDAndStoreID(ctx, methodID, store.ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, 0, fmt.Errorf("%w: shipping method %d not found", ErrInvalidShipping, methodID)
+		}
+		return nil, 0, fmt.Errorf("failed to get shipping method: %w", err)
+	}
+	if !method.IsActive {
+		return nil, 0, fmt.Errorf("%w: shipping method %d is not available", ErrInvalidShipping, methodID)
+	}
+
+	fee, err := s.Fee(ctx, store, method, weight, address)
+	if err != nil {
+		return nil, 0, err
+	}
+	return method, fee, nil
+}
+
+// Fee works out what a method charges to deliver weight grams to address.
+func (s *ShippingService) Fee(ctx context.Context, store *model.Store, method *model.ShippingMethod, weight int, address *model.ShippingAddress) (float64, error) {
+	if method.Type == model.ShippingPickup {
+		return 0, nil
+	}
+	if address == nil {
+		return 0, fmt.Errorf("%w: %s needs a shipping_address", ErrInvalidShipping, method.Name)
+	}
+
+	switch method.Type {
+	case model.ShippingFlat:
+		return method.Fee, nil
+
+	case model.ShippingPerKm:
+		if store.Latitude == nil || store.Longitude == nil {
+			return 0, fmt.Errorf("%w: store location is not set", ErrInvalidShipping)
+		}
+		if address.Latitude == nil || address.Longitude == nil {
+			return 0, fmt.Errorf("%w: %s needs the address latitude and longitude", ErrInvalidShipping, method.Name)
+		}
+		distance := model.DistanceKm(*store.Latitude, *store.Longitude, *address.Latitude, *address.Longitude)
+		if method.MaxDistanceKm > 0 && distance > method.MaxDistanceKm {
+			return 0, fmt.Errorf("%w: %s delivers up to %.0f km, address is %.1f km away", ErrInvalidShipping, method.Name, method.MaxDistanceKm, distance)
+		}
+		return method.Fee + method.PerKmFee*math.Ceil(distance), nil
+
+	case model.ShippingCourier:
+		if len(method.WeightRates) > 0 {
+			for _, rate := range method.WeightRates {
+				if weight <= rate.MaxWeight {
+					return rate.Fee, nil
+				}
+			}
+			return 0, fmt.Errorf("%w: order is too heavy for %s", ErrInvalidShipping, method.Name)
+		}
+
+		fee, err := s.rater.Rate(ctx, shipping.RateRequest{
+			Carrier:     method.Carrier,
+			Service:     method.Service,
+			Origin:      store.PostalCode,
+			Destination: address.PostalCode,
+			Weight:      weight,
+		})
+		if err != nil {
+			if errors.Is(err, shipping.ErrNoRate) {
+				return 0, fmt.Errorf("%w: %s: %s", ErrInvalidShipping, method.Name, err.Error())
+			}
+			return 0, fmt.Errorf("failed to get carrier rate: %w", err)
+		}
+		return fee, nil
+	}
+
+	return 0, fmt.Errorf("%w: unknown shipping method type %q", ErrInvalidShipping, method.Type)
+}
+
+// prepareShippingMethod checks that a courier method can be priced and
+// keeps its weight table sorted.
+func prepareShippingMethod(method *model.ShippingMethod) error {
+	if method.Type == model.ShippingCourier && len(method.WeightRates) == 0 && method.Carrier == "" {
+		return ErrInvalidShippingMethod
+	}
+	slices.SortFunc(method.WeightRates, func(a, b model.WeightRate) int { return a.MaxWeight - b.MaxWeight })
+	return nil
+}
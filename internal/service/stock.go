@@ -0,0 +1,316 @@
+// internal/service/stock.go
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"todo-go/internal/model"
+	"todo-go/internal/repository"
+	"todo-go/pkg/notify"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidStockMovement = errors.New("restocks and returns must add stock")
+	ErrVariantRequired      = errors.New("product has variants, stock is kept per variant_id")
+
+	// ErrInsufficientStock is returned when a movement would take a balance
+	// below zero
+	ErrInsufficientStock = repository.ErrInsufficientStock
+)
+
+type StockService struct {
+	stockRepo    *repository.StockRepository
+	productRepo  *repository.ProductRepository
+	storeRepo    *repository.StoreRepository
+	userRepo     *repository.UserRepository
+	notifier     notify.Notifier
+	catalogCache *CatalogCache
+}
+
+func NewStockService(stockRepo *repository.StockRepository, productRepo *repository.ProductRepository, storeRepo *repository.StoreRepository, userRepo *repository.UserRepository, notifier notify.Notifier, catalogCache *CatalogCache) *StockService {
+	return &StockService{
+		stockRepo:    stockRepo,
+		productRepo:  productRepo,
+		storeRepo:    storeRepo,
+		userRepo:     userRepo,
+		notifier:     notifier,
+		catalogCache: catalogCache,
+	}
+}
+
+// Record books a manual restock, return or adjustment by the store owner.
+func (s *StockService) Record(ctx context.Context, user *model.User, req *model.CreateStockMovementRequest) (*model.StockMovement, error) {
+	store, err := s.storeRepo.GetByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get store: %w", err)
+	}
+
+	product, err := s.productRepo.GetByIDAndStoreID(ctx, req.ProductID, store.ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrProductNotFound
+		}
+		return nil, fmt.Errorf("failed to get product: %w", err)
+	}
+
+	if req.VariantID != nil {
+		if findVariant(product, *req.VariantID) == nil {
+			return nil, ErrVariantNotFound
+		}
+	} else if len(product.Variants) > 0 {
+		return nil, ErrVariantRequired
+	}
+	if req.Type != model.StockAdjustment && req.Quantity < 0 {
+		return nil, ErrInvalidStockMovement
+	}
+
+	movement := &model.StockMovement{
+		StoreID:   store.ID,
+		ProductID: product.ID,
+		VariantID: req.VariantID,
+		Type:      req.Type,
+		Quantity:  req.Quantity,
+		Reason:    req.Reason,
+		UserID:    &user.ID,
+	}
+	if err := s.stockRepo.Record(ctx, movement); err != nil {
+		return nil, fmt.Errorf("failed to record stock movement: %w", err)
+	}
+	s.catalogCache.Invalidate(ctx, store.ID)
+
+	return movement, nil
+}
+
+// History lists the movements of a product, archived ones included.
+func (s *StockService) History(ctx context.Context, user *model.User, productID int64, opts *model.ListOptions) ([]*model.StockMovement, *model.PageInfo, error) {
+	store, err := s.storeRepo.GetByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get store: %w", err)
+	}
+
+	_, err = s.productRepo.GetByIDAndStoreID(ctx, productID, store.ID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		_, err = s.productRepo.GetArchivedByIDAndStoreID(ctx, productID, store.ID)
+	}
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrProductNotFound
+		}
+		return nil, nil, fmt.Errorf("failed to get product: %w", err)
+	}
+
+	movements, pageInfo, err := s.stockRepo.ListByProductID(ctx, productID, opts)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get stock movements: %w", err)
+	}
+
+	return movements, pageInfo, nil
+}
+
+// Reconcile brings every stock balance of the store back in line with the
+// ledger and returns the balances that had drifted.
+func (s *StockService) Reconcile(ctx context.Context, user *model.User) ([]*model.StockDrift, error) {
+	store, err := s.storeRepo.GetByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get store: %w", err)
+	}
+
+	drifts, err := s.stockRepo.Reconcile(ctx, store.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reconcile stock: %w", err)
+	}
+	if len(drifts) > 0 {
+		s.catalogCache.Invalidate(ctx, store.ID)
+	}
+
+	return drifts, nil
+}
+
+// LowStock reports every product and variant of the store that sits at or
+// below its reorder threshold.
+func (s *StockService) LowStock(ctx context.Context, user *model.User) ([]*model.LowStockItem, error) {
+	store, err := s.storeRepo.GetByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get store: %w", err)
+	}
+
+	products, err := s.productRepo.GetReorderTrackedByStoreID(ctx, store.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get products: %w", err)
+	}
+
+	items := []*model.LowStockItem{}
+	for _, product := range products {
+		items = append(items, product.LowStockItems()...)
+	}
+	return items, nil
+}
+
+// RunLowStockAlerts checks stock levels every interval until ctx is done.
+func (s *StockService) RunLowStockAlerts(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.CheckLowStock(ctx); err != nil {
+			log.Printf("failed to check low stock: %s", err.Error())
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// CheckLowStock looks for items that crossed their reorder threshold since
+// the last check and notifies each store owner once about all of them.
+// Items that were restocked above the threshold get their alert resolved,
+// so a later drop alerts again, and so do items whose owner turned alerts
+// off. Alerts of items otherwise no longer watched, e.g. a deactivated or
+// archived product, stay open: nothing was restocked. A store whose alerts
+// cannot be sent or saved is retried on the next check.
+func (s *StockService) CheckLowStock(ctx context.Context) error {
+	products, err := s.productRepo.GetReorderTracked(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get products: %w", err)
+	}
+
+	open, err := s.stockRepo.GetOpenAlerts(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get stock alerts: %w", err)
+	}
+
+	type key struct{ product, variant int64 }
+	alerted := make(map[key]*model.StockAlert, len(open))
+	for _, alert := range open {
+		k := key{product: alert.ProductID}
+		if alert.VariantID != nil {
+			k.variant = *alert.VariantID
+		}
+		alerted[k] = alert
+	}
+
+	// The items LowStockItems looks at, low or not
+	watched := make(map[key]bool)
+	for _, product := range products {
+		hasVariants := false
+		for _, variant := range product.Variants {
+			if variant.IsActive {
+				watched[key{product: product.ID, variant: variant.ID}] = true
+				hasVariants = true
+			}
+		}
+		if !hasVariants {
+			watched[key{product: product.ID}] = true
+		}
+	}
+
+	var storeIDs []int64
+	crossed := make(map[int64][]*model.LowStockItem)
+	for _, product := range products {
+		for _, item := range product.LowStockItems() {
+			k := key{product: item.ProductID}
+			if item.VariantID != nil {
+				k.variant = *item.VariantID
+			}
+			if _, ok := alerted[k]; ok {
+				delete(alerted, k)
+				continue
+			}
+			if _, ok := crossed[item.StoreID]; !ok {
+				storeIDs = append(storeIDs, item.StoreID)
+			}
+			crossed[item.StoreID] = append(crossed[item.StoreID], item)
+		}
+	}
+
+	// Whatever is still in alerted and watched is no longer low
+	resolved, err := s.stockRepo.GetSilencedAlertIDs(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get stock alerts: %w", err)
+	}
+	for k, alert := range alerted {
+		if watched[k] {
+			resolved = append(resolved, alert.ID)
+		}
+	}
+	if err := s.stockRepo.ResolveAlerts(ctx, resolved, time.Now()); err != nil {
+		return fmt.Errorf("failed to resolve stock alerts: %w", err)
+	}
+
+	for _, storeID := range storeIDs {
+		items := crossed[storeID]
+		if err := s.notifyLowStock(ctx, storeID, items); err != nil {
+			log.Printf("failed to send low stock alert for store %d: %s", storeID, err.Error())
+			continue
+		}
+
+		alerts := make([]*model.StockAlert, len(items))
+		for i, item := range items {
+			alerts[i] = &model.StockAlert{
+				StoreID:   item.StoreID,
+				ProductID: item.ProductID,
+				VariantID: item.VariantID,
+				Stock:     item.Stock,
+				Threshold: item.Threshold,
+			}
+		}
+		if err := s.stockRepo.CreateAlerts(ctx, alerts); err != nil {
+			log.Printf("failed to save low stock alerts for store %d: %s", storeID, err.Error())
+			continue
+		}
+	}
+
+	return nil
+}
+
+func (s *StockService) notifyLowStock(ctx context.Context, storeID int64, items []*model.LowStockItem) error {
+	store, err := s.storeRepo.GetByID(ctx, storeID)
+	if err != nil {
+		return fmt.Errorf("failed to get store: %w", err)
+	}
+	owner, err := s.userRepo.GetByID(ctx, store.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to get store owner: %w", err)
+	}
+
+	var body strings.Builder
+	fmt.Fprintf(&body, "These products of %s are running low:\n\n", store.Name)
+	for _, item := range items {
+		name := item.Name
+		if item.Variant != "" {
+			name += " (" + item.Variant + ")"
+		}
+		fmt.Fprintf(&body, "- %s: %d left, reorder at %d\n", name, item.Stock, item.Threshold)
+	}
+
+	return s.notifier.Notify(ctx, &notify.Notification{
+		Email:   owner.Email,
+		Subject: fmt.Sprintf("Low stock at %s", store.Name),
+		Body:    body.String(),
+	})
+}
+
+// stockMovements returns the movement, made by user, that takes a balance
+// from one value to another; none when it stays the same. Used where
+// requests still set stock as an absolute number.
+func stockMovements(user *model.User, storeID int64, from, to int, movementType model.StockMovementType, reason string) []*model.StockMovement {
+	if from == to {
+		return nil
+	}
+	return []*model.StockMovement{{
+		StoreID:  storeID,
+		Type:     movementType,
+		Quantity: to - from,
+		Reason:   reason,
+		UserID:   &user.ID,
+	}}
+}
@@ -5,20 +5,26 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"todo-go/internal/model"
 	"todo-go/internal/repository"
+	"todo-go/pkg/qris"
 
 	"gorm.io/gorm"
 )
 
-var ErrStoreNotFound = errors.New("store not found")
+var (
+	ErrStoreNotFound = errors.New("store not found")
+	ErrInvalidQRIS   = errors.New("invalid qris_payload")
+)
 
 type StoreService struct {
-	storeRepo *repository.StoreRepository
+	storeRepo    *repository.StoreRepository
+	catalogCache *CatalogCache
 }
 
-func NewStoreService(storeRepo *repository.StoreRepository) *StoreService {
-	return &StoreService{storeRepo: storeRepo}
+func NewStoreService(storeRepo *repository.StoreRepository, catalogCache *CatalogCache) *StoreService {
+	return &StoreService{storeRepo: storeRepo, catalogCache: catalogCache}
 }
 
 func (s *StoreService) Create(ctx context.Context, user *model.User, req *model.CreateStoreRequest) (*model.Store, error) {
@@ -62,15 +68,30 @@ func (s *StoreService) Update(ctx context.Context, user *model.User, req *model.
 
 	store.Name = req.Name
 	store.Description = req.Description
+	if store.Logo != req.Logo {
+		store.LogoThumb = ""
+	}
 	store.Logo = req.Logo
 	store.Address = req.Address
 	store.Phone = req.Phone
 	store.WhatsApp = req.WhatsApp
 	store.IsActive = req.IsActive
+	store.HideOutOfStock = req.HideOutOfStock
+	store.PostalCode = req.PostalCode
+	store.Latitude = req.Latitude
+	store.Longitude = req.Longitude
+
+	store.QRISPayload = strings.TrimSpace(req.QRISPayload)
+	if store.QRISPayload != "" {
+		if _, err := qris.Parse(store.QRISPayload); err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidQRIS, err.Error())
+		}
+	}
 
 	if err := s.storeRepo.Save(ctx, store); err != nil {
 		return nil, fmt.Errorf("failed to update store: %w", err)
 	}
+	s.catalogCache.Invalidate(ctx, store.ID)
 
 	return store, nil
 }
@@ -92,12 +92,12 @@ func (s *TodoService) Delete(ctx context.Context, user *model.User, id int64) er
 	return nil
 }
 
-func (s *TodoService) GetAllByUser(ctx context.Context, user *model.User) ([]*model.Todo, error) {
-	// Get all todos by user
-	todos, err := s.todoRepo.GetAllByUserID(ctx, user.ID)
+func (s *TodoService) GetAllByUser(ctx context.Context, user *model.User, opts *model.ListOptions) ([]*model.Todo, *model.PageInfo, error) {
+	// Get one page of todos by user
+	todos, pageInfo, err := s.todoRepo.ListByUserID(ctx, user.ID, opts)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get all todos by user: %w", err)
+		return nil, nil, fmt.Errorf("failed to get all todos by user: %w", err)
 	}
 
-	return todos, nil
+	return todos, pageInfo, nil
 }
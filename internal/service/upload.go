@@ -0,0 +1,208 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"log"
+	"net/http"
+	"strings"
+	"todo-go/internal/model"
+	"todo-go/internal/repository"
+	"todo-go/pkg/imaging"
+	"todo-go/pkg/storage"
+
+	"gorm.io/gorm"
+)
+
+const (
+	MaxUploadSize = 5 << 20
+
+	maxImagePixels  = 40_000_000
+	mediumImageSide = 800
+	thumbImageSide  = 200
+)
+
+var (
+	ErrUnsupportedImage = errors.New("unsupported image type, use jpeg, png or gif")
+	ErrImageTooLarge    = errors.New("image dimensions are too large")
+	ErrNoStoreLogo      = errors.New("store has no logo, upload one first")
+)
+
+// imageFormats maps sniffed content types to the format variants are
+// encoded in. GIFs are flattened to PNG to keep transparency.
+var imageFormats = map[string]string{
+	"image/jpeg": "jpeg",
+	"image/png":  "png",
+	"image/gif":  "png",
+}
+
+type UploadService struct {
+	blobStore    storage.BlobStore
+	storeRepo    *repository.StoreRepository
+	catalogCache *CatalogCache
+}
+
+func NewUploadService(blobStore storage.BlobStore, storeRepo *repository.StoreRepository, catalogCache *CatalogCache) *UploadService {
+	return &UploadService{
+		blobStore:    blobStore,
+		storeRepo:    storeRepo,
+		catalogCache: catalogCache,
+	}
+}
+
+// StoredImage holds the URLs of an uploaded image and its resized variants.
+type StoredImage struct {
+	Original  string
+	Medium    string
+	Thumbnail string
+	Keys      []string
+}
+
+func (s *UploadService) UploadStoreLogo(ctx context.Context, user *model.User, data []byte) (*model.Store, error) {
+	store, err := s.storeRepo.GetByUserID(ctx, user.ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrStoreNotFound
+		}
+		return nil, fmt.Errorf("failed to get store: %w", err)
+	}
+
+	stored, err := s.StoreImage(ctx, fmt.Sprintf("stores/%d/logo", store.ID), data)
+	if err != nil {
+		return nil, err
+	}
+
+	oldKeys := store.LogoKeys
+	store.Logo = stored.Medium
+	store.LogoThumb = stored.Thumbnail
+	store.LogoKeys = strings.Join(stored.Keys, ",")
+
+	if err := s.storeRepo.Save(ctx, store); err != nil {
+		s.DeleteKeys(ctx, store.LogoKeys)
+		return nil, fmt.Errorf("failed to update store: %w", err)
+	}
+	s.DeleteKeys(ctx, oldKeys)
+	s.catalogCache.Invalidate(ctx, store.ID)
+
+	return store, nil
+}
+
+// StoreLogo decodes the medium variant of a store's uploaded logo.
+func (s *UploadService) StoreLogo(ctx context.Context, store *model.Store) (image.Image, error) {
+	keys := strings.Split(store.LogoKeys, ",")
+	if len(keys) < 2 {
+		return nil, ErrNoStoreLogo
+	}
+
+	data, err := s.blobStore.Get(ctx, keys[1])
+	if err != nil {
+		return nil, fmt.Errorf("failed to read logo: %w", err)
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode logo: %w", err)
+	}
+
+	return img, nil
+}
+
+// StoreImage validates an uploaded image by sniffing its content, then
+// stores the original alongside medium and thumbnail variants under prefix.
+func (s *UploadService) StoreImage(ctx context.Context, prefix string, data []byte) (*StoredImage, error) {
+	contentType := http.DetectContentType(data)
+	format, ok := imageFormats[contentType]
+	if !ok {
+		return nil, ErrUnsupportedImage
+	}
+
+	// Check the header before decoding so a tiny file can't claim a huge canvas
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedImage
+	}
+	if cfg.Width*cfg.Height > maxImagePixels {
+		return nil, ErrImageTooLarge
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedImage
+	}
+
+	medium, mediumType, err := imaging.Encode(imaging.Resize(img, mediumImageSide), format)
+	if err != nil {
+		return nil, err
+	}
+	thumb, thumbType, err := imaging.Encode(imaging.Resize(img, thumbImageSide), format)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := randomID()
+	if err != nil {
+		return nil, err
+	}
+
+	ext := "." + format
+	if format == "jpeg" {
+		ext = ".jpg"
+	}
+	originalExt := map[string]string{"image/jpeg": ".jpg", "image/png": ".png", "image/gif": ".gif"}[contentType]
+
+	variants := []struct {
+		key         string
+		data        []byte
+		contentType string
+	}{
+		{key: prefix + "/" + id + "-original" + originalExt, data: data, contentType: contentType},
+		{key: prefix + "/" + id + "-medium" + ext, data: medium, contentType: mediumType},
+		{key: prefix + "/" + id + "-thumb" + ext, data: thumb, contentType: thumbType},
+	}
+
+	stored := &StoredImage{}
+	urls := make([]string, 0, len(variants))
+	for _, v := range variants {
+		if err := s.blobStore.Put(ctx, v.key, bytes.NewReader(v.data), int64(len(v.data)), v.contentType); err != nil {
+			s.DeleteKeys(ctx, strings.Join(stored.Keys, ","))
+			return nil, fmt.Errorf("failed to store image: %w", err)
+		}
+		stored.Keys = append(stored.Keys, v.key)
+
+		url, err := s.blobStore.URL(ctx, v.key)
+		if err != nil {
+			s.DeleteKeys(ctx, strings.Join(stored.Keys, ","))
+			return nil, fmt.Errorf("failed to get image url: %w", err)
+		}
+		urls = append(urls, url)
+	}
+
+	stored.Original, stored.Medium, stored.Thumbnail = urls[0], urls[1], urls[2]
+
+	return stored, nil
+}
+
+// DeleteKeys removes previously stored blobs. Failures are only logged since
+// the database no longer points at them.
+func (s *UploadService) DeleteKeys(ctx context.Context, keys string) {
+	if keys == "" {
+		return
+	}
+	for _, key := range strings.Split(keys, ",") {
+		if err := s.blobStore.Delete(ctx, key); err != nil {
+			log.Printf("failed to delete blob %s: %s", key, err.Error())
+		}
+	}
+}
+
+func randomID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate id: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
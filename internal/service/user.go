@@ -0,0 +1,189 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"todo-go/internal/model"
+	"todo-go/internal/repository"
+	"todo-go/pkg/jwt"
+	"todo-go/pkg/mailer"
+
+	jwtLib "github.com/golang-jwt/jwt"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidPassword          = errors.New("invalid password")
+	ErrEmailAlreadyUsed         = errors.New("email already in use")
+	ErrInvalidVerificationToken = errors.New("invalid or expired verification token")
+)
+
+const emailVerificationPurpose = "verify_email"
+
+type UserService struct {
+	userRepo *repository.UserRepository
+	jwtSvc   *jwt.Service
+	mailer   mailer.Mailer
+	policy   model.RetentionPolicy
+}
+
+func NewUserService(userRepo *repository.UserRepository, jwtSvc *jwt.Service, mailer mailer.Mailer, policy model.RetentionPolicy) *UserService {
+	return &UserService{
+		userRepo: userRepo,
+		jwtSvc:   jwtSvc,
+		mailer:   mailer,
+		policy:   policy,
+	}
+}
+
+func (s *UserService) UpdateProfile(ctx context.Context, user *model.User, req *model.UpdateProfileRequest) (*model.User, error) {
+	user.Name = req.Name
+
+	if err := s.userRepo.Save(ctx, user); err != nil {
+		return nil, fmt.Errorf("failed to update user: %w", err)
+	}
+
+	return user, nil
+}
+
+// ChangePassword replaces the password, revokes every token issued so far
+// and returns a fresh access token for the current client.
+func (s *UserService) ChangePassword(ctx context.Context, user *model.User, req *model.ChangePasswordRequest) (string, error) {
+	if !user.ValidatePassword(req.OldPassword) {
+		return "", ErrInvalidPassword
+	}
+
+	if err := user.GeneratePassword(req.NewPassword); err != nil {
+		return "", fmt.Errorf("failed to generate password: %w", err)
+	}
+	user.TokenVersion++
+
+	if err := s.userRepo.Save(ctx, user); err != nil {
+		return "", fmt.Errorf("failed to update user: %w", err)
+	}
+
+	accessToken, err := generateAccessToken(ctx, s.jwtSvc, user)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate access token: %w", err)
+	}
+
+	return accessToken, nil
+}
+
+// RequestEmailChange mails a verification token to the new address. The
+// email on the account is only replaced once the token is confirmed, and
+// only the latest token can be: requesting again or confirming ends the
+// change the earlier tokens were for.
+func (s *UserService) RequestEmailChange(ctx context.Context, user *model.User, req *model.ChangeEmailRequest) error {
+	if !user.ValidatePassword(req.Password) {
+		return ErrInvalidPassword
+	}
+
+	if err := s.ensureEmailAvailable(ctx, user, req.Email); err != nil {
+		return err
+	}
+
+	nonce, err := randomID()
+	if err != nil {
+		return err
+	}
+	user.EmailNonce = nonce
+	if err := s.userRepo.Save(ctx, user); err != nil {
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+
+	tokenExp := time.Now().Add(24 * time.Hour).Unix()
+	tokenData := map[string]any{
+		"purpose":       emailVerificationPurpose,
+		"user_id":       user.ID,
+		"email":         req.Email,
+		"nonce":         nonce,
+		"token_version": user.TokenVersion,
+	}
+
+	token, err := s.jwtSvc.GenerateToken(ctx, tokenData, tokenExp)
+	if err != nil {
+		return fmt.Errorf("failed to generate verification token: %w", err)
+	}
+
+	body := fmt.Sprintf("Hi %s,\n\nConfirm your new email address by sending this token to POST /api/v1/auth/verify-email:\n\n%s\n\nThe token expires in 24 hours.", user.Name, token)
+	if err := s.mailer.Send(ctx, req.Email, "Confirm your new email address", body); err != nil {
+		return fmt.Errorf("failed to send verification email: %w", err)
+	}
+
+	return nil
+}
+
+func (s *UserService) VerifyEmail(ctx context.Context, req *model.VerifyEmailRequest) (*model.User, error) {
+	token, err := s.jwtSvc.ParseToken(ctx, req.Token)
+	if err != nil || !token.Valid {
+		return nil, ErrInvalidVerificationToken
+	}
+
+	claims := token.Claims.(jwtLib.MapClaims)
+	purpose, _ := claims["purpose"].(string)
+	userID, _ := claims["user_id"].(float64)
+	email, _ := claims["email"].(string)
+	nonce, _ := claims["nonce"].(string)
+	tokenVersion, _ := claims["token_version"].(float64)
+	if purpose != emailVerificationPurpose || email == "" || nonce == "" {
+		return nil, ErrInvalidVerificationToken
+	}
+
+	user, err := s.userRepo.GetByID(ctx, int64(userID))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidVerificationToken
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	// A password change in between invalidates pending email changes
+	if int64(tokenVersion) != user.TokenVersion {
+		return nil, ErrInvalidVerificationToken
+	}
+
+	if err := s.ensureEmailAvailable(ctx, user, email); err != nil {
+		return nil, err
+	}
+
+	confirmed, err := s.userRepo.ConfirmEmail(ctx, user, email, nonce)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update user: %w", err)
+	}
+	if !confirmed {
+		return nil, ErrInvalidVerificationToken
+	}
+	user.Email = email
+	user.EmailVerified = true
+	user.EmailNonce = ""
+
+	return user, nil
+}
+
+func (s *UserService) DeleteAccount(ctx context.Context, user *model.User, req *model.DeleteAccountRequest) error {
+	if !user.ValidatePassword(req.Password) {
+		return ErrInvalidPassword
+	}
+
+	if err := s.userRepo.DeleteAccount(ctx, user, s.policy); err != nil {
+		return fmt.Errorf("failed to delete account: %w", err)
+	}
+
+	return nil
+}
+
+func (s *UserService) ensureEmailAvailable(ctx context.Context, user *model.User, email string) error {
+	existing, err := s.userRepo.GetByEmail(ctx, email)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("failed to get user by email: %w", err)
+	}
+
+	if existing != nil && existing.ID != user.ID {
+		return ErrEmailAlreadyUsed
+	}
+
+	return nil
+}
@@ -0,0 +1,185 @@
+// internal/service/voucher.go
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"todo-go/internal/model"
+	"todo-go/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrVoucherNotFound  = errors.New("voucher not found")
+	ErrVoucherCodeTaken = errors.New("voucher code already used by another voucher")
+	ErrInvalidVoucher   = errors.New("a percent voucher takes at most 100 percent off")
+	ErrVoucherWindow    = errors.New("ends_at must be after starts_at")
+
+	// ErrVoucherNotApplicable is returned when an order's voucher code
+	// cannot be used for it
+	ErrVoucherNotApplicable = errors.New("voucher cannot be used")
+)
+
+type VoucherService struct {
+	voucherRepo  *repository.VoucherRepository
+	productRepo  *repository.ProductRepository
+	categoryRepo *repository.CategoryRepository
+	storeRepo    *repository.StoreRepository
+}
+
+func NewVoucherService(voucherRepo *repository.VoucherRepository, productRepo *repository.ProductRepository, categoryRepo *repository.CategoryRepository, storeRepo *repository.StoreRepository) *VoucherService {
+	return &VoucherService{
+		voucherRepo:  voucherRepo,
+		productRepo:  productRepo,
+		categoryRepo: categoryRepo,
+		storeRepo:    storeRepo,
+	}
+}
+
+func (s *VoucherService) Create(ctx context.Context, user *model.User, req *model.CreateVoucherRequest) (*model.Voucher, error) {
+	store, err := s.storeRepo.GetByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get store: %w", err)
+	}
+
+	voucher := &model.Voucher{
+		StoreID:       store.ID,
+		Code:          model.NormalizeVoucherCode(req.Code),
+		Type:          req.Type,
+		Value:         req.Value,
+		MinOrderTotal: req.MinOrderTotal,
+		UsageLimit:    req.UsageLimit,
+		PerPhoneLimit: req.PerPhoneLimit,
+		StartsAt:      req.StartsAt,
+		EndsAt:        req.EndsAt,
+		ProductIDs:    req.ProductIDs,
+		CategoryIDs:   req.CategoryIDs,
+		IsActive:      req.IsActive,
+	}
+	if err := s.check(ctx, voucher); err != nil {
+		return nil, err
+	}
+
+	if err := s.voucherRepo.Save(ctx, voucher); err != nil {
+		return nil, fmt.Errorf("failed to save voucher: %w", err)
+	}
+
+	return voucher, nil
+}
+
+func (s *VoucherService) List(ctx context.Context, user *model.User, opts *model.ListOptions) ([]*model.Voucher, *model.PageInfo, error) {
+	store, err := s.storeRepo.GetByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get store: %w", err)
+	}
+
+	vouchers, pageInfo, err := s.voucherRepo.ListByStoreID(ctx, store.ID, opts)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get vouchers: %w", err)
+	}
+
+	return vouchers, pageInfo, nil
+}
+
+// Update changes the voucher's terms. Uses so far stay counted.
+func (s *VoucherService) Update(ctx context.Context, user *model.User, req *model.UpdateVoucherRequest) (*model.Voucher, error) {
+	store, err := s.storeRepo.GetByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get store: %w", err)
+	}
+
+	voucher, err := s.voucherRepo.GetByIDAndStoreID(ctx, req.ID, store.ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrVoucherNotFound
+		}
+		return nil, fmt.Errorf("failed to get voucher: %w", err)
+	}
+
+	voucher.Code = model.NormalizeVoucherCode(req.Code)
+	voucher.Type = req.Type
+	voucher.Value = req.Value
+	voucher.MinOrderTotal = req.MinOrderTotal
+	voucher.UsageLimit = req.UsageLimit
+	voucher.PerPhoneLimit = req.PerPhoneLimit
+	voucher.StartsAt = req.StartsAt
+	voucher.EndsAt = req.EndsAt
+	voucher.ProductIDs = req.ProductIDs
+	voucher.CategoryIDs = req.CategoryIDs
+	voucher.IsActive = req.IsActive
+	if err := s.check(ctx, voucher); err != nil {
+		return nil, err
+	}
+
+	if err := s.voucherRepo.Save(ctx, voucher); err != nil {
+		return nil, fmt.Errorf("failed to update voucher: %w", err)
+	}
+
+	return voucher, nil
+}
+
+func (s *VoucherService) Delete(ctx context.Context, user *model.User, id int64) error {
+	store, err := s.storeRepo.GetByUserID(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get store: %w", err)
+	}
+
+	if _, err := s.voucherRepo.GetByIDAndStoreID(ctx, id, store.ID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrVoucherNotFound
+		}
+		return fmt.Errorf("failed to get voucher: %w", err)
+	}
+
+	if err := s.voucherRepo.Delete(ctx, id); err != nil {
+		return fmt.Errorf("failed to delete voucher: %w", err)
+	}
+
+	return nil
+}
+
+// check validates the discount, keeps codes unique within the store and
+// makes sure restrictions only name the store's own products and
+// categories.
+func (s *VoucherService) check(ctx context.Context, voucher *model.Voucher) error {
+	if voucher.Type == model.VoucherPercent && voucher.Value > 100 {
+		return ErrInvalidVoucher
+	}
+	if voucher.StartsAt != nil && voucher.EndsAt != nil && !voucher.EndsAt.After(*voucher.StartsAt) {
+		return ErrVoucherWindow
+	}
+
+	existing, err := s.voucherRepo.GetByCodeAndStoreID(ctx, voucher.Code, voucher.StoreID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("failed to get voucher by code: %w", err)
+	}
+	if existing != nil && existing.ID != voucher.ID {
+		return ErrVoucherCodeTaken
+	}
+
+	for _, id := range voucher.ProductIDs {
+		if _, err := s.productRepo.GetByIDAndStoreID(ctx, id, voucher.StoreID); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("%w: %d", ErrProductNotFound, id)
+			}
+			return fmt.Errorf("failed to get product: %w", err)
+		}
+	}
+
+	if len(voucher.CategoryIDs) > 0 {
+		categories, err := s.categoryRepo.GetByStoreID(ctx, voucher.StoreID)
+		if err != nil {
+			return fmt.Errorf("failed to get categories: %w", err)
+		}
+		for _, id := range voucher.CategoryIDs {
+			if !slices.ContainsFunc(categories, func(c *model.Category) bool { return c.ID == id }) {
+				return fmt.Errorf("%w: %d", ErrCategoryNotFound, id)
+			}
+		}
+	}
+
+	return nil
+}
@@ -5,25 +5,106 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 	"todo-go/internal/model"
 	"todo-go/internal/repository"
+	"todo-go/internal/search"
+	"todo-go/internal/storefront"
+	"todo-go/pkg/diff"
+	"todo-go/pkg/domain"
+	"todo-go/pkg/sanitize"
+	"todo-go/pkg/slug"
 
 	"gorm.io/gorm"
 )
 
-var ErrWebsiteNotFound = errors.New("website not found")
+var (
+	ErrWebsiteNotFound   = errors.New("website not found")
+	ErrInvalidDomain     = errors.New("invalid domain")
+	ErrDomainTaken       = errors.New("domain is already used by another store")
+	ErrDomainNotVerified = errors.New("domain ownership not verified")
+	ErrRevisionNotFound  = errors.New("website revision not found")
+	ErrInvalidRevision   = errors.New("invalid website revision")
+	ErrPreviewNotFound   = errors.New("preview link is invalid or expired")
+	ErrInvalidTemplate   = errors.New("invalid template")
+)
+
+// previewTTL is how long a preview link keeps working
+const previewTTL = 24 * time.Hour
+
+// reservedDomains are subdomains of the platform that never name a store
+var reservedDomains = map[string]bool{
+	"www": true, "api": true, "admin": true, "app": true, "dashboard": true,
+	"mail": true, "email": true, "smtp": true, "ftp": true, "ns1": true, "ns2": true,
+	"static": true, "assets": true, "cdn": true, "uploads": true, "catalog": true,
+	"blog": true, "help": true, "support": true, "status": true, "docs": true,
+	"login": true, "signin": true, "signup": true, "register": true, "account": true,
+	"billing": true, "pay": true, "payment": true, "checkout": true, "store": true,
+	"shop": true, "toko": true, "dev": true, "staging": true, "test": true,
+}
+
+// DomainAllowed reports whether the owner of storeID may use name as their
+// website domain, leaving aside whether another store already has it.
+func DomainAllowed(name string, storeID int64) bool {
+	return domain.ValidLabel(name) && !reservedDomain(name, storeID)
+}
+
+// reservedDomain reports whether name is kept from the owner of storeID:
+// a platform subdomain, or the "toko-{store_id}" placeholder given to
+// another store's website.
+func reservedDomain(name string, storeID int64) bool {
+	if reservedDomains[name] {
+		return true
+	}
+	rest, ok := strings.CutPrefix(name, "toko-")
+	if !ok {
+		return false
+	}
+	id, suffix, _ := strings.Cut(rest, "-")
+	owner, err := strconv.ParseInt(id, 10, 64)
+	if err != nil || strings.Trim(suffix, "0123456789") != "" {
+		return false
+	}
+	return owner != storeID
+}
+
+// maxGeneratedDomain leaves room for a collision suffix within the 63
+// characters of a hostname label
+const maxGeneratedDomain = 50
+
+// maxDomainAttempts bounds how often a generated domain is generated again
+// after losing a race for it
+const maxDomainAttempts = 3
 
 type WebsiteService struct {
-	websiteRepo *repository.WebsiteRepository
-	storeRepo   *repository.StoreRepository
-	productRepo *repository.ProductRepository
+	websiteRepo  *repository.WebsiteRepository
+	storeRepo    *repository.StoreRepository
+	productRepo  *repository.ProductRepository
+	categoryRepo *repository.CategoryRepository
+	searcher     search.ProductSearcher
+	pricer       *Pricer
+	verifier     *domain.Verifier
+	themes       *storefront.Registry
+	catalogCache *CatalogCache
+	baseDomain   string // stores are served at {domain}.{baseDomain}
 }
 
-func NewWebsiteService(websiteRepo *repository.WebsiteRepository, storeRepo *repository.StoreRepository, productRepo *repository.ProductRepository) *WebsiteService {
+func NewWebsiteService(websiteRepo *repository.WebsiteRepository, storeRepo *repository.StoreRepository, productRepo *repository.ProductRepository, categoryRepo *repository.CategoryRepository, searcher search.ProductSearcher, pricer *Pricer, verifier *domain.Verifier, themes *storefront.Registry, catalogCache *CatalogCache, baseDomain string) *WebsiteService {
 	return &WebsiteService{
-		websiteRepo: websiteRepo,
-		storeRepo:   storeRepo,
-		productRepo: productRepo,
+		websiteRepo:  websiteRepo,
+		storeRepo:    storeRepo,
+		productRepo:  productRepo,
+		categoryRepo: categoryRepo,
+		searcher:     searcher,
+		pricer:       pricer,
+		verifier:     verifier,
+		themes:       themes,
+		catalogCache: catalogCache,
+		baseDomain:   baseDomain,
 	}
 }
 
@@ -33,19 +114,52 @@ func (s *WebsiteService) Create(ctx context.Context, user *model.User, req *mode
 		return nil, fmt.Errorf("failed to get store: %w", err)
 	}
 
+	if err := s.themes.Validate(req.Template, req.Settings); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidTemplate, err)
+	}
+
+	generated := req.Domain == ""
+	if generated {
+		req.Domain, err = s.generateDomain(ctx, store)
+	} else {
+		err = s.checkDomain(ctx, req.Domain, store.ID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
 	website := &model.Website{
 		StoreID:     store.ID,
 		Template:    req.Template,
-		CustomCSS:   req.CustomCSS,
-		CustomHTML:  req.CustomHTML,
+		Settings:    req.Settings,
 		Domain:      req.Domain,
 		IsPublished: false,
 	}
+	website.CustomHTML, website.CustomCSS, website.Rejected = sanitizeCustomCode(req.CustomHTML, req.CustomCSS)
 
-	if err := s.websiteRepo.Save(ctx, website); err != nil {
+	// Another store can take the domain between the check and the save; a
+	// generated one is simply generated again
+	err = s.websiteRepo.Save(ctx, website)
+	for attempt := 1; generated && attempt < maxDomainAttempts && errors.Is(err, gorm.ErrDuplicatedKey); attempt++ {
+		if website.Domain, err = s.generateDomain(ctx, store); err != nil {
+			return nil, err
+		}
+		err = s.websiteRepo.Save(ctx, website)
+	}
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return nil, ErrDomainTaken
+	}
+	if err != nil {
 		return nil, fmt.Errorf("failed to save website: %w", err)
 	}
 
+	// The first design is live straight away; the site stays hidden until
+	// it is published
+	website.LiveVersion = 1
+	if err := s.websiteRepo.SaveRevisions(ctx, website, liveRevision(website, 1)); err != nil {
+		return nil, fmt.Errorf("failed to save website revision: %w", err)
+	}
+
 	return website, nil
 }
 
@@ -66,6 +180,22 @@ func (s *WebsiteService) GetByUser(ctx context.Context, user *model.User) (*mode
 	return website, nil
 }
 
+// GetWithDraft returns the website together with its pending draft.
+func (s *WebsiteService) GetWithDraft(ctx context.Context, user *model.User) (*model.Website, error) {
+	website, err := s.GetByUser(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	if website.DraftVersion != 0 {
+		website.Draft, err = s.getRevision(ctx, website, website.DraftVersion)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return website, nil
+}
+
 func (s *WebsiteService) Update(ctx context.Context, user *model.User, req *model.UpdateWebsiteRequest) (*model.Website, error) {
 	store, err := s.storeRepo.GetByUserID(ctx, user.ID)
 	if err != nil {
@@ -80,25 +210,642 @@ func (s *WebsiteService) Update(ctx context.Context, user *model.User, req *mode
 		return nil, fmt.Errorf("failed to get website: %w", err)
 	}
 
-	website.Template = req.Template
-	website.CustomCSS = req.CustomCSS
-	website.CustomHTML = req.CustomHTML
-	website.Domain = req.Domain
-	website.IsPublished = req.IsPublished
+	if err := s.themes.Validate(req.Template, req.Settings); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidTemplate, err)
+	}
+
+	// Leaving the domain out keeps the current one; websites from before
+	// domains were required get one generated
+	switch {
+	case req.Domain == "" && website.Domain == "":
+		req.Domain, err = s.generateDomain(ctx, store)
+	case req.Domain == "":
+		req.Domain = website.Domain
+	case req.Domain != website.Domain:
+		err = s.checkDomain(ctx, req.Domain, store.ID)
+	}
+	if err != nil {
+		return nil, err
+	}
 
-	if err := s.websiteRepo.Save(ctx, website); err != nil {
+	draft := &model.WebsiteRevision{
+		WebsiteID: website.ID,
+		Template:  req.Template,
+		Settings:  req.Settings,
+	}
+	var rejected []string
+	draft.CustomHTML, draft.CustomCSS, rejected = sanitizeCustomCode(req.CustomHTML, req.CustomCSS)
+
+	domainBefore := website.Domain
+	website, err = s.websiteRepo.Revise(ctx, website.ID, func(website *model.Website, latest int) ([]*model.WebsiteRevision, error) {
+		website.Domain = req.Domain
+
+		// Websites from before revisions keep their live design as the
+		// first one, so the owner can roll back to it
+		var revisions []*model.WebsiteRevision
+		if website.LiveVersion == 0 {
+			latest++
+			website.LiveVersion = latest
+			revisions = append(revisions, liveRevision(website, latest))
+		}
+
+		draft.Version = latest + 1
+		website.DraftVersion = draft.Version
+		return append(revisions, draft), nil
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) && req.Domain != domainBefore {
+			return nil, ErrDomainTaken
+		}
 		return nil, fmt.Errorf("failed to update website: %w", err)
 	}
+	s.catalogCache.Invalidate(ctx, website.StoreID)
+
+	website.Draft = draft
+	website.Rejected = rejected
+	return website, nil
+}
+
+// Publish puts the draft live, if there is one, and makes the website
+// public.
+func (s *WebsiteService) Publish(ctx context.Context, user *model.User) (*model.Website, error) {
+	website, err := s.GetByUser(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	website, err = s.websiteRepo.Revise(ctx, website.ID, func(website *model.Website, _ int) ([]*model.WebsiteRevision, error) {
+		var revisions []*model.WebsiteRevision
+		if website.DraftVersion != 0 {
+			draft, err := s.getRevision(ctx, website, website.DraftVersion)
+			if err != nil {
+				return nil, err
+			}
+			promote(website, draft)
+			revisions = append(revisions, draft)
+		}
+		website.IsPublished = true
+		return revisions, nil
+	})
+	if err != nil {
+		if errors.Is(err, ErrRevisionNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to publish website: %w", err)
+	}
+	s.catalogCache.Invalidate(ctx, website.StoreID)
+	return website, nil
+}
+
+// Unpublish hides the website; its revisions are kept.
+func (s *WebsiteService) Unpublish(ctx context.Context, user *model.User) (*model.Website, error) {
+	website, err := s.GetByUser(ctx, user)
+	if err != nil {
+		return nil, err
+	}
 
+	website.IsPublished = false
+	if err := s.websiteRepo.SaveFields(ctx, website, "is_published"); err != nil {
+		return nil, fmt.Errorf("failed to unpublish website: %w", err)
+	}
+	s.catalogCache.Invalidate(ctx, website.StoreID)
 	return website, nil
 }
 
+// ListRevisions returns the website's revision history, newest first.
+func (s *WebsiteService) ListRevisions(ctx context.Context, user *model.User) ([]*model.WebsiteRevision, error) {
+	website, err := s.GetByUser(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	revisions, err := s.websiteRepo.ListRevisions(ctx, website.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get website revisions: %w", err)
+	}
+	for _, revision := range revisions {
+		switch revision.Version {
+		case website.LiveVersion:
+			revision.Status = "live"
+		case website.DraftVersion:
+			revision.Status = "draft"
+		}
+	}
+	return revisions, nil
+}
+
+// WebsiteDiff lists the fields that differ between two revisions.
+type WebsiteDiff struct {
+	From    int           `json:"from"`
+	To      int           `json:"to"`
+	Changes []FieldChange `json:"changes"`
+}
+
+type FieldChange struct {
+	Field string      `json:"field"`
+	Lines []diff.Line `json:"lines"`
+}
+
+// DiffRevisions compares two revisions line by line. Leaving from out
+// compares against the live version, leaving to out uses the draft.
+func (s *WebsiteService) DiffRevisions(ctx context.Context, user *model.User, from, to int) (*WebsiteDiff, error) {
+	website, err := s.GetByUser(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	if from == 0 {
+		from = website.LiveVersion
+	}
+	if to == 0 {
+		to = website.DraftVersion
+	}
+	if from == 0 || to == 0 {
+		return nil, fmt.Errorf("%w: no draft to compare, pass from and to", ErrInvalidRevision)
+	}
+
+	base, err := s.getRevision(ctx, website, from)
+	if err != nil {
+		return nil, err
+	}
+	head, err := s.getRevision(ctx, website, to)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &WebsiteDiff{From: from, To: to, Changes: []FieldChange{}}
+	for _, field := range []struct{ name, base, head string }{
+		{"template", base.Template, head.Template},
+		{"settings", settingLines(base.Settings), settingLines(head.Settings)},
+		{"custom_css", base.CustomCSS, head.CustomCSS},
+		{"custom_html", base.CustomHTML, head.CustomHTML},
+	} {
+		if lines := diff.Lines(field.base, field.head); diff.Changed(lines) {
+			result.Changes = append(result.Changes, FieldChange{Field: field.name, Lines: lines})
+		}
+	}
+	return result, nil
+}
+
+// Rollback puts an earlier revision live again as a new revision, so the
+// history stays intact. A pending draft is set aside but kept.
+func (s *WebsiteService) Rollback(ctx context.Context, user *model.User, version int) (*model.Website, error) {
+	website, err := s.GetByUser(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	target, err := s.getRevision(ctx, website, version)
+	if err != nil {
+		return nil, err
+	}
+
+	website, err = s.websiteRepo.Revise(ctx, website.ID, func(website *model.Website, latest int) ([]*model.WebsiteRevision, error) {
+		website.DraftVersion = 0
+		if target.Version == website.LiveVersion {
+			return nil, nil
+		}
+
+		restored := &model.WebsiteRevision{
+			WebsiteID:    website.ID,
+			Version:      latest + 1,
+			Template:     target.Template,
+			Settings:     target.Settings,
+			CustomCSS:    target.CustomCSS,
+			CustomHTML:   target.CustomHTML,
+			RestoredFrom: target.Version,
+		}
+		promote(website, restored)
+		return []*model.WebsiteRevision{restored}, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to roll back website: %w", err)
+	}
+	s.catalogCache.Invalidate(ctx, website.StoreID)
+	return website, nil
+}
+
+// CreatePreview issues a link that shows the draft, or the live design when
+// there is no draft, even while the website is unpublished. Creating a new
+// link revokes the previous one.
+func (s *WebsiteService) CreatePreview(ctx context.Context, user *model.User) (*model.WebsitePreview, error) {
+	website, err := s.GetByUser(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	expiresAt := time.Now().Add(previewTTL)
+	website.PreviewToken = domain.NewToken()
+	website.PreviewExpiresAt = &expiresAt
+	if err := s.websiteRepo.SaveFields(ctx, website, "preview_token", "preview_expires_at"); err != nil {
+		return nil, fmt.Errorf("failed to save preview token: %w", err)
+	}
+
+	version := website.DraftVersion
+	if version == 0 {
+		version = website.LiveVersion
+	}
+	return &model.WebsitePreview{
+		URL:       "/preview/" + website.PreviewToken,
+		Version:   version,
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// PreviewCatalog returns the catalog a preview link shows, with the draft
+// design applied.
+func (s *WebsiteService) PreviewCatalog(ctx context.Context, token string, opts *model.ListOptions) (*CatalogData, error) {
+	website, err := s.websiteRepo.GetByPreviewToken(ctx, token)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPreviewNotFound
+		}
+		return nil, fmt.Errorf("failed to get website: %w", err)
+	}
+	if website.PreviewExpiresAt == nil || time.Now().After(*website.PreviewExpiresAt) {
+		return nil, ErrPreviewNotFound
+	}
+
+	if website.DraftVersion != 0 {
+		draft, err := s.getRevision(ctx, website, website.DraftVersion)
+		if err != nil {
+			return nil, err
+		}
+		website.Template = draft.Template
+		website.Settings = draft.Settings
+		website.CustomCSS = draft.CustomCSS
+		website.CustomHTML = draft.CustomHTML
+	}
+
+	return s.catalog(ctx, website, opts)
+}
+
+func (s *WebsiteService) getRevision(ctx context.Context, website *model.Website, version int) (*model.WebsiteRevision, error) {
+	revision, err := s.websiteRepo.GetRevision(ctx, website.ID, version)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: version %d", ErrRevisionNotFound, version)
+		}
+		return nil, fmt.Errorf("failed to get website revision: %w", err)
+	}
+	return revision, nil
+}
+
+// settingLines writes settings one "key: value" per line, so they diff
+// like the other fields.
+func settingLines(settings map[string]string) string {
+	keys := make([]string, 0, len(settings))
+	for key := range settings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, key := range keys {
+		fmt.Fprintf(&b, "%s: %s\n", key, settings[key])
+	}
+	return b.String()
+}
+
+// liveRevision snapshots the design the website is serving.
+func liveRevision(website *model.Website, version int) *model.WebsiteRevision {
+	publishedAt := website.UpdatedAt
+	return &model.WebsiteRevision{
+		WebsiteID:   website.ID,
+		Version:     version,
+		Template:    website.Template,
+		Settings:    website.Settings,
+		CustomCSS:   website.CustomCSS,
+		CustomHTML:  website.CustomHTML,
+		PublishedAt: &publishedAt,
+	}
+}
+
+// promote makes revision the website's live design.
+func promote(website *model.Website, revision *model.WebsiteRevision) {
+	now := time.Now()
+	revision.PublishedAt = &now
+	website.Template = revision.Template
+	website.Settings = revision.Settings
+	website.CustomCSS = revision.CustomCSS
+	website.CustomHTML = revision.CustomHTML
+	website.LiveVersion = revision.Version
+	if website.DraftVersion == revision.Version {
+		website.DraftVersion = 0
+	}
+}
+
+// checkDomain makes sure a website domain can be used as a hostname label,
+// so it also works as {domain}.{baseDomain}, and that no other store has
+// it.
+func (s *WebsiteService) checkDomain(ctx context.Context, name string, storeID int64) error {
+	if name == "" {
+		return nil
+	}
+	if !domain.ValidLabel(name) {
+		return fmt.Errorf("%w: use lowercase letters, digits and dashes", ErrInvalidDomain)
+	}
+	if reservedDomain(name, storeID) {
+		return fmt.Errorf("%w: %s is reserved", ErrInvalidDomain, name)
+	}
+
+	taken, err := s.websiteRepo.DomainTaken(ctx, name, storeID)
+	if err != nil {
+		return fmt.Errorf("failed to check domain: %w", err)
+	}
+	if taken {
+		return ErrDomainTaken
+	}
+	return nil
+}
+
+// generateDomain derives a free domain from the store name, e.g. "Warung
+// Bu Siti" becomes "warung-bu-siti", or "warung-bu-siti-2" when that is
+// taken.
+func (s *WebsiteService) generateDomain(ctx context.Context, store *model.Store) (string, error) {
+	base := slug.Make(store.Name)
+	if len(base) > maxGeneratedDomain {
+		base = strings.TrimRight(base[:maxGeneratedDomain], "-")
+	}
+	switch {
+	case base == "":
+		base = fmt.Sprintf("toko-%d", store.ID)
+	case reservedDomain(base, store.ID):
+		base = "toko-" + base
+	}
+
+	candidate := base
+	for n := 2; n <= 100; n++ {
+		taken, err := s.websiteRepo.DomainTaken(ctx, candidate, store.ID)
+		if err != nil {
+			return "", fmt.Errorf("failed to check domain: %w", err)
+		}
+		if !taken {
+			return candidate, nil
+		}
+		candidate = fmt.Sprintf("%s-%d", base, n)
+	}
+	return fmt.Sprintf("%s-%d", base, store.ID), nil
+}
+
+// CheckDomain tells the owner whether they can use a domain and, when they
+// cannot, suggests one that is free.
+func (s *WebsiteService) CheckDomain(ctx context.Context, user *model.User, name string) (*model.DomainAvailability, error) {
+	store, err := s.storeRepo.GetByUserID(ctx, user.ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrStoreNotFound
+		}
+		return nil, fmt.Errorf("failed to get store: %w", err)
+	}
+
+	name = strings.ToLower(strings.TrimSpace(name))
+	availability := &model.DomainAvailability{Domain: name, Available: true}
+	if name == "" {
+		err = fmt.Errorf("%w: domain is required", ErrInvalidDomain)
+	} else {
+		err = s.checkDomain(ctx, name, store.ID)
+	}
+	switch {
+	case errors.Is(err, ErrInvalidDomain), errors.Is(err, ErrDomainTaken):
+		availability.Available = false
+		availability.Reason = err.Error()
+	case err != nil:
+		return nil, err
+	}
+
+	if !availability.Available {
+		availability.Suggestion, err = s.generateDomain(ctx, store)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return availability, nil
+}
+
+// SetCustomDomain records the hostname the owner wants their catalog served
+// at. It only goes live after VerifyCustomDomain; an empty hostname removes
+// it.
+func (s *WebsiteService) SetCustomDomain(ctx context.Context, user *model.User, req *model.SetCustomDomainRequest) (*model.DomainVerification, error) {
+	website, err := s.GetByUser(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	host := domain.Normalize(req.CustomDomain)
+	switch {
+	case host == "":
+		website.CustomDomain = ""
+		website.DomainToken = ""
+		website.DomainVerifiedAt = nil
+	case host == website.CustomDomain:
+		// Keep the token the owner may already have published
+	default:
+		if !domain.ValidHostname(host) {
+			return nil, fmt.Errorf("%w: %s is not a hostname", ErrInvalidDomain, host)
+		}
+		if host == s.baseDomain || strings.HasSuffix(host, "."+s.baseDomain) {
+			return nil, fmt.Errorf("%w: subdomains of %s are assigned through domain", ErrInvalidDomain, s.baseDomain)
+		}
+		taken, err := s.websiteRepo.CustomDomainTaken(ctx, host, website.StoreID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check custom domain: %w", err)
+		}
+		if taken {
+			return nil, ErrDomainTaken
+		}
+
+		website.CustomDomain = host
+		website.DomainToken = domain.NewToken()
+		website.DomainVerifiedAt = nil
+	}
+
+	if err := s.websiteRepo.SaveFields(ctx, website, "custom_domain", "domain_token", "domain_verified_at"); err != nil {
+		return nil, fmt.Errorf("failed to update website: %w", err)
+	}
+
+	return s.domainVerification(website), nil
+}
+
+// VerifyCustomDomain checks the DNS TXT record of the custom domain and,
+// when it carries the website's token, starts serving the catalog there.
+func (s *WebsiteService) VerifyCustomDomain(ctx context.Context, user *model.User) (*model.DomainVerification, error) {
+	website, err := s.GetByUser(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	if website.CustomDomain == "" {
+		return nil, fmt.Errorf("%w: no custom domain set", ErrInvalidDomain)
+	}
+	if website.DomainVerifiedAt != nil {
+		return s.domainVerification(website), nil
+	}
+
+	if err := s.verifier.Verify(ctx, website.CustomDomain, website.DomainToken); err != nil {
+		if errors.Is(err, domain.ErrNotVerified) {
+			return nil, fmt.Errorf("%w: %s", ErrDomainNotVerified, err.Error())
+		}
+		return nil, err
+	}
+
+	taken, err := s.websiteRepo.CustomDomainTaken(ctx, website.CustomDomain, website.StoreID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check custom domain: %w", err)
+	}
+	if taken {
+		return nil, ErrDomainTaken
+	}
+
+	now := time.Now()
+	website.DomainVerifiedAt = &now
+	if err := s.websiteRepo.SaveFields(ctx, website, "domain_verified_at"); err != nil {
+		// Another store verified the same host meanwhile
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, ErrDomainTaken
+		}
+		return nil, fmt.Errorf("failed to update website: %w", err)
+	}
+
+	return s.domainVerification(website), nil
+}
+
+func (s *WebsiteService) domainVerification(website *model.Website) *model.DomainVerification {
+	if website.CustomDomain == "" {
+		return &model.DomainVerification{}
+	}
+	name, value := s.verifier.Record(website.CustomDomain, website.DomainToken)
+	return &model.DomainVerification{
+		CustomDomain: website.CustomDomain,
+		RecordType:   "TXT",
+		RecordName:   name,
+		RecordValue:  value,
+		VerifiedAt:   website.DomainVerifiedAt,
+	}
+}
+
+// SiteForHost returns the domain of the website to serve for a request
+// host: the label of {domain}.{baseDomain}, or the website whose verified
+// custom domain it is. It returns "" for hosts that are not a store's, such
+// as the platform itself.
+func (s *WebsiteService) SiteForHost(ctx context.Context, host string) (string, error) {
+	host = domain.Normalize(host)
+	if host == "localhost" || host == s.baseDomain || net.ParseIP(host) != nil {
+		return "", nil
+	}
+
+	if label, ok := strings.CutSuffix(host, "."+s.baseDomain); ok {
+		if reservedDomains[label] || !domain.ValidLabel(label) {
+			return "", nil
+		}
+		return label, nil
+	}
+
+	website, err := s.websiteRepo.GetByCustomDomain(ctx, host)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to get website: %w", err)
+	}
+	return website.Domain, nil
+}
+
+// sanitizeCustomCode cleans the owner's HTML and CSS before they are saved,
+// since both end up on the pages customers open. What was taken out is
+// reported back so the owner can fix it.
+func sanitizeCustomCode(customHTML, customCSS string) (string, string, []string) {
+	cleanHTML, rejectedHTML := sanitize.HTML(customHTML)
+	cleanCSS, rejectedCSS := sanitize.CSS(customCSS)
+
+	var rejected []string
+	for _, r := range rejectedHTML {
+		rejected = append(rejected, "custom_html: "+r)
+	}
+	for _, r := range rejectedCSS {
+		rejected = append(rejected, "custom_css: "+r)
+	}
+	return cleanHTML, cleanCSS, rejected
+}
+
 type CatalogData struct {
-	Store    *model.Store     `json:"store"`
-	Products []*model.Product `json:"products"`
+	Website    *model.Website    `json:"-"`
+	Store      *model.Store      `json:"store"`
+	Categories []*model.Category `json:"categories"`
+	Products   []*model.Product  `json:"products"`
+	Pagination *model.PageInfo   `json:"pagination"`
+	LoadedAt   time.Time         `json:"-"` // when the data was read from the database
+}
+
+type CategoryCatalogData struct {
+	Store      *model.Store     `json:"store"`
+	Category   *model.Category  `json:"category"`
+	Products   []*model.Product `json:"products"`
+	Pagination *model.PageInfo  `json:"pagination"`
+}
+
+// GetCatalog returns one page of the published store's active products.
+// Pages are served from the catalog cache when possible.
+func (s *WebsiteService) GetCatalog(ctx context.Context, domain string, opts *model.ListOptions) (*CatalogData, error) {
+	cached, slot := s.catalogCache.get(ctx, domain, opts)
+	if cached != nil {
+		return cached, nil
+	}
+
+	loadedAt := time.Now()
+	website, err := s.websiteRepo.GetByDomain(ctx, domain)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrWebsiteNotFound
+		}
+		return nil, fmt.Errorf("failed to get website: %w", err)
+	}
+
+	data, err := s.catalog(ctx, website, opts)
+	if err != nil {
+		return nil, err
+	}
+	data.LoadedAt = loadedAt
+	s.catalogCache.set(ctx, slot, domain, data)
+	return data, nil
+}
+
+func (s *WebsiteService) catalog(ctx context.Context, website *model.Website, opts *model.ListOptions) (*CatalogData, error) {
+	store, err := s.storeRepo.GetByID(ctx, website.StoreID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get store: %w", err)
+	}
+
+	active := true
+	opts.Active = &active
+	opts.InStock = store.HideOutOfStock
+
+	products, pageInfo, err := s.productRepo.ListByStoreID(ctx, website.StoreID, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get products: %w", err)
+	}
+	if err := s.applyPrices(ctx, website.StoreID, products); err != nil {
+		return nil, err
+	}
+
+	categories, err := s.categoryRepo.GetByStoreID(ctx, website.StoreID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get categories: %w", err)
+	}
+
+	return &CatalogData{
+		Website:    website,
+		Store:      store,
+		Categories: model.BuildCategoryTree(categories),
+		Products:   products,
+		Pagination: pageInfo,
+		LoadedAt:   time.Now(),
+	}, nil
 }
 
-func (s *WebsiteService) GetCatalog(ctx context.Context, domain string) (*CatalogData, error) {
+// GetCatalogProduct returns the catalog page of a single product, which
+// product QR codes link to.
+func (s *WebsiteService) GetCatalogProduct(ctx context.Context, domain string, productID int64) (*CatalogData, error) {
+	loadedAt := time.Now()
 	website, err := s.websiteRepo.GetByDomain(ctx, domain)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -112,13 +859,191 @@ func (s *WebsiteService) GetCatalog(ctx context.Context, domain string) (*Catalo
 		return nil, fmt.Errorf("failed to get store: %w", err)
 	}
 
-	products, err := s.productRepo.GetByStoreID(ctx, website.StoreID)
+	product, err := s.productRepo.GetByIDAndStoreID(ctx, productID, website.StoreID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrProductNotFound
+		}
+		return nil, fmt.Errorf("failed to get product: %w", err)
+	}
+	if !product.IsActive {
+		return nil, ErrProductNotFound
+	}
+	products := []*model.Product{product}
+	if err := s.applyPrices(ctx, website.StoreID, products); err != nil {
+		return nil, err
+	}
+
+	return &CatalogData{
+		Website:  website,
+		Store:    store,
+		Products: products,
+		LoadedAt: loadedAt,
+	}, nil
+}
+
+// GetCategoryCatalog returns the products of one category section,
+// including those filed under its subcategories.
+func (s *WebsiteService) GetCategoryCatalog(ctx context.Context, domain, categorySlug string, opts *model.ListOptions) (*CategoryCatalogData, error) {
+	website, err := s.websiteRepo.GetByDomain(ctx, domain)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrWebsiteNotFound
+		}
+		return nil, fmt.Errorf("failed to get website: %w", err)
+	}
+
+	store, err := s.storeRepo.GetByID(ctx, website.StoreID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get store: %w", err)
+	}
+
+	categories, err := s.categoryRepo.GetByStoreID(ctx, website.StoreID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get categories: %w", err)
+	}
+	model.BuildCategoryTree(categories)
+
+	var category *model.Category
+	for _, c := range categories {
+		if c.Slug == categorySlug {
+			category = c
+			break
+		}
+	}
+	if category == nil {
+		return nil, ErrCategoryNotFound
+	}
+
+	var ids []int64
+	var collect func(c *model.Category)
+	collect = func(c *model.Category) {
+		ids = append(ids, c.ID)
+		for _, child := range c.Children {
+			collect(child)
+		}
+	}
+	collect(category)
+
+	active := true
+	opts.Active = &active
+	opts.CategoryIDs = ids
+	opts.InStock = store.HideOutOfStock
+
+	products, pageInfo, err := s.productRepo.ListByStoreID(ctx, website.StoreID, opts)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get products: %w", err)
 	}
+	if err := s.applyPrices(ctx, website.StoreID, products); err != nil {
+		return nil, err
+	}
+
+	return &CategoryCatalogData{
+		Store:      store,
+		Category:   category,
+		Products:   products,
+		Pagination: pageInfo,
+	}, nil
+}
+
+const (
+	defaultSearchLimit = 20
+	// searchCandidates bounds how many ranked hits are loaded before the
+	// price and stock filters are applied
+	searchCandidates = 500
+)
+
+// SearchCatalog ranks the published store's products against the query and
+// applies the price and stock filters, best match first.
+func (s *WebsiteService) SearchCatalog(ctx context.Context, domain string, req *model.SearchProductsRequest) (*CatalogData, error) {
+	website, err := s.websiteRepo.GetByDomain(ctx, domain)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrWebsiteNotFound
+		}
+		return nil, fmt.Errorf("failed to get website: %w", err)
+	}
+
+	store, err := s.storeRepo.GetByID(ctx, website.StoreID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get store: %w", err)
+	}
+
+	if store.HideOutOfStock {
+		req.InStock = true
+	}
+
+	hits, err := s.searcher.Search(ctx, website.StoreID, req.Query, searchCandidates)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search products: %w", err)
+	}
+
+	products := []*model.Product{}
+	if len(hits) > 0 {
+		ids := make([]int64, len(hits))
+		for i, hit := range hits {
+			ids[i] = hit.ProductID
+		}
+
+		found, err := s.productRepo.GetByIDs(ctx, website.StoreID, ids)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get products: %w", err)
+		}
+		if err := s.applyPrices(ctx, website.StoreID, found); err != nil {
+			return nil, err
+		}
+
+		byID := make(map[int64]*model.Product, len(found))
+		for _, product := range found {
+			byID[product.ID] = product
+		}
+
+		limit := req.Limit
+		if limit <= 0 {
+			limit = defaultSearchLimit
+		}
+
+		for _, hit := range hits {
+			product, ok := byID[hit.ProductID]
+			if !ok || !matchesSearchFilters(product, req) {
+				continue
+			}
+			products = append(products, product)
+			if len(products) == limit {
+				break
+			}
+		}
+	}
 
 	return &CatalogData{
+		Website:  website,
 		Store:    store,
 		Products: products,
 	}, nil
 }
+
+// applyPrices swaps in sale prices so customers see what they will pay.
+func (s *WebsiteService) applyPrices(ctx context.Context, storeID int64, products []*model.Product) error {
+	prices, err := s.pricer.PriceList(ctx, storeID, time.Now())
+	if err != nil {
+		return err
+	}
+	prices.Apply(products...)
+	return nil
+}
+
+func matchesSearchFilters(product *model.Product, req *model.SearchProductsRequest) bool {
+	if req.InStock && !product.InStock() {
+		return false
+	}
+
+	low, high := product.PriceRange()
+	if req.MinPrice != nil && high < *req.MinPrice {
+		return false
+	}
+	if req.MaxPrice != nil && low > *req.MaxPrice {
+		return false
+	}
+
+	return true
+}
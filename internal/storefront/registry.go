@@ -0,0 +1,277 @@
+// internal/storefront/registry.go
+package storefront
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"unicode/utf8"
+)
+
+var (
+	ErrUnknownTheme   = errors.New("unknown template")
+	ErrInvalidSetting = errors.New("invalid template setting")
+)
+
+// Setting types a theme can ask the owner to fill in
+const (
+	SettingColor  = "color"  // #rgb or #rrggbb
+	SettingSelect = "select" // one of Options
+	SettingText   = "text"   // plain text up to MaxLength characters
+)
+
+// defaultMaxLength applies to text settings that do not set their own
+const defaultMaxLength = 200
+
+var colorPattern = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// Theme is one website template: its page blocks plus the metadata the
+// website builder shows, read from theme.json next to theme.html.
+type Theme struct {
+	Name        string    `json:"name"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	Settings    []Setting `json:"settings"`
+
+	tmpl        *template.Template
+	preview     []byte
+	previewType string
+}
+
+type Setting struct {
+	Key       string   `json:"key"`
+	Label     string   `json:"label"`
+	Type      string   `json:"type"`
+	Default   string   `json:"default"`
+	Options   []Option `json:"options,omitempty"`
+	MaxLength int      `json:"max_length,omitempty"`
+}
+
+type Option struct {
+	Value string `json:"value"`
+	Label string `json:"label"`
+}
+
+// Preview returns the theme's preview image and its content type, or nil
+// when it has none.
+func (t *Theme) Preview() ([]byte, string) {
+	return t.preview, t.previewType
+}
+
+// Validate checks settings an owner picked for the theme. Empty values
+// fall back to the default.
+func (t *Theme) Validate(settings map[string]string) error {
+	for key, value := range settings {
+		setting := t.setting(key)
+		if setting == nil {
+			return fmt.Errorf("%w: %s has no setting %q", ErrInvalidSetting, t.Name, key)
+		}
+		if value == "" {
+			continue
+		}
+		if err := setting.check(value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Resolve returns every setting of the theme, taking the saved value when
+// it is valid and the default otherwise, so a website switched to another
+// theme still renders.
+func (t *Theme) Resolve(saved map[string]string) map[string]string {
+	resolved := make(map[string]string, len(t.Settings))
+	for _, setting := range t.Settings {
+		resolved[setting.Key] = setting.Default
+		if value := saved[setting.Key]; value != "" && setting.check(value) == nil {
+			resolved[setting.Key] = value
+		}
+	}
+	return resolved
+}
+
+func (t *Theme) setting(key string) *Setting {
+	for i := range t.Settings {
+		if t.Settings[i].Key == key {
+			return &t.Settings[i]
+		}
+	}
+	return nil
+}
+
+func (s *Setting) check(value string) error {
+	switch s.Type {
+	case SettingColor:
+		if !colorPattern.MatchString(value) {
+			return fmt.Errorf("%w: %s must be a color like #1a2b3c", ErrInvalidSetting, s.Key)
+		}
+	case SettingSelect:
+		for _, option := range s.Options {
+			if option.Value == value {
+				return nil
+			}
+		}
+		return fmt.Errorf("%w: %s must be one of the listed options", ErrInvalidSetting, s.Key)
+	case SettingText:
+		limit := s.MaxLength
+		if limit == 0 {
+			limit = defaultMaxLength
+		}
+		if utf8.RuneCountInString(value) > limit {
+			return fmt.Errorf("%w: %s is longer than %d characters", ErrInvalidSetting, s.Key, limit)
+		}
+	}
+	return nil
+}
+
+// Registry holds the themes websites can use: the built-in ones, plus any a
+// designer drops into a theme directory, one folder per theme:
+//
+//	{dir}/{name}/theme.html   blocks redefining the shared layout
+//	{dir}/{name}/theme.json   title, description and settings
+//	{dir}/{name}/preview.png  optional, also .jpg, .webp or .svg
+//
+// A folder named like a built-in theme replaces it.
+type Registry struct {
+	themes map[string]*Theme
+}
+
+// NewRegistry loads the built-in themes and, when dir is not empty, the
+// themes in dir.
+func NewRegistry(dir string) (*Registry, error) {
+	layout, err := fs.Sub(builtin, "templates")
+	if err != nil {
+		return nil, err
+	}
+	themes, err := fs.Sub(builtin, "templates/themes")
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Registry{themes: make(map[string]*Theme)}
+	if err := r.load(layout, themes); err != nil {
+		return nil, err
+	}
+	if dir != "" {
+		if err := r.load(layout, os.DirFS(dir)); err != nil {
+			return nil, err
+		}
+	}
+	if r.themes[DefaultTemplate] == nil {
+		return nil, fmt.Errorf("default template %s is missing", DefaultTemplate)
+	}
+	return r, nil
+}
+
+func (r *Registry) load(layout, themes fs.FS) error {
+	entries, err := fs.ReadDir(themes, ".")
+	if err != nil {
+		return fmt.Errorf("failed to read templates: %w", err)
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		theme, err := loadTheme(layout, themes, entry.Name())
+		if err != nil {
+			return fmt.Errorf("failed to load template %s: %w", entry.Name(), err)
+		}
+		r.themes[theme.Name] = theme
+	}
+	return nil
+}
+
+func loadTheme(layout, themes fs.FS, name string) (*Theme, error) {
+	meta, err := fs.ReadFile(themes, path.Join(name, "theme.json"))
+	if err != nil {
+		return nil, err
+	}
+	theme := &Theme{}
+	if err := json.Unmarshal(meta, theme); err != nil {
+		return nil, fmt.Errorf("invalid theme.json: %w", err)
+	}
+	theme.Name = name
+	if theme.Title == "" {
+		theme.Title = name
+	}
+
+	seen := make(map[string]bool)
+	for _, setting := range theme.Settings {
+		switch {
+		case setting.Key == "" || seen[setting.Key]:
+			return nil, fmt.Errorf("setting key %q is empty or repeated", setting.Key)
+		case setting.Type != SettingColor && setting.Type != SettingSelect && setting.Type != SettingText:
+			return nil, fmt.Errorf("setting %s has unknown type %q", setting.Key, setting.Type)
+		case setting.Type == SettingSelect && len(setting.Options) == 0:
+			return nil, fmt.Errorf("setting %s has no options", setting.Key)
+		}
+		if setting.Default != "" {
+			if err := setting.check(setting.Default); err != nil {
+				return nil, fmt.Errorf("bad default: %w", err)
+			}
+		}
+		seen[setting.Key] = true
+	}
+
+	theme.tmpl, err = template.New(name).Funcs(funcs).ParseFS(layout, "layout.html")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := theme.tmpl.ParseFS(themes, path.Join(name, "theme.html")); err != nil {
+		return nil, err
+	}
+
+	for _, preview := range previewTypes {
+		if image, err := fs.ReadFile(themes, path.Join(name, "preview"+preview.ext)); err == nil {
+			theme.preview, theme.previewType = image, preview.contentType
+			break
+		}
+	}
+	return theme, nil
+}
+
+var previewTypes = []struct{ ext, contentType string }{
+	{".png", "image/png"},
+	{".jpg", "image/jpeg"},
+	{".webp", "image/webp"},
+	{".svg", "image/svg+xml"},
+}
+
+// Themes returns every theme sorted by name.
+func (r *Registry) Themes() []*Theme {
+	themes := make([]*Theme, 0, len(r.themes))
+	for _, theme := range r.themes {
+		themes = append(themes, theme)
+	}
+	sort.Slice(themes, func(i, j int) bool { return themes[i].Name < themes[j].Name })
+	return themes
+}
+
+// Theme returns the named theme, or nil.
+func (r *Registry) Theme(name string) *Theme {
+	return r.themes[name]
+}
+
+// Validate checks that a website can use the theme with these settings.
+func (r *Registry) Validate(name string, settings map[string]string) error {
+	theme := r.themes[name]
+	if theme == nil {
+		return fmt.Errorf("%w: %s", ErrUnknownTheme, name)
+	}
+	return theme.Validate(settings)
+}
+
+// lookup returns the theme a website renders with.
+func (r *Registry) lookup(name string) *Theme {
+	if theme, ok := r.themes[name]; ok {
+		return theme
+	}
+	return r.themes[DefaultTemplate]
+}
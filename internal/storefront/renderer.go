@@ -0,0 +1,108 @@
+// internal/storefront/renderer.go
+package storefront
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"html/template"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"todo-go/internal/model"
+	"todo-go/pkg/sanitize"
+)
+
+//go:embed templates
+var builtin embed.FS
+
+// DefaultTemplate is used for websites whose Template is not a registered
+// theme.
+const DefaultTemplate = "modern"
+
+// ContentSecurityPolicy is sent with every rendered page. Pages run no
+// scripts at all, and the order form may only post to us and hand over to
+// WhatsApp.
+const ContentSecurityPolicy = "default-src 'none'; style-src 'unsafe-inline'; img-src 'self' https: data:; " +
+	"form-action 'self' https://wa.me https://api.whatsapp.com; base-uri 'none'; frame-ancestors 'none'"
+
+// Page is everything a theme can show for one catalog page.
+type Page struct {
+	Website     *model.Website
+	Store       *model.Store
+	Categories  []*model.Category
+	Products    []*model.Product
+	Shipping    []*model.ShippingMethod
+	OrderURL    string            // the order form posts here
+	NextURL     string            // next catalog page, empty on the last one
+	TableNumber string            // pre-filled into the order form
+	QRCode      string            // the QR code the customer came through
+	Settings    map[string]string // the theme's settings, filled in by Render
+}
+
+// Renderer turns catalog pages into HTML with the theme chosen by the
+// website. Every theme is the shared layout with some of its blocks
+// redefined.
+type Renderer struct {
+	registry *Registry
+}
+
+func NewRenderer(registry *Registry) *Renderer {
+	return &Renderer{registry: registry}
+}
+
+// Render writes the page as a complete HTML document. Nothing is written
+// when rendering fails.
+func (r *Renderer) Render(w io.Writer, page *Page) error {
+	theme := r.registry.lookup(page.Website.Template)
+	page.Settings = theme.Resolve(page.Website.Settings)
+
+	var buf bytes.Buffer
+	if err := theme.tmpl.ExecuteTemplate(&buf, "layout", page); err != nil {
+		return fmt.Errorf("failed to render %s: %w", theme.Name, err)
+	}
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+var funcs = template.FuncMap{
+	"rupiah":     rupiah,
+	"customCSS":  customCSS,
+	"customHTML": customHTML,
+	"inStock":    func(p *model.Product) bool { return p.InStock() },
+}
+
+// customCSS lets the owner's stylesheet into a <style> element. It was
+// filtered on save; filtering again covers websites saved before that.
+// html/template would reject a stylesheet as a whole, so any "<" that could
+// start "</style>" is written as a CSS escape instead.
+func customCSS(css string) template.CSS {
+	clean, _ := sanitize.CSS(css)
+	return template.CSS(strings.ReplaceAll(clean, "<", `\3c `))
+}
+
+// customHTML is the owner's own markup for their page, sanitized like
+// customCSS.
+func customHTML(html string) template.HTML {
+	clean, _ := sanitize.HTML(html)
+	return template.HTML(clean)
+}
+
+// rupiah formats an amount the Indonesian way, e.g. "Rp 15.000".
+func rupiah(amount float64) string {
+	digits := strconv.FormatFloat(math.Abs(math.Round(amount)), 'f', 0, 64)
+
+	var b strings.Builder
+	if amount <= -0.5 {
+		b.WriteByte('-')
+	}
+	b.WriteString("Rp ")
+	for i, d := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteByte('.')
+		}
+		b.WriteRune(d)
+	}
+	return b.String()
+}
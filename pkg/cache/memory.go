@@ -0,0 +1,77 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type entry struct {
+	value     []byte
+	expiresAt time.Time
+}
+
+// Memory is a Cache local to this process. It holds at most maxEntries
+// values; when full, expired values are dropped first, then arbitrary ones.
+type Memory struct {
+	mu         sync.Mutex
+	entries    map[string]entry
+	maxEntries int
+}
+
+func NewMemory(maxEntries int) *Memory {
+	return &Memory{
+		entries:    make(map[string]entry),
+		maxEntries: maxEntries,
+	}
+}
+
+func (m *Memory) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, ok := m.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	if time.Now().After(e.expiresAt) {
+		delete(m.entries, key)
+		return nil, false, nil
+	}
+	return e.value, true, nil
+}
+
+func (m *Memory) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.entries[key]; !ok && len(m.entries) >= m.maxEntries {
+		m.evict()
+	}
+	m.entries[key] = entry{value: value, expiresAt: time.Now().Add(ttl)}
+	return nil
+}
+
+func (m *Memory) Delete(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.entries, key)
+	return nil
+}
+
+// evict makes room for one more entry. Callers hold mu.
+func (m *Memory) evict() {
+	now := time.Now()
+	for key, e := range m.entries {
+		if now.After(e.expiresAt) {
+			delete(m.entries, key)
+		}
+	}
+	for key := range m.entries {
+		if len(m.entries) < m.maxEntries {
+			break
+		}
+		delete(m.entries, key)
+	}
+}
@@ -0,0 +1,16 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// Cache keeps values for a limited time. Memory is the default; any shared
+// store with expiring keys, such as Redis or Memcached, can stand in for it
+// so several API instances share one cache.
+type Cache interface {
+	// Get reports false when key is missing or expired
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, key string) error
+}
@@ -0,0 +1,110 @@
+package domain
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+)
+
+var ErrNotVerified = errors.New("verification TXT record not found")
+
+// Resolver looks up DNS TXT records. *net.Resolver satisfies it; tests and
+// local setups can use StaticResolver.
+type Resolver interface {
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+}
+
+// StaticResolver answers TXT lookups from a map of record name to values.
+type StaticResolver map[string][]string
+
+func (r StaticResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	records, ok := r[strings.TrimSuffix(name, ".")]
+	if !ok {
+		return nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+	}
+	return records, nil
+}
+
+// Verifier checks domain ownership: the owner publishes a TXT record with a
+// token we gave them under a fixed label of their domain.
+type Verifier struct {
+	resolver Resolver
+	label    string // e.g. "_umkm-verify"
+}
+
+func NewVerifier(resolver Resolver, label string) *Verifier {
+	return &Verifier{resolver: resolver, label: label}
+}
+
+// Record returns the name and value of the TXT record that proves
+// ownership of host.
+func (v *Verifier) Record(host, token string) (string, string) {
+	return v.label + "." + host, "umkm-verification=" + token
+}
+
+// Verify looks for the TXT record of host carrying token.
+func (v *Verifier) Verify(ctx context.Context, host, token string) error {
+	name, want := v.Record(host, token)
+	records, err := v.resolver.LookupTXT(ctx, name)
+	if err != nil {
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+			return fmt.Errorf("%w: %s", ErrNotVerified, name)
+		}
+		return fmt.Errorf("failed to look up %s: %w", name, err)
+	}
+
+	for _, record := range records {
+		if strings.TrimSpace(record) == want {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s has no %q", ErrNotVerified, name, want)
+}
+
+// NewToken returns a random verification token.
+func NewToken() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// Normalize lowercases host and strips a port and trailing dot.
+func Normalize(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(host)), ".")
+}
+
+// ValidHostname reports whether host is a fully qualified hostname made of
+// letter, digit and hyphen labels, like "tokopakjohn.com".
+func ValidHostname(host string) bool {
+	if len(host) > 253 || !strings.Contains(host, ".") {
+		return false
+	}
+	for _, label := range strings.Split(host, ".") {
+		if !ValidLabel(label) {
+			return false
+		}
+	}
+	return true
+}
+
+// ValidLabel reports whether s can be one label of a hostname: 1 to 63
+// lowercase letters, digits and inner hyphens.
+func ValidLabel(s string) bool {
+	if s == "" || len(s) > 63 || s[0] == '-' || s[len(s)-1] == '-' {
+		return false
+	}
+	for _, c := range s {
+		if !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '-') {
+			return false
+		}
+	}
+	return true
+}
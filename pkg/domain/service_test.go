@@ -0,0 +1,170 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// failingResolver fails every lookup, like a DNS server that times out.
+type failingResolver struct{ err error }
+
+func (r failingResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	return nil, r.err
+}
+
+func TestVerify(t *testing.T) {
+	errTimeout := errors.New("i/o timeout")
+	tests := []struct {
+		name     string
+		resolver Resolver
+		wantErr  error // nil when the domain must verify
+	}{
+		{
+			name: "match",
+			resolver: StaticResolver{
+				"_umkm-verify.tokopakjohn.com": {"v=spf1 -all", "umkm-verification=abc123"},
+			},
+		},
+		{
+			name: "match with spaces",
+			resolver: StaticResolver{
+				"_umkm-verify.tokopakjohn.com": {"  umkm-verification=abc123 "},
+			},
+		},
+		{
+			name:     "missing record",
+			resolver: StaticResolver{},
+			wantErr:  ErrNotVerified,
+		},
+		{
+			name: "wrong token",
+			resolver: StaticResolver{
+				"_umkm-verify.tokopakjohn.com": {"umkm-verification=other"},
+			},
+			wantErr: ErrNotVerified,
+		},
+		{
+			name: "record under the bare domain",
+			resolver: StaticResolver{
+				"tokopakjohn.com": {"umkm-verification=abc123"},
+			},
+			wantErr: ErrNotVerified,
+		},
+		{
+			name:     "resolver error",
+			resolver: failingResolver{err: errTimeout},
+			wantErr:  errTimeout,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := NewVerifier(tt.resolver, "_umkm-verify")
+
+			err := v.Verify(context.Background(), "tokopakjohn.com", "abc123")
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatalf("Verify = %v, want nil", err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Verify = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == errTimeout && errors.Is(err, ErrNotVerified) {
+				t.Errorf("Verify = %v, a failed lookup must not read as a missing record", err)
+			}
+		})
+	}
+}
+
+func TestRecord(t *testing.T) {
+	v := NewVerifier(StaticResolver{}, "_umkm-verify")
+
+	name, value := v.Record("tokopakjohn.com", "abc123")
+	if name != "_umkm-verify.tokopakjohn.com" {
+		t.Errorf("name = %q", name)
+	}
+	if value != "umkm-verification=abc123" {
+		t.Errorf("value = %q", value)
+	}
+}
+
+func TestNewToken(t *testing.T) {
+	a, b := NewToken(), NewToken()
+	if len(a) != 32 || strings.Trim(a, "0123456789abcdef") != "" {
+		t.Errorf("NewToken = %q, want 32 hex characters", a)
+	}
+	if a == b {
+		t.Error("NewToken returned the same token twice")
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"TokoPakJohn.com", "tokopakjohn.com"},
+		{"tokopakjohn.com.", "tokopakjohn.com"},
+		{" shop.tokopakjohn.com:8080 ", "shop.tokopakjohn.com"},
+		{"tokopakjohn.com:443", "tokopakjohn.com"},
+	}
+	for _, tt := range tests {
+		if got := Normalize(tt.in); got != tt.want {
+			t.Errorf("Normalize(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestValidHostname(t *testing.T) {
+	tests := []struct {
+		host string
+		want bool
+	}{
+		{"tokopakjohn.com", true},
+		{"shop.toko-pak-john.co.id", true},
+		{"xn--80ak6aa92e.com", true},
+		{"localhost", false},
+		{"", false},
+		{"tokopakjohn..com", false},
+		{".tokopakjohn.com", false},
+		{"-toko.com", false},
+		{"toko-.com", false},
+		{"Toko.com", false},
+		{"toko_pak.com", false},
+		{"toko pak.com", false},
+		{"toko.com/path", false},
+		{strings.Repeat("a", 64) + ".com", false},
+		{strings.Repeat(strings.Repeat("a", 63)+".", 4) + "com", false},
+	}
+	for _, tt := range tests {
+		if got := ValidHostname(tt.host); got != tt.want {
+			t.Errorf("ValidHostname(%q) = %v, want %v", tt.host, got, tt.want)
+		}
+	}
+}
+
+func TestValidLabel(t *testing.T) {
+	tests := []struct {
+		label string
+		want  bool
+	}{
+		{"warung-bu-siti", true},
+		{"toko2", true},
+		{"a", true},
+		{strings.Repeat("a", 63), true},
+		{strings.Repeat("a", 64), false},
+		{"", false},
+		{"-toko", false},
+		{"toko-", false},
+		{"Toko", false},
+		{"deleted_42", false},
+		{"toko.pak", false},
+		{"tokó", false},
+	}
+	for _, tt := range tests {
+		if got := ValidLabel(tt.label); got != tt.want {
+			t.Errorf("ValidLabel(%q) = %v, want %v", tt.label, got, tt.want)
+		}
+	}
+}
@@ -39,7 +39,18 @@ func (s *Service) JWT(next http.Handler) http.Handler {
 		}
 
 		claims := token.Claims.(jwtLib.MapClaims)
-		userID := claims["user_id"].(float64)
+
+		// Single-purpose tokens (e.g. email verification) are not access tokens
+		if _, ok := claims["purpose"]; ok {
+			resp.WriteJSON(w, http.StatusUnauthorized, map[string]any{"error": "unauthorized"})
+			return
+		}
+
+		userID, ok := claims["user_id"].(float64)
+		if !ok {
+			resp.WriteJSON(w, http.StatusUnauthorized, map[string]any{"error": "unauthorized"})
+			return
+		}
 
 		user, err := s.userRepo.GetByID(ctx, int64(userID))
 		if err != nil {
@@ -47,6 +58,13 @@ func (s *Service) JWT(next http.Handler) http.Handler {
 			return
 		}
 
+		// Tokens issued before a password change or account deletion are revoked
+		tokenVersion, _ := claims["token_version"].(float64)
+		if int64(tokenVersion) != user.TokenVersion {
+			resp.WriteJSON(w, http.StatusUnauthorized, map[string]any{"error": "unauthorized"})
+			return
+		}
+
 		r = r.WithContext(context.WithValue(ctx, "user", user))
 		next.ServeHTTP(w, r)
 	})
@@ -0,0 +1,43 @@
+package notify
+
+import (
+	"context"
+	"log"
+	"todo-go/pkg/mailer"
+)
+
+// Notification is a message meant for one store owner.
+type Notification struct {
+	Email   string
+	Subject string
+	Body    string
+}
+
+type Notifier interface {
+	Notify(ctx context.Context, n *Notification) error
+}
+
+// LogNotifier writes notifications to the server log.
+type LogNotifier struct{}
+
+func NewLogNotifier() *LogNotifier {
+	return &LogNotifier{}
+}
+
+func (n *LogNotifier) Notify(ctx context.Context, notification *Notification) error {
+	log.Printf("notify email=%s subject=%q\n%s", notification.Email, notification.Subject, notification.Body)
+	return nil
+}
+
+// MailNotifier delivers notifications by email.
+type MailNotifier struct {
+	mailer mailer.Mailer
+}
+
+func NewMailNotifier(m mailer.Mailer) *MailNotifier {
+	return &MailNotifier{mailer: m}
+}
+
+func (n *MailNotifier) Notify(ctx context.Context, notification *Notification) error {
+	return n.mailer.Send(ctx, notification.Email, notification.Subject, notification.Body)
+}
@@ -0,0 +1,152 @@
+package payment
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const mockSignatureHeader = "X-Mock-Signature"
+
+type mockPayment struct {
+	reference string
+	amount    float64
+	status    Status
+}
+
+// MockProvider is an in-memory gateway for local development. Payments stay
+// pending until Simulate settles them; its webhooks are signed with an
+// HMAC-SHA256 of the body like a real gateway's would be.
+type MockProvider struct {
+	secret []byte
+
+	mu       sync.Mutex
+	payments map[string]*mockPayment
+}
+
+func NewMockProvider(secret string) *MockProvider {
+	return &MockProvider{
+		secret:   []byte(secret),
+		payments: make(map[string]*mockPayment),
+	}
+}
+
+func (p *MockProvider) Name() string {
+	return "mock"
+}
+
+func (p *MockProvider) CreateIntent(ctx context.Context, req IntentRequest) (*Intent, error) {
+	ref := "MOCK-" + randomHex(8)
+
+	p.mu.Lock()
+	p.payments[ref] = &mockPayment{reference: req.Reference, amount: req.Amount, status: StatusPending}
+	p.mu.Unlock()
+
+	intent := &Intent{
+		ProviderRef: ref,
+		PaymentURL:  "https://mock-payment.local/pay/" + ref,
+		ExpiresAt:   req.ExpiresAt,
+	}
+	switch req.Method {
+	case "va":
+		intent.VANumber = "8808" + randomDigits(12)
+	default:
+		intent.QRString = fmt.Sprintf("MOCKQRIS|%s|%.0f", ref, req.Amount)
+	}
+	return intent, nil
+}
+
+type mockWebhook struct {
+	EventID     string  `json:"event_id"`
+	ProviderRef string  `json:"provider_ref"`
+	Reference   string  `json:"reference"`
+	Status      Status  `json:"status"`
+	Amount      float64 `json:"amount"`
+}
+
+func (p *MockProvider) ParseWebhook(header http.Header, body []byte) (*Event, error) {
+	signature, err := hex.DecodeString(header.Get(mockSignatureHeader))
+	if err != nil || !hmac.Equal(signature, p.sign(body)) {
+		return nil, ErrInvalidSignature
+	}
+
+	var hook mockWebhook
+	if err := json.Unmarshal(body, &hook); err != nil {
+		return nil, fmt.Errorf("failed to decode webhook: %w", err)
+	}
+	return &Event{
+		EventID:     hook.EventID,
+		ProviderRef: hook.ProviderRef,
+		Reference:   hook.Reference,
+		Status:      hook.Status,
+		Amount:      hook.Amount,
+	}, nil
+}
+
+func (p *MockProvider) Status(ctx context.Context, providerRef string) (Status, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	payment, ok := p.payments[providerRef]
+	if !ok {
+		return "", ErrUnknownPayment
+	}
+	return payment.status, nil
+}
+
+// Simulate settles a mock payment as the customer would and returns the
+// signed webhook the gateway sends for it.
+func (p *MockProvider) Simulate(providerRef string, status Status) (http.Header, []byte, error) {
+	p.mu.Lock()
+	payment, ok := p.payments[providerRef]
+	if ok {
+		payment.status = status
+	}
+	p.mu.Unlock()
+	if !ok {
+		return nil, nil, ErrUnknownPayment
+	}
+
+	body, err := json.Marshal(mockWebhook{
+		EventID:     fmt.Sprintf("evt-%s-%d", randomHex(6), time.Now().UnixNano()),
+		ProviderRef: providerRef,
+		Reference:   payment.reference,
+		Status:      status,
+		Amount:      payment.amount,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	header := http.Header{}
+	header.Set(mockSignatureHeader, hex.EncodeToString(p.sign(body)))
+	return header, body, nil
+}
+
+func (p *MockProvider) sign(body []byte) []byte {
+	mac := hmac.New(sha256.New, p.secret)
+	mac.Write(body)
+	return mac.Sum(nil)
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func randomDigits(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	for i := range b {
+		b[i] = '0' + b[i]%10
+	}
+	return string(b)
+}
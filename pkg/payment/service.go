@@ -0,0 +1,65 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+)
+
+var (
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	ErrUnknownPayment   = errors.New("payment not known to provider")
+)
+
+type Status string
+
+const (
+	StatusPending Status = "pending"
+	StatusPaid    Status = "paid"
+	StatusFailed  Status = "failed"
+	StatusExpired Status = "expired"
+)
+
+// IntentRequest asks the provider to collect Amount rupiah for one order.
+// Reference is our own id for the payment and comes back in webhooks.
+type IntentRequest struct {
+	Reference     string
+	Method        string // "qris" or "va"
+	Amount        float64
+	CustomerName  string
+	CustomerPhone string
+	ExpiresAt     time.Time
+}
+
+// Intent is what the customer needs to pay: a QR string to scan or a
+// virtual account number to transfer to.
+type Intent struct {
+	ProviderRef string
+	QRString    string
+	VANumber    string
+	PaymentURL  string
+	ExpiresAt   time.Time
+}
+
+// Event is a verified status change reported by the provider. EventID is
+// unique per delivery attempt group so retries can be recognised.
+type Event struct {
+	EventID     string
+	ProviderRef string
+	Reference   string
+	Status      Status
+	Amount      float64
+}
+
+// Provider is a payment gateway for QRIS and virtual account style flows.
+// Payments are created as intents, settle asynchronously and are reported
+// through signed webhooks; Status lets pending payments be checked when a
+// webhook never arrives.
+type Provider interface {
+	Name() string
+	CreateIntent(ctx context.Context, req IntentRequest) (*Intent, error)
+	// ParseWebhook verifies the signature of a callback and decodes it
+	ParseWebhook(header http.Header, body []byte) (*Event, error)
+	Status(ctx context.Context, providerRef string) (Status, error)
+}
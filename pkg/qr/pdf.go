@@ -0,0 +1,158 @@
+package qr
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Sheet is the text printed around a code on a PDF sheet.
+type Sheet struct {
+	Title   string // large, above the code, e.g. the store name
+	Caption string // below the code, e.g. "Scan untuk pesan"
+	Footer  string // small, at the bottom, e.g. the encoded URL
+}
+
+// A5 portrait in points, a common size for table stands and flyers
+const (
+	pageWidth  = 420
+	pageHeight = 595
+	pageMargin = 30
+	codeSide   = 300
+)
+
+// PDF lays the code out on a one page A5 sheet with the sheet's text. The
+// code is embedded as an image at its pixel size, so callers wanting a crisp
+// print should ask for around 1000px.
+func (c *Code) PDF(sheet Sheet) ([]byte, error) {
+	var raw bytes.Buffer
+	img := c.Image()
+	bounds := img.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r, g, b, _ := img.At(x, y).RGBA()
+			raw.Write([]byte{byte(r >> 8), byte(g >> 8), byte(b >> 8)})
+		}
+	}
+	var pixels bytes.Buffer
+	zw := zlib.NewWriter(&pixels)
+	if _, err := zw.Write(raw.Bytes()); err != nil {
+		return nil, fmt.Errorf("failed to compress QR code: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to compress QR code: %w", err)
+	}
+
+	var content bytes.Buffer
+	codeX := (pageWidth - codeSide) / 2
+	codeY := 195
+	fmt.Fprintf(&content, "q %d 0 0 %d %d %d cm /Im1 Do Q\n", codeSide, codeSide, codeX, codeY)
+	writeText(&content, "F2", sheet.Title, 26, 525, [3]float64{0, 0, 0})
+	writeText(&content, "F1", sheet.Caption, 18, 160, [3]float64{0, 0, 0})
+	writeText(&content, "F1", sheet.Footer, 9, pageMargin, [3]float64{0.4, 0.4, 0.4})
+
+	return writePDF(bounds, pixels.Bytes(), content.Bytes()), nil
+}
+
+// writeText draws one centered line, shrinking the font until it fits
+// between the margins.
+func writeText(w *bytes.Buffer, font, text string, size, y float64, rgb [3]float64) {
+	text = winAnsi(text)
+	if text == "" {
+		return
+	}
+
+	widths := helvetica
+	if font == "F2" {
+		widths = helveticaBold
+	}
+	units := 0
+	for i := 0; i < len(text); i++ {
+		units += widths[text[i]-' ']
+	}
+	if fit := float64(pageWidth-2*pageMargin) * 1000 / float64(units); fit < size {
+		size = fit
+	}
+	x := (pageWidth - float64(units)*size/1000) / 2
+
+	escaped := strings.NewReplacer(`\`, `\\`, `(`, `\(`, `)`, `\)`).Replace(text)
+	fmt.Fprintf(w, "BT %.2f %.2f %.2f rg /%s %.2f Tf %.2f %.2f Td (%s) Tj ET\n",
+		rgb[0], rgb[1], rgb[2], font, size, x, y, escaped)
+}
+
+// writePDF assembles a one page document: catalog, page tree, page, the two
+// standard fonts, the code image and the page content.
+func writePDF(bounds image.Rectangle, pixels, content []byte) []byte {
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] "+
+			"/Resources << /Font << /F1 4 0 R /F2 5 0 R >> /XObject << /Im1 6 0 R >> >> /Contents 7 0 R >>",
+			pageWidth, pageHeight),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>",
+		fmt.Sprintf("<< /Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceRGB "+
+			"/BitsPerComponent 8 /Filter /FlateDecode /Length %d >>\nstream\n%s\nendstream",
+			bounds.Dx(), bounds.Dy(), len(pixels), pixels),
+		fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content),
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return buf.Bytes()
+}
+
+// winAnsi folds text to the printable ASCII the standard fonts are measured
+// for: accents are dropped and anything else becomes "?".
+func winAnsi(text string) string {
+	var b strings.Builder
+	for _, r := range norm.NFKD.String(text) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			continue
+		case unicode.IsSpace(r):
+			b.WriteByte(' ')
+		case r >= ' ' && r <= '~':
+			b.WriteRune(r)
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// Advance widths of ' ' to '~' in 1/1000 em, from the Adobe font metrics
+var helvetica = [95]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+var helveticaBold = [95]int{
+	278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+	975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+	333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+	611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+}
@@ -0,0 +1,262 @@
+package qr
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+	"strconv"
+	"strings"
+	"todo-go/pkg/imaging"
+
+	"github.com/skip2/go-qrcode"
+)
+
+var (
+	ErrInvalidLevel = errors.New("invalid error correction level, use L, M, Q or H")
+	ErrInvalidColor = errors.New("invalid color, use #rgb or #rrggbb")
+	ErrLowContrast  = errors.New("foreground and background colors are too similar to scan")
+	ErrTooSmall     = errors.New("size is too small for this much content")
+)
+
+// Error correction levels. Higher levels survive more damage, or a logo
+// covering the middle, at the cost of denser codes.
+const (
+	LevelLow      = "L" // ~7% of the code can be restored
+	LevelMedium   = "M" // ~15%
+	LevelQuartile = "Q" // ~25%
+	LevelHigh     = "H" // ~30%
+	DefaultSize   = 256
+	DefaultLevel  = LevelMedium
+)
+
+// logoShare is how much of the code's width the logo and its pad take. The
+// pad covers about 8% of the area, well inside what level H restores.
+const logoShare = 0.22
+
+// minContrast is the WCAG contrast ratio below which phone cameras start
+// missing modules, e.g. light grey on white.
+const minContrast = 3.0
+
+var levels = map[string]qrcode.RecoveryLevel{
+	LevelLow:      qrcode.Low,
+	LevelMedium:   qrcode.Medium,
+	LevelQuartile: qrcode.High,
+	LevelHigh:     qrcode.Highest,
+}
+
+// Options customize a generated code. Zero values fall back to a 256px
+// black on white code at level M.
+type Options struct {
+	Size       int    // width and height in pixels
+	Level      string // L, M, Q or H
+	Foreground string // #rgb or #rrggbb
+	Background string
+	Logo       image.Image // drawn in the center; forces level H
+}
+
+// Code is a QR code ready to be drawn in any format.
+type Code struct {
+	modules    [][]bool
+	size       int
+	foreground color.RGBA
+	background color.RGBA
+	logo       image.Image
+}
+
+// New encodes text with opts.
+func (s *Service) New(text string, opts Options) (*Code, error) {
+	if opts.Size == 0 {
+		opts.Size = DefaultSize
+	}
+	if opts.Level == "" {
+		opts.Level = DefaultLevel
+	}
+	if opts.Logo != nil {
+		opts.Level = LevelHigh
+	}
+
+	level, ok := levels[strings.ToUpper(opts.Level)]
+	if !ok {
+		return nil, ErrInvalidLevel
+	}
+	fg, err := parseColor(opts.Foreground, color.RGBA{A: 255})
+	if err != nil {
+		return nil, err
+	}
+	bg, err := parseColor(opts.Background, color.RGBA{R: 255, G: 255, B: 255, A: 255})
+	if err != nil {
+		return nil, err
+	}
+	if contrast(fg, bg) < minContrast {
+		return nil, ErrLowContrast
+	}
+
+	q, err := qrcode.New(text, level)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate QR code: %w", err)
+	}
+	modules := q.Bitmap()
+	if opts.Size < len(modules) {
+		return nil, fmt.Errorf("%w: needs at least %dpx", ErrTooSmall, len(modules))
+	}
+
+	return &Code{
+		modules:    modules,
+		size:       opts.Size,
+		foreground: fg,
+		background: bg,
+		logo:       opts.Logo,
+	}, nil
+}
+
+// Image draws the code at its size. Modules are whole pixels so edges stay
+// sharp; the quiet zone absorbs what is left over.
+func (c *Code) Image() image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, c.size, c.size))
+	draw.Draw(img, img.Bounds(), &image.Uniform{c.background}, image.Point{}, draw.Src)
+
+	n := len(c.modules)
+	scale := c.size / n
+	offset := (c.size - n*scale) / 2
+	fg := &image.Uniform{c.foreground}
+	for y, row := range c.modules {
+		for x, dark := range row {
+			if dark {
+				r := image.Rect(x*scale, y*scale, (x+1)*scale, (y+1)*scale).Add(image.Pt(offset, offset))
+				draw.Draw(img, r, fg, image.Point{}, draw.Src)
+			}
+		}
+	}
+
+	if c.logo != nil {
+		pad, inner := c.logoBox()
+		draw.Draw(img, pad, &image.Uniform{c.background}, image.Point{}, draw.Src)
+		logo := imaging.Resize(c.logo, inner.Dx())
+		b := logo.Bounds()
+		at := inner.Min.Add(image.Pt((inner.Dx()-b.Dx())/2, (inner.Dy()-b.Dy())/2))
+		draw.Draw(img, image.Rectangle{Min: at, Max: at.Add(b.Size())}, logo, b.Min, draw.Over)
+	}
+
+	return img
+}
+
+// PNG draws the code as a PNG image.
+func (c *Code) PNG() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, c.Image()); err != nil {
+		return nil, fmt.Errorf("failed to encode QR code: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// SVG draws the code as a vector image, one module per user unit, so it
+// prints sharp at any size.
+func (c *Code) SVG() ([]byte, error) {
+	n := len(c.modules)
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`,
+		c.size, c.size, n, n)
+	fmt.Fprintf(&buf, `<rect width="%d" height="%d" fill="%s"/>`, n, n, hex(c.background))
+
+	// One path, with horizontal runs of dark modules merged
+	fmt.Fprintf(&buf, `<path fill="%s" d="`, hex(c.foreground))
+	for y, row := range c.modules {
+		for x := 0; x < n; x++ {
+			if !row[x] {
+				continue
+			}
+			start := x
+			for x < n && row[x] {
+				x++
+			}
+			fmt.Fprintf(&buf, "M%d %dh%dv1h-%dz", start, y, x-start, x-start)
+		}
+	}
+	buf.WriteString(`"/>`)
+
+	if c.logo != nil {
+		// Lay the box out in pixels, then scale it to modules
+		pad, inner := c.logoBox()
+		unit := float64(n) / float64(c.size)
+		logo, err := c.logoPNG(inner.Dx())
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(&buf, `<rect x="%.2f" y="%.2f" width="%.2f" height="%.2f" fill="%s"/>`,
+			float64(pad.Min.X)*unit, float64(pad.Min.Y)*unit, float64(pad.Dx())*unit, float64(pad.Dy())*unit, hex(c.background))
+		fmt.Fprintf(&buf, `<image x="%.2f" y="%.2f" width="%.2f" height="%.2f" href="data:image/png;base64,%s"/>`,
+			float64(inner.Min.X)*unit, float64(inner.Min.Y)*unit, float64(inner.Dx())*unit, float64(inner.Dy())*unit,
+			base64.StdEncoding.EncodeToString(logo))
+	}
+
+	buf.WriteString(`</svg>`)
+	return buf.Bytes(), nil
+}
+
+// logoBox returns the square cleared for the logo and the part of it the
+// logo is drawn in, both centered.
+func (c *Code) logoBox() (pad, inner image.Rectangle) {
+	side := int(float64(c.size) * logoShare)
+	margin := max(1, side/10)
+	at := (c.size - side) / 2
+	pad = image.Rect(at, at, at+side, at+side)
+	return pad, pad.Inset(margin)
+}
+
+func (c *Code) logoPNG(side int) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, imaging.Resize(c.logo, side)); err != nil {
+		return nil, fmt.Errorf("failed to encode logo: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// parseColor reads #rgb or #rrggbb, returning def for an empty string.
+func parseColor(s string, def color.RGBA) (color.RGBA, error) {
+	if s == "" {
+		return def, nil
+	}
+	s = strings.TrimPrefix(s, "#")
+	if len(s) == 3 {
+		s = string([]byte{s[0], s[0], s[1], s[1], s[2], s[2]})
+	}
+
+	if len(s) != 6 {
+		return color.RGBA{}, ErrInvalidColor
+	}
+	v, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		return color.RGBA{}, ErrInvalidColor
+	}
+	return color.RGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 255}, nil
+}
+
+func hex(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// contrast is the WCAG contrast ratio of two colors, from 1 to 21.
+func contrast(a, b color.RGBA) float64 {
+	la, lb := luminance(a), luminance(b)
+	if la < lb {
+		la, lb = lb, la
+	}
+	return (la + 0.05) / (lb + 0.05)
+}
+
+func luminance(c color.RGBA) float64 {
+	channel := func(v uint8) float64 {
+		f := float64(v) / 255
+		if f <= 0.03928 {
+			return f / 12.92
+		}
+		return math.Pow((f+0.055)/1.055, 2.4)
+	}
+	return 0.2126*channel(c.R) + 0.7152*channel(c.G) + 0.0722*channel(c.B)
+}
@@ -0,0 +1,137 @@
+package qris
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidPayload = errors.New("invalid QRIS payload")
+
+// Tags of the EMVCo merchant presented QR data objects used here
+const (
+	tagFormat     = "00"
+	tagInitiation = "01"
+	tagAmount     = "54"
+	tagCRC        = "63"
+
+	initiationDynamic = "12"
+)
+
+// Field is one tag-length-value data object of a QRIS payload.
+type Field struct {
+	Tag   string
+	Value string
+}
+
+// Parse splits a QRIS payload into its top-level data objects. The payload
+// must start with the format indicator and end with a CRC that matches the
+// rest of it.
+func Parse(payload string) ([]Field, error) {
+	payload = strings.TrimSpace(payload)
+
+	var fields []Field
+	for rest := payload; rest != ""; {
+		if len(rest) < 4 {
+			return nil, fmt.Errorf("%w: truncated data object", ErrInvalidPayload)
+		}
+		// The length is always two ASCII digits; Atoi alone would take "-1"
+		if !isDigit(rest[2]) || !isDigit(rest[3]) {
+			return nil, fmt.Errorf("%w: bad length for tag %s", ErrInvalidPayload, rest[:2])
+		}
+		n := int(rest[2]-'0')*10 + int(rest[3]-'0')
+		if len(rest) < 4+n {
+			return nil, fmt.Errorf("%w: bad length for tag %s", ErrInvalidPayload, rest[:2])
+		}
+		fields = append(fields, Field{Tag: rest[:2], Value: rest[4 : 4+n]})
+		rest = rest[4+n:]
+	}
+
+	if len(fields) < 2 || fields[0].Tag != tagFormat || fields[0].Value != "01" {
+		return nil, fmt.Errorf("%w: missing payload format indicator", ErrInvalidPayload)
+	}
+	last := fields[len(fields)-1]
+	if last.Tag != tagCRC || len(last.Value) != 4 {
+		return nil, fmt.Errorf("%w: missing CRC", ErrInvalidPayload)
+	}
+	if want := checksum(payload[:len(payload)-4]); !strings.EqualFold(last.Value, want) {
+		return nil, fmt.Errorf("%w: CRC is %s, expected %s", ErrInvalidPayload, last.Value, want)
+	}
+
+	return fields, nil
+}
+
+// Dynamic turns a store's static QRIS payload into a one-off payload for
+// the given amount in rupiah. The initiation method becomes dynamic, any
+// amount already in the payload is replaced and the CRC is recalculated.
+func Dynamic(payload string, amount float64) (string, error) {
+	if amount <= 0 {
+		return "", fmt.Errorf("%w: amount must be positive", ErrInvalidPayload)
+	}
+	value := strconv.FormatFloat(math.Round(amount*100)/100, 'f', -1, 64)
+	if len(value) > 13 {
+		return "", fmt.Errorf("%w: amount too large", ErrInvalidPayload)
+	}
+
+	fields, err := Parse(payload)
+	if err != nil {
+		return "", err
+	}
+
+	// Data objects are kept in tag order, the format indicator first
+	out := []Field{fields[0], {Tag: tagInitiation, Value: initiationDynamic}}
+	added := false
+	for _, field := range fields[1:] {
+		switch field.Tag {
+		case tagInitiation, tagAmount, tagCRC:
+			continue
+		}
+		if !added && field.Tag > tagAmount {
+			out = append(out, Field{Tag: tagAmount, Value: value})
+			added = true
+		}
+		out = append(out, field)
+	}
+	if !added {
+		out = append(out, Field{Tag: tagAmount, Value: value})
+	}
+
+	return Encode(out), nil
+}
+
+// Encode joins data objects into a payload and appends its CRC.
+func Encode(fields []Field) string {
+	var b strings.Builder
+	for _, field := range fields {
+		if field.Tag == tagCRC {
+			continue
+		}
+		fmt.Fprintf(&b, "%s%02d%s", field.Tag, len(field.Value), field.Value)
+	}
+	b.WriteString(tagCRC + "04")
+	b.WriteString(checksum(b.String()))
+	return b.String()
+}
+
+// checksum is the CRC-16/CCITT-FALSE of data, including the tag and length
+// of the CRC object itself, as four uppercase hex digits.
+func checksum(data string) string {
+	crc := uint16(0xFFFF)
+	for i := 0; i < len(data); i++ {
+		crc ^= uint16(data[i]) << 8
+		for range 8 {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return fmt.Sprintf("%04X", crc)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
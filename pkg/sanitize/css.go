@@ -0,0 +1,204 @@
+package sanitize
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var allowedProperties = map[string]bool{
+	"color": true, "background": true, "background-color": true, "background-position": true,
+	"background-size": true, "background-repeat": true, "opacity": true,
+	"line-height": true, "letter-spacing": true, "word-spacing": true, "white-space": true,
+	"vertical-align": true, "width": true, "height": true, "min-width": true, "max-width": true,
+	"min-height": true, "max-height": true, "box-sizing": true, "box-shadow": true,
+	"display": true, "visibility": true, "overflow": true, "overflow-x": true, "overflow-y": true,
+	"float": true, "clear": true, "order": true, "gap": true, "row-gap": true, "column-gap": true,
+	"object-fit": true, "object-position": true, "aspect-ratio": true, "transform": true,
+	"cursor": true,
+}
+
+// allowedPrefixes cover property families such as margin-top or
+// border-radius
+var allowedPrefixes = []string{
+	"margin", "padding", "border", "outline", "font", "text-", "flex", "grid",
+	"align-", "justify-", "place-", "list-style", "transition", "animation",
+}
+
+// nestedAtRules hold rules of their own; every other at-rule, @import and
+// @font-face among them, is dropped
+var nestedAtRules = map[string]bool{
+	"@media": true, "@supports": true, "@keyframes": true, "@-webkit-keyframes": true,
+}
+
+// unsafeValues can load resources or run code in some browser
+var unsafeValues = []string{
+	"url(", "image(", "image-set(", "expression(", "javascript:", "-moz-binding", "behavior", "\\", "<", "@",
+}
+
+var cssComment = regexp.MustCompile(`(?s)/\*.*?(\*/|$)`)
+
+// CSS filters a stylesheet down to rules whose selectors are plain and whose
+// declarations use allowlisted properties without URLs or expressions.
+// @media, @supports and @keyframes blocks are filtered the same way. It
+// returns the clean stylesheet and a description of what it took out.
+func CSS(input string) (string, []string) {
+	f := &cssFilter{}
+	out := f.rules(cssComment.ReplaceAllString(input, ""))
+	return out, f.rejected
+}
+
+// Declarations filters a list of declarations, the body of a rule or a
+// style attribute.
+func Declarations(input string) (string, []string) {
+	f := &cssFilter{}
+	out := f.declarations(cssComment.ReplaceAllString(input, ""))
+	return out, f.rejected
+}
+
+type cssFilter struct {
+	rejected []string
+}
+
+func (f *cssFilter) reject(format string, args ...any) {
+	f.rejected = append(f.rejected, fmt.Sprintf(format, args...))
+}
+
+func (f *cssFilter) rules(s string) string {
+	var b strings.Builder
+	for {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			break
+		}
+
+		open := scan(s, 0, "{;")
+		if open == len(s) {
+			f.reject("unterminated rule %q", shorten(s))
+			break
+		}
+		prelude := strings.TrimSpace(s[:open])
+		if s[open] == ';' {
+			// Statements like @import or @charset, or stray declarations
+			if name, _, _ := strings.Cut(prelude, " "); strings.HasPrefix(name, "@") {
+				f.reject("%s rule", strings.ToLower(name))
+			} else {
+				f.reject("%q", shorten(prelude))
+			}
+			s = s[open+1:]
+			continue
+		}
+
+		end := scan(s, open+1, "}")
+		body := s[open+1 : end]
+		s = s[min(end+1, len(s)):]
+
+		if strings.HasPrefix(prelude, "@") {
+			name, _, _ := strings.Cut(strings.ToLower(prelude), " ")
+			if !nestedAtRules[name] || !safeValue(prelude[len(name):]) {
+				f.reject("%s rule", name)
+				continue
+			}
+			if inner := f.rules(body); inner != "" {
+				fmt.Fprintf(&b, "%s{%s}\n", prelude, inner)
+			}
+			continue
+		}
+
+		if prelude == "" || strings.ContainsAny(prelude, "<@\\") {
+			f.reject("selector %q", shorten(prelude))
+			continue
+		}
+		if decls := f.declarations(body); decls != "" {
+			fmt.Fprintf(&b, "%s{%s}\n", prelude, decls)
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+func (f *cssFilter) declarations(s string) string {
+	var kept []string
+	for s != "" {
+		end := scan(s, 0, ";")
+		decl := strings.TrimSpace(s[:end])
+		s = s[min(end+1, len(s)):]
+		if decl == "" {
+			continue
+		}
+
+		name, value, ok := strings.Cut(decl, ":")
+		name = strings.ToLower(strings.TrimSpace(name))
+		value = strings.TrimSpace(value)
+		switch {
+		case !ok || value == "":
+			f.reject("declaration %q", shorten(decl))
+		case !allowedProperty(name):
+			f.reject("property %s", name)
+		case !safeValue(value):
+			f.reject("%s value %q", name, shorten(value))
+		default:
+			kept = append(kept, name+":"+value)
+		}
+	}
+	return strings.Join(kept, ";")
+}
+
+func allowedProperty(name string) bool {
+	if allowedProperties[name] {
+		return true
+	}
+	for _, prefix := range allowedPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func safeValue(value string) bool {
+	value = strings.ToLower(value)
+	for _, unsafe := range unsafeValues {
+		if strings.Contains(value, unsafe) {
+			return false
+		}
+	}
+	return !strings.ContainsAny(value, "{}")
+}
+
+// scan returns the index of the first byte from stops at or after i that is
+// outside quotes and parentheses, or len(s). Braces nest, so scanning for
+// "}" finds the one closing the current block.
+func scan(s string, i int, stops string) int {
+	var quote byte
+	parens, braces := 0, 0
+	for ; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '(':
+			parens++
+		case c == ')' && parens > 0:
+			parens--
+		case parens > 0:
+		case c == '}' && braces > 0:
+			braces--
+		case strings.IndexByte(stops, c) >= 0:
+			return i
+		case c == '{':
+			braces++
+		}
+	}
+	return len(s)
+}
+
+func shorten(s string) string {
+	if len(s) > 40 {
+		return s[:40] + "..."
+	}
+	return s
+}
@@ -0,0 +1,151 @@
+package sanitize
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// allowedElements are kept with their allowed attributes; attributes in
+// globalAttributes are allowed on all of them.
+var allowedElements = map[atom.Atom][]string{
+	atom.P: nil, atom.Div: nil, atom.Span: nil, atom.Br: nil, atom.Hr: nil,
+	atom.H1: nil, atom.H2: nil, atom.H3: nil, atom.H4: nil, atom.H5: nil, atom.H6: nil,
+	atom.Strong: nil, atom.B: nil, atom.Em: nil, atom.I: nil, atom.U: nil, atom.S: nil,
+	atom.Small: nil, atom.Mark: nil, atom.Blockquote: nil, atom.Pre: nil, atom.Code: nil,
+	atom.Ul: nil, atom.Ol: nil, atom.Li: nil,
+	atom.Section: nil, atom.Article: nil, atom.Header: nil, atom.Footer: nil,
+	atom.Figure: nil, atom.Figcaption: nil,
+	atom.Table: nil, atom.Thead: nil, atom.Tbody: nil, atom.Tr: nil,
+	atom.Th:  {"colspan", "rowspan"},
+	atom.Td:  {"colspan", "rowspan"},
+	atom.A:   {"href", "target", "rel"},
+	atom.Img: {"src", "alt", "width", "height", "loading"},
+}
+
+var globalAttributes = []string{"class", "id", "title", "lang", "dir", "style"}
+
+// droppedElements are removed together with everything inside them; other
+// elements that are not allowed are unwrapped and keep their content.
+var droppedElements = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Iframe: true, atom.Frame: true,
+	atom.Frameset: true, atom.Object: true, atom.Embed: true, atom.Applet: true,
+	atom.Template: true, atom.Noscript: true, atom.Svg: true, atom.Math: true,
+	atom.Form: true, atom.Input: true, atom.Button: true, atom.Textarea: true,
+	atom.Select: true, atom.Link: true, atom.Meta: true, atom.Base: true,
+	atom.Title: true, atom.Head: true,
+}
+
+var urlSchemes = map[string]bool{"http": true, "https": true, "mailto": true, "tel": true}
+
+// HTML keeps only an allowlisted subset of HTML: formatting, lists, tables,
+// links and images, without scripts, event handlers, forms, frames or
+// javascript: URLs. Style attributes go through CSS. It returns the clean
+// markup and a description of everything it took out.
+func HTML(input string) (string, []string) {
+	context := &html.Node{Type: html.ElementNode, Data: "div", DataAtom: atom.Div}
+	nodes, err := html.ParseFragment(strings.NewReader(input), context)
+	if err != nil {
+		return "", []string{"unparseable HTML: " + err.Error()}
+	}
+
+	s := &htmlSanitizer{}
+	var b strings.Builder
+	for _, n := range nodes {
+		for _, clean := range s.node(n) {
+			html.Render(&b, clean)
+		}
+	}
+	return b.String(), s.rejected
+}
+
+type htmlSanitizer struct {
+	rejected []string
+}
+
+func (s *htmlSanitizer) reject(format string, args ...any) {
+	s.rejected = append(s.rejected, fmt.Sprintf(format, args...))
+}
+
+// node returns what n becomes: itself cleaned, its cleaned children when it
+// is unwrapped, or nothing.
+func (s *htmlSanitizer) node(n *html.Node) []*html.Node {
+	switch n.Type {
+	case html.TextNode:
+		return []*html.Node{{Type: html.TextNode, Data: n.Data}}
+	case html.ElementNode:
+	default:
+		// Comments and doctypes
+		return nil
+	}
+
+	if droppedElements[n.DataAtom] {
+		s.reject("<%s> element", n.Data)
+		return nil
+	}
+
+	var children []*html.Node
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		children = append(children, s.node(c)...)
+	}
+
+	allowed, ok := allowedElements[n.DataAtom]
+	if !ok {
+		s.reject("<%s> element (content kept)", n.Data)
+		return children
+	}
+
+	clean := &html.Node{Type: html.ElementNode, Data: n.Data, DataAtom: n.DataAtom}
+	blank := false
+	for _, attr := range n.Attr {
+		key := strings.ToLower(attr.Key)
+		if attr.Namespace != "" || !(slices.Contains(globalAttributes, key) || slices.Contains(allowed, key)) {
+			s.reject("%s attribute on <%s>", attr.Key, n.Data)
+			continue
+		}
+
+		value := attr.Val
+		switch key {
+		case "href", "src":
+			if !safeURL(value) {
+				s.reject("%s=%q on <%s>", key, value, n.Data)
+				continue
+			}
+		case "style":
+			var rejected []string
+			value, rejected = Declarations(value)
+			for _, r := range rejected {
+				s.reject("%s in style attribute on <%s>", r, n.Data)
+			}
+			if value == "" {
+				continue
+			}
+		case "target":
+			blank = value == "_blank"
+		case "rel":
+			continue // set below
+		}
+		clean.Attr = append(clean.Attr, html.Attribute{Key: key, Val: value})
+	}
+	if n.DataAtom == atom.A && blank {
+		clean.Attr = append(clean.Attr, html.Attribute{Key: "rel", Val: "noopener noreferrer"})
+	}
+
+	for _, c := range children {
+		clean.AppendChild(c)
+	}
+	return []*html.Node{clean}
+}
+
+// safeURL allows relative URLs and absolute ones with a known scheme.
+func safeURL(raw string) bool {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return false
+	}
+	return u.Scheme == "" || urlSchemes[strings.ToLower(u.Scheme)]
+}
@@ -0,0 +1,42 @@
+package shipping
+
+import (
+	"context"
+	"errors"
+	"math"
+)
+
+var ErrNoRate = errors.New("carrier has no rate for this route")
+
+// RateRequest describes a parcel to price. Weight is in grams.
+type RateRequest struct {
+	Carrier     string
+	Service     string
+	Origin      string // postal code
+	Destination string // postal code
+	Weight      int
+}
+
+// Rater looks up what a carrier charges for a parcel. Implementations call
+// a carrier or aggregator API; StubRater stands in for one locally.
+type Rater interface {
+	Rate(ctx context.Context, req RateRequest) (float64, error)
+}
+
+// StubRater charges a fixed price per started kilogram on any route. It is
+// used until a real carrier integration is configured.
+type StubRater struct {
+	perKg float64
+}
+
+func NewStubRater(perKg float64) *StubRater {
+	return &StubRater{perKg: perKg}
+}
+
+func (r *StubRater) Rate(ctx context.Context, req RateRequest) (float64, error) {
+	if req.Origin == "" || req.Destination == "" {
+		return 0, ErrNoRate
+	}
+	kg := math.Ceil(float64(max(req.Weight, 1)) / 1000)
+	return kg * r.perKg, nil
+}
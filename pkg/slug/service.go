@@ -0,0 +1,37 @@
+package slug
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Make turns free text into a lowercase, dash separated slug. Accented
+// letters are folded to their ASCII base ("Kopi Susu Gula Aren" becomes
+// "kopi-susu-gula-aren", "Café" becomes "cafe"); anything else that is not a
+// letter or digit acts as a separator.
+func Make(text string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range norm.NFKD.String(text) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			continue
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(unicode.ToLower(r))
+			dash = false
+		default:
+			dash = true
+		}
+	}
+	return b.String()
+}
+
+// Valid reports whether s already is a well-formed slug.
+func Valid(s string) bool {
+	return s != "" && Make(s) == s
+}
@@ -0,0 +1,98 @@
+package spreadsheet
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ReadCSV parses a CSV file as exported by common spreadsheet programs: a
+// UTF-8 byte order mark is skipped and the delimiter is a comma, or a
+// semicolon when the header line uses those instead (Excel does so in
+// locales such as id-ID where the comma is the decimal separator). The
+// quote NewCSVWriter puts before formula-like text is removed again.
+func ReadCSV(data []byte) ([][]string, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	header := data
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		header = data[:i]
+	}
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	if bytes.Count(header, []byte(";")) > bytes.Count(header, []byte(",")) {
+		reader.Comma = ';'
+	}
+	reader.FieldsPerRecord = -1
+
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("invalid csv: %w", err)
+	}
+	for _, row := range rows {
+		for i, cell := range row {
+			if escaped(cell) {
+				row[i] = cell[1:]
+			}
+		}
+	}
+	return rows, nil
+}
+
+type csvWriter struct {
+	w *csv.Writer
+}
+
+// NewCSVWriter writes rows as comma separated values. Text that a
+// spreadsheet would evaluate as a formula is prefixed with a quote.
+func NewCSVWriter(w io.Writer) Writer {
+	return &csvWriter{w: csv.NewWriter(w)}
+}
+
+func (c *csvWriter) WriteRow(values []any) error {
+	record := make([]string, len(values))
+	for i, value := range values {
+		switch v := value.(type) {
+		case string:
+			record[i] = escapeFormula(v)
+		case float64:
+			record[i] = strconv.FormatFloat(v, 'f', -1, 64)
+		default:
+			record[i] = fmt.Sprint(v)
+		}
+	}
+	return c.w.Write(record)
+}
+
+func (c *csvWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// escapeFormula quotes text a spreadsheet would evaluate, and text that
+// would otherwise look quoted, so ReadCSV can undo it exactly.
+func escapeFormula(s string) string {
+	if formulaLike(s) || escaped(s) {
+		return "'" + s
+	}
+	return s
+}
+
+func formulaLike(s string) bool {
+	if s == "" {
+		return false
+	}
+	switch s[0] {
+	case '=', '+', '-', '@', '\t', '\r':
+		return true
+	}
+	return false
+}
+
+// escaped reports whether s went through escapeFormula.
+func escaped(s string) bool {
+	return strings.HasPrefix(s, "'") && (formulaLike(s[1:]) || escaped(s[1:]))
+}
@@ -0,0 +1,28 @@
+package spreadsheet
+
+import (
+	"bytes"
+	"errors"
+)
+
+var ErrUnsupportedFormat = errors.New("unsupported spreadsheet, upload a CSV or XLSX file")
+
+// Writer emits one row at a time so large exports can be streamed. Values
+// may be strings, integers, floats or bools; anything else is written with
+// fmt's %v.
+type Writer interface {
+	WriteRow(values []any) error
+	Close() error
+}
+
+// Parse reads every row of the first sheet of an XLSX workbook or of a CSV
+// file. The format is detected from the content, not the file name.
+func Parse(data []byte) ([][]string, error) {
+	if bytes.HasPrefix(data, []byte("PK\x03\x04")) {
+		return ReadXLSX(data)
+	}
+	if bytes.IndexByte(data, 0) >= 0 {
+		return nil, ErrUnsupportedFormat
+	}
+	return ReadCSV(data)
+}
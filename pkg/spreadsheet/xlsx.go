@@ -0,0 +1,407 @@
+package spreadsheet
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// maxPartSize caps how much a single part of a workbook may inflate to,
+// so a small upload cannot expand into gigabytes of XML.
+const maxPartSize = 64 << 20
+
+const relationshipsNS = "http://schemas.openxmlformats.org/officeDocument/2006/relationships"
+
+var errPartTooLarge = errors.New("invalid xlsx: workbook part too large")
+
+// ReadXLSX returns the cell text of the first worksheet of a workbook. Row
+// i of the result is spreadsheet row i+1; skipped rows come back empty.
+func ReadXLSX(data []byte) ([][]string, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid xlsx: %w", err)
+	}
+
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	var shared []string
+	if f, ok := files["xl/sharedStrings.xml"]; ok {
+		if shared, err = readSharedStrings(f); err != nil {
+			return nil, err
+		}
+	}
+
+	sheet, ok := files[firstSheetPath(files)]
+	if !ok {
+		return nil, errors.New("invalid xlsx: workbook has no worksheet")
+	}
+	return readSheet(sheet, shared)
+}
+
+func openPart(f *zip.File) (*xml.Decoder, func() error, error) {
+	if f.UncompressedSize64 > maxPartSize {
+		return nil, nil, errPartTooLarge
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid xlsx: %w", err)
+	}
+	return xml.NewDecoder(io.LimitReader(rc, maxPartSize)), rc.Close, nil
+}
+
+// firstSheetPath follows the workbook relationships to the first sheet,
+// falling back to the conventional location.
+func firstSheetPath(files map[string]*zip.File) string {
+	fallback := "xl/worksheets/sheet1.xml"
+
+	var workbook struct {
+		Sheets []struct {
+			Attrs []xml.Attr `xml:",any,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	var rels struct {
+		Relationships []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+	if decodePart(files["xl/workbook.xml"], &workbook) != nil || len(workbook.Sheets) == 0 {
+		return fallback
+	}
+	if decodePart(files["xl/_rels/workbook.xml.rels"], &rels) != nil {
+		return fallback
+	}
+
+	id := ""
+	for _, attr := range workbook.Sheets[0].Attrs {
+		if attr.Name.Space == relationshipsNS && attr.Name.Local == "id" {
+			id = attr.Value
+		}
+	}
+	for _, rel := range rels.Relationships {
+		if rel.ID != id {
+			continue
+		}
+		if strings.HasPrefix(rel.Target, "/") {
+			return strings.TrimPrefix(rel.Target, "/")
+		}
+		return path.Join("xl", rel.Target)
+	}
+	return fallback
+}
+
+func decodePart(f *zip.File, v any) error {
+	if f == nil {
+		return errors.New("missing part")
+	}
+	d, closePart, err := openPart(f)
+	if err != nil {
+		return err
+	}
+	defer closePart()
+	return d.Decode(v)
+}
+
+// readSharedStrings collects the string table. Rich text runs are joined
+// and phonetic hints are dropped.
+func readSharedStrings(f *zip.File) ([]string, error) {
+	d, closePart, err := openPart(f)
+	if err != nil {
+		return nil, err
+	}
+	defer closePart()
+
+	var shared []string
+	var text strings.Builder
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			return shared, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid xlsx shared strings: %w", err)
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "si":
+				text.Reset()
+			case "rPh":
+				if err := d.Skip(); err != nil {
+					return nil, fmt.Errorf("invalid xlsx shared strings: %w", err)
+				}
+			case "t":
+				s, err := readText(d)
+				if err != nil {
+					return nil, fmt.Errorf("invalid xlsx shared strings: %w", err)
+				}
+				text.WriteString(s)
+			}
+		case xml.EndElement:
+			if t.Name.Local == "si" {
+				shared = append(shared, text.String())
+			}
+		}
+	}
+}
+
+func readSheet(f *zip.File, shared []string) ([][]string, error) {
+	d, closePart, err := openPart(f)
+	if err != nil {
+		return nil, err
+	}
+	defer closePart()
+
+	var (
+		rows      [][]string
+		cellRef   string
+		cellType  string
+		cellValue string
+		inline    strings.Builder
+	)
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid xlsx sheet: %w", err)
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "row":
+				n := len(rows) + 1
+				if r := attr(t, "r"); r != "" {
+					if n, err = strconv.Atoi(r); err != nil || n < len(rows)+1 || n > 1<<20 {
+						return nil, fmt.Errorf("invalid xlsx sheet: bad row number %q", r)
+					}
+				}
+				for len(rows) < n {
+					rows = append(rows, nil)
+				}
+			case "c":
+				cellRef, cellType, cellValue = attr(t, "r"), attr(t, "t"), ""
+				inline.Reset()
+			case "v":
+				if cellValue, err = readText(d); err != nil {
+					return nil, fmt.Errorf("invalid xlsx sheet: %w", err)
+				}
+			case "t":
+				s, err := readText(d)
+				if err != nil {
+					return nil, fmt.Errorf("invalid xlsx sheet: %w", err)
+				}
+				inline.WriteString(s)
+			case "rPh":
+				if err := d.Skip(); err != nil {
+					return nil, fmt.Errorf("invalid xlsx sheet: %w", err)
+				}
+			}
+		case xml.EndElement:
+			if t.Name.Local != "c" || len(rows) == 0 {
+				continue
+			}
+
+			value := cellValue
+			switch cellType {
+			case "s":
+				i, err := strconv.Atoi(cellValue)
+				if err != nil || i < 0 || i >= len(shared) {
+					return nil, fmt.Errorf("invalid xlsx sheet: bad shared string %q", cellValue)
+				}
+				value = shared[i]
+			case "inlineStr":
+				value = inline.String()
+			case "b":
+				value = strconv.FormatBool(cellValue == "1")
+			}
+
+			row := rows[len(rows)-1]
+			col := len(row)
+			if cellRef != "" {
+				if col, err = columnIndex(cellRef); err != nil || col < len(row) {
+					return nil, fmt.Errorf("invalid xlsx sheet: bad cell reference %q", cellRef)
+				}
+			}
+			for len(row) < col {
+				row = append(row, "")
+			}
+			rows[len(rows)-1] = append(row, value)
+		}
+	}
+}
+
+// readText returns the character data of the element whose start tag was
+// just read, consuming its end tag.
+func readText(d *xml.Decoder) (string, error) {
+	var text strings.Builder
+	for depth := 1; depth > 0; {
+		tok, err := d.Token()
+		if err != nil {
+			return "", err
+		}
+		switch t := tok.(type) {
+		case xml.CharData:
+			text.Write(t)
+		case xml.StartElement:
+			depth++
+		case xml.EndElement:
+			depth--
+		}
+	}
+	return text.String(), nil
+}
+
+func attr(el xml.StartElement, name string) string {
+	for _, a := range el.Attr {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// columnIndex turns the letters of a reference such as "AB12" into a
+// zero-based column index.
+func columnIndex(ref string) (int, error) {
+	col := 0
+	i := 0
+	for ; i < len(ref) && ref[i] >= 'A' && ref[i] <= 'Z'; i++ {
+		col = col*26 + int(ref[i]-'A'+1)
+		if col > 16384 {
+			return 0, errors.New("column out of range")
+		}
+	}
+	if i == 0 {
+		return 0, errors.New("missing column")
+	}
+	return col - 1, nil
+}
+
+func columnName(i int) string {
+	name := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+	return name
+}
+
+const (
+	xlsxContentTypes = xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`</Types>`
+	xlsxRels = xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`
+	xlsxWorkbook = xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="` + relationshipsNS + `">` +
+		`<sheets><sheet name="%s" sheetId="1" r:id="rId1"/></sheets>` +
+		`</workbook>`
+	xlsxWorkbookRels = xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`</Relationships>`
+	xlsxSheetStart = xml.Header + `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`
+	xlsxSheetEnd   = `</sheetData></worksheet>`
+)
+
+type xlsxWriter struct {
+	zw    *zip.Writer
+	sheet *bufio.Writer
+	row   int
+}
+
+// NewXLSXWriter streams a single-sheet workbook to w. Strings are written
+// inline so no shared string table has to be held in memory.
+func NewXLSXWriter(w io.Writer, sheetName string) (Writer, error) {
+	zw := zip.NewWriter(w)
+
+	var name bytes.Buffer
+	xml.EscapeText(&name, []byte(sheetName))
+
+	parts := []struct{ name, body string }{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRels},
+		{"xl/workbook.xml", fmt.Sprintf(xlsxWorkbook, name.String())},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels},
+	}
+	for _, part := range parts {
+		pw, err := zw.Create(part.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(pw, part.body); err != nil {
+			return nil, err
+		}
+	}
+
+	sw, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	sheet := bufio.NewWriter(sw)
+	if _, err := sheet.WriteString(xlsxSheetStart); err != nil {
+		return nil, err
+	}
+
+	return &xlsxWriter{zw: zw, sheet: sheet}, nil
+}
+
+func (x *xlsxWriter) WriteRow(values []any) error {
+	x.row++
+	fmt.Fprintf(x.sheet, `<row r="%d">`, x.row)
+	for i, value := range values {
+		ref := columnName(i) + strconv.Itoa(x.row)
+		switch v := value.(type) {
+		case bool:
+			b := 0
+			if v {
+				b = 1
+			}
+			fmt.Fprintf(x.sheet, `<c r="%s" t="b"><v>%d</v></c>`, ref, b)
+		case int:
+			fmt.Fprintf(x.sheet, `<c r="%s"><v>%d</v></c>`, ref, v)
+		case int64:
+			fmt.Fprintf(x.sheet, `<c r="%s"><v>%d</v></c>`, ref, v)
+		case float64:
+			fmt.Fprintf(x.sheet, `<c r="%s"><v>%s</v></c>`, ref, strconv.FormatFloat(v, 'f', -1, 64))
+		default:
+			s, ok := v.(string)
+			if !ok {
+				s = fmt.Sprint(v)
+			}
+			if s == "" {
+				continue
+			}
+			fmt.Fprintf(x.sheet, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">`, ref)
+			xml.EscapeText(x.sheet, []byte(s))
+			x.sheet.WriteString(`</t></is></c>`)
+		}
+	}
+	_, err := x.sheet.WriteString(`</row>`)
+	return err
+}
+
+func (x *xlsxWriter) Close() error {
+	if _, err := x.sheet.WriteString(xlsxSheetEnd); err != nil {
+		return err
+	}
+	if err := x.sheet.Flush(); err != nil {
+		return err
+	}
+	return x.zw.Close()
+}
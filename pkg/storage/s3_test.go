@@ -0,0 +1,207 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeS3 is a minimal stand-in for an S3-compatible server such as MinIO.
+// It keeps objects in memory and only accepts requests whose SigV4
+// signature matches its own secret key.
+type fakeS3 struct {
+	secretKey string
+
+	mu      sync.Mutex
+	objects map[string]fakeObject
+}
+
+type fakeObject struct {
+	data        []byte
+	contentType string
+}
+
+func newFakeS3(t *testing.T, secretKey string) (*fakeS3, *httptest.Server) {
+	f := &fakeS3{secretKey: secretKey, objects: make(map[string]fakeObject)}
+	server := httptest.NewServer(f)
+	t.Cleanup(server.Close)
+	return f, server
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !f.verify(r) {
+		http.Error(w, "SignatureDoesNotMatch", http.StatusForbidden)
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	key := r.URL.EscapedPath()
+	switch r.Method {
+	case http.MethodPut:
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		f.objects[key] = fakeObject{data: data, contentType: r.Header.Get("Content-Type")}
+	case http.MethodGet:
+		object, ok := f.objects[key]
+		if !ok {
+			http.Error(w, "NoSuchKey", http.StatusNotFound)
+			return
+		}
+		w.Write(object.data)
+	case http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// verify recomputes the signature from the request as received, the way
+// the server side of SigV4 does.
+func (f *fakeS3) verify(r *http.Request) bool {
+	auth := strings.TrimPrefix(r.Header.Get("Authorization"), s3Algorithm+" ")
+	fields := make(map[string]string)
+	for _, part := range strings.Split(auth, ", ") {
+		name, value, _ := strings.Cut(part, "=")
+		fields[name] = value
+	}
+	credential := strings.SplitN(fields["Credential"], "/", 2)
+	if len(credential) != 2 {
+		return false
+	}
+	now, err := time.Parse("20060102T150405Z", r.Header.Get("X-Amz-Date"))
+	if err != nil {
+		return false
+	}
+
+	names := strings.Split(fields["SignedHeaders"], ";")
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		value := r.Header.Get(name)
+		if name == "host" {
+			value = r.Host
+		}
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(value) + "\n")
+	}
+	canonicalRequest := strings.Join([]string{
+		r.Method,
+		r.URL.EscapedPath(),
+		canonicalQuery(r.URL.Query()),
+		canonicalHeaders.String(),
+		strings.Join(names, ";"),
+		r.Header.Get("X-Amz-Content-Sha256"),
+	}, "\n")
+
+	region := strings.Split(credential[1], "/")[1]
+	server := NewS3Store(S3Config{Region: region, SecretKey: f.secretKey})
+	want := server.signature(now, credential[1], canonicalRequest)
+	return fields["Signature"] == want
+}
+
+func (f *fakeS3) object(path string) (fakeObject, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	object, ok := f.objects[path]
+	return object, ok
+}
+
+func TestS3StorePutGetDelete(t *testing.T) {
+	fake, server := newFakeS3(t, "secret")
+	store := NewS3Store(S3Config{
+		Endpoint:  server.URL + "/",
+		Bucket:    "umkm",
+		AccessKey: "access",
+		SecretKey: "secret",
+		PublicURL: "https://cdn.example.com/umkm/",
+	})
+	ctx := context.Background()
+
+	key := "products/1/foto produk.png"
+	data := []byte("image bytes")
+	if err := store.Put(ctx, key, strings.NewReader(string(data)), int64(len(data)), "image/png"); err != nil {
+		t.Fatalf("Put: %s", err)
+	}
+
+	object, ok := fake.object("/umkm/products/1/foto%20produk.png")
+	if !ok {
+		t.Fatal("Put did not store the object at the path-style key")
+	}
+	if object.contentType != "image/png" {
+		t.Errorf("content type = %q, want image/png", object.contentType)
+	}
+
+	got, err := store.Get(ctx, key)
+	if err != nil {
+		t.Fatalf("Get: %s", err)
+	}
+	if string(got) != string(data) {
+		t.Errorf("Get = %q, want %q", got, data)
+	}
+
+	if err := store.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete: %s", err)
+	}
+	if _, ok := fake.object("/umkm/products/1/foto%20produk.png"); ok {
+		t.Error("Delete left the object behind")
+	}
+	if _, err := store.Get(ctx, key); err == nil {
+		t.Error("Get after Delete succeeded, want an error")
+	}
+}
+
+func TestS3StoreRejectedSignature(t *testing.T) {
+	_, server := newFakeS3(t, "secret")
+	store := NewS3Store(S3Config{
+		Endpoint:  server.URL,
+		Bucket:    "umkm",
+		AccessKey: "access",
+		SecretKey: "wrong",
+		PublicURL: "https://cdn.example.com/umkm",
+	})
+
+	err := store.Put(context.Background(), "a.png", strings.NewReader("x"), 1, "image/png")
+	if err == nil || !strings.Contains(err.Error(), "403") {
+		t.Fatalf("Put with the wrong secret = %v, want a 403 error", err)
+	}
+}
+
+func TestS3StoreURL(t *testing.T) {
+	tests := []struct {
+		publicURL string
+		key       string
+		want      string
+		wantErr   error
+	}{
+		{"https://cdn.example.com/umkm", "stores/1/logo.png", "https://cdn.example.com/umkm/stores/1/logo.png", nil},
+		{"http://localhost:9000/umkm/", "a b.png", "http://localhost:9000/umkm/a%20b.png", nil},
+		{"", "stores/1/logo.png", "", ErrNoPublicURL},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%q", tt.publicURL), func(t *testing.T) {
+			store := NewS3Store(S3Config{Endpoint: "http://localhost:9000", Bucket: "umkm", PublicURL: tt.publicURL})
+
+			got, err := store.URL(context.Background(), tt.key)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("URL error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("URL = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
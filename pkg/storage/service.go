@@ -0,0 +1,15 @@
+package storage
+
+import (
+	"context"
+	"io"
+)
+
+// BlobStore persists uploaded files under a key, reads them back and hands
+// out URLs that clients can fetch them from.
+type BlobStore interface {
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) ([]byte, error)
+	Delete(ctx context.Context, key string) error
+	URL(ctx context.Context, key string) (string, error)
+}